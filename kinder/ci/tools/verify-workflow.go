@@ -37,7 +37,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("error: failed to create workflow: %v\n", err)
 	}
-	if err := w.Run(io.Discard, true, false, true, "ARTIFACTS"); err != nil {
+	if err := w.Run(io.Discard, true, false, true, ktestworkflow.FailFast, "ARTIFACTS"); err != nil {
 		log.Fatalf("error: failed to run workflow: %v\n", err)
 	}
 	log.Infof("%s OK", file)
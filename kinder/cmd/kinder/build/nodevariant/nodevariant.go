@@ -35,6 +35,10 @@ type flagpole struct {
 	Kubelet                 string
 	PrePullAdditionalImages bool
 	Path                    []string
+	KubeletDropin           string
+	ContainerdConfig        string
+	ExcludeImages           []string
+	PullConcurrency         int
 }
 
 // NewCommand returns a new cobra.Command for building the node image
@@ -100,6 +104,26 @@ func NewCommand() *cobra.Command {
 		nil,
 		"sourcePath:destPath pairs; copies file/dir at sourcePath on the host to destPath inside the image, destPath has to be absolute",
 	)
+	cmd.Flags().StringVar(
+		&flags.KubeletDropin, "with-kubelet-dropin",
+		"",
+		"path to a systemd drop-in file (.conf) for the kubelet service to be added to the image",
+	)
+	cmd.Flags().StringVar(
+		&flags.ContainerdConfig, "with-containerd-config",
+		"",
+		"path to a containerd config.toml fragment, e.g. with registry mirrors, to be merged into the containerd config of the image",
+	)
+	cmd.Flags().StringSliceVar(
+		&flags.ExcludeImages, "exclude-images",
+		nil,
+		"Kubernetes images to be skipped when adding artifacts to the image, e.g. kube-proxy; this allows to provide a custom image instead",
+	)
+	cmd.Flags().IntVar(
+		&flags.PullConcurrency, "pull-concurrency",
+		1,
+		"number of kubeadm additional images to be pulled in parallel on the host; images are imported into the image sequentially",
+	)
 	return cmd
 }
 
@@ -118,6 +142,10 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
 		// bits options
 		alter.WithImageNamePrefix(flags.ImageNamePrefix),
 		alter.WithPath(flags.Path),
+		alter.WithKubeletDropin(flags.KubeletDropin),
+		alter.WithContainerdConfig(flags.ContainerdConfig),
+		alter.WithExcludeImages(flags.ExcludeImages),
+		alter.WithPullConcurrency(flags.PullConcurrency),
 	)
 	if err != nil {
 		return errors.Wrap(err, "error creating alter context")
@@ -38,6 +38,7 @@ type flagpole struct {
 	ExternalEtcd         bool
 	ExternalLoadBalancer bool
 	Volumes              []string
+	NodeCRIs             map[string]string
 }
 
 // NewCommand returns a new cobra.Command for cluster creation
@@ -93,6 +94,12 @@ func NewCommand() *cobra.Command {
 		"volume", nil,
 		"mount a volume on node containers",
 	)
+	cmd.Flags().StringToStringVar(
+		&flags.NodeCRIs,
+		"node-cri", nil,
+		"the container runtime to be used on a K8s node, e.g. worker-1=containerd; the container runtime must be installed in the node image. "+
+			"If not set, the container runtime detected in the node image is used",
+	)
 
 	cmd.MarkFlagRequired("image")
 
@@ -116,6 +123,7 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
 		manager.ExternalEtcd(flags.ExternalEtcd),
 		manager.Retain(flags.Retain),
 		manager.Volumes(flags.Volumes),
+		manager.NodeCRI(flags.NodeCRIs),
 	); err != nil {
 		return errors.Wrap(err, "failed to create cluster")
 	}
@@ -18,16 +18,19 @@ package do
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	K8sVersion "k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/kubeadm/kinder/pkg/cluster/manager"
 	"k8s.io/kubeadm/kinder/pkg/cluster/manager/actions"
 	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
 )
 
 type flagpole struct {
@@ -36,7 +39,7 @@ type flagpole struct {
 	UpgradeVersion        string
 	CopyCerts             string
 	Discovery             string
-	OnlyNode              string
+	OnlyNode              []string
 	DryRun                bool
 	VLevel                int
 	PatchesDir            string
@@ -45,6 +48,21 @@ type flagpole struct {
 	KubeadmConfigVersion  string
 	FeatureGate           string
 	EncryptionAlgorithm   string
+	CRISocket             string
+	DNSDomain             string
+	SkipKubeProxy         bool
+	APIServerCertSANs     []string
+	EtcdSnapshot          string
+	AuditPolicy           string
+	ResetCleanupTmpDir    bool
+	ResetCertificatesDir  string
+	NetworkChaos          string
+	NetworkLatency        time.Duration
+	NetworkChaosPeers     []string
+	CoreDNSCorefile       string
+	CoreDNSImageRepo      string
+	CoreDNSImageTag       string
+	CommandHistory        string
 }
 
 // NewCommand returns a new cobra.Command for exec
@@ -69,9 +87,9 @@ func NewCommand() *cobra.Command {
 		&flags.Name,
 		"name", constants.DefaultClusterName, "cluster name",
 	)
-	cmd.Flags().StringVar(&flags.OnlyNode,
+	cmd.Flags().StringSliceVar(&flags.OnlyNode,
 		"only-node",
-		"", "exec the action only on the selected node",
+		nil, "exec the action only on the selected nodes; nodes can be specified with or without the cluster name prefix",
 	)
 	cmd.Flags().BoolVar(
 		&flags.DryRun,
@@ -110,17 +128,17 @@ func NewCommand() *cobra.Command {
 	cmd.Flags().StringVar(
 		&flags.PatchesDir,
 		"patches", flags.PatchesDir,
-		"the patches directory to be used for init, join and upgrade",
+		"the patches directory to be used for init, join, upgrade and for staging templated patches",
 	)
 	cmd.Flags().StringVar(
 		&flags.IgnorePreflightErrors,
 		"ignore-preflight-errors", constants.KubeadmIgnorePreflightErrors,
-		"list of kubeadm preflight errors to skip",
+		"list of kubeadm preflight errors to skip; names are validated against the preflight checks known for the kubeadm version on each node",
 	)
 	cmd.Flags().StringVar(
 		&flags.KubeadmConfigVersion,
 		"kubeadm-config-version", flags.KubeadmConfigVersion,
-		"the kubeadm config version to be used for init, join and upgrade. "+
+		"the kubeadm config version to be used for init, join, upgrade and reset. "+
 			"If not set, kubeadm will automatically choose the kubeadm config version "+
 			"according to the Kubernetes version in use",
 	)
@@ -134,6 +152,84 @@ func NewCommand() *cobra.Command {
 		"kubeadm-encryption-algorithm", "",
 		"the encryption algorithm used by kubeadm for private keys in the cluster",
 	)
+	cmd.Flags().StringVar(
+		&flags.CRISocket,
+		"cri-socket", "",
+		"the CRI socket to be used for init, join and reset, e.g. unix:///run/containerd/containerd.sock; "+
+			"if not set, the default CRI socket of the CRI installed on the nodes is used",
+	)
+	cmd.Flags().StringVar(
+		&flags.DNSDomain,
+		"dns-domain", "",
+		"the DNS domain used by services in the cluster created by init; if not set, cluster.local is used",
+	)
+	cmd.Flags().BoolVar(
+		&flags.SkipKubeProxy,
+		"skip-kube-proxy", false,
+		"skip the kube-proxy addon during init and upgrade, e.g. for testing CNI plugins replacing kube-proxy; "+
+			"skipping kube-proxy during upgrade requires kubeadm config version v1beta4",
+	)
+	cmd.Flags().StringSliceVar(
+		&flags.APIServerCertSANs,
+		"apiserver-cert-sans", nil,
+		"additional Subject Alternative Names, IP addresses or DNS names, for the API server serving certificate "+
+			"generated by init; the SANs are verified on the control plane nodes after init and join",
+	)
+	cmd.Flags().StringVar(
+		&flags.EtcdSnapshot,
+		"etcd-snapshot", "",
+		"the path on the host of the etcd snapshot to be saved by etcd-snapshot or restored by etcd-restore",
+	)
+	cmd.Flags().StringVar(
+		&flags.AuditPolicy,
+		"audit-policy", "",
+		"the path on the host of the audit policy used by audit-logging; if not set, the metadata of all the requests is logged",
+	)
+	cmd.Flags().BoolVar(
+		&flags.ResetCleanupTmpDir,
+		"reset-cleanup-tmp-dir", false,
+		"instruct kubeadm reset to cleanup the /etc/kubernetes/tmp directory; requires kubeadm config version v1beta4",
+	)
+	cmd.Flags().StringVar(
+		&flags.ResetCertificatesDir,
+		"reset-certificates-dir", "",
+		"the directory where the certificates to be removed by kubeadm reset are stored; requires kubeadm config version v1beta4",
+	)
+	cmd.Flags().StringVar(
+		&flags.NetworkChaos,
+		"network-chaos", string(actions.NetworkPartition),
+		fmt.Sprintf("the network chaos injected by network-chaos. Use one of %s", actions.KnownNetworkChaosMode()),
+	)
+	cmd.Flags().DurationVar(
+		&flags.NetworkLatency,
+		"network-latency", 0,
+		"the latency added by network-chaos in latency mode; if not set, 200ms is used",
+	)
+	cmd.Flags().StringSliceVar(
+		&flags.NetworkChaosPeers,
+		"network-chaos-peers", nil,
+		"the nodes affected by network-chaos; if not set, all the other K8s nodes are affected",
+	)
+	cmd.Flags().StringVar(
+		&flags.CoreDNSCorefile,
+		"coredns-corefile", "",
+		"the path on the host of the Corefile used by configure-coredns",
+	)
+	cmd.Flags().StringVar(
+		&flags.CoreDNSImageRepo,
+		"coredns-image-repository", "",
+		"the repository of the CoreDNS image used by configure-coredns, e.g. docker.io/coredns",
+	)
+	cmd.Flags().StringVar(
+		&flags.CoreDNSImageTag,
+		"coredns-image-tag", "",
+		"the tag of the CoreDNS image used by configure-coredns, e.g. v1.11.3",
+	)
+	cmd.Flags().StringVar(
+		&flags.CommandHistory,
+		"command-history", "",
+		"the path of a file where the commands run on the nodes are written as a shell script, also if the action fails",
+	)
 	return cmd
 }
 
@@ -157,15 +253,32 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
+	networkChaos := actions.NetworkChaosMode(strings.ToLower(flags.NetworkChaos))
+	if err := actions.ValidateNetworkChaosMode(networkChaos); err != nil {
+		return err
+	}
+
+	if flags.CRISocket != "" {
+		if err := kubeadm.ValidateCRISocket(flags.CRISocket); err != nil {
+			return err
+		}
+	}
+
+	if flags.DNSDomain != "" {
+		if err := kubeadm.ValidateDNSDomain(flags.DNSDomain); err != nil {
+			return err
+		}
+	}
+
 	// get a kinder cluster manager
 	o, err := manager.NewClusterManager(flags.Name)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create a kinder cluster manager for %s", flags.Name)
 	}
 
-	// eventually, instruct the cluster manager to run only commands on one node
-	if flags.OnlyNode != "" {
-		if err := o.OnlyNode(flags.OnlyNode); err != nil {
+	// eventually, instruct the cluster manager to run only commands on the selected nodes
+	if len(flags.OnlyNode) > 0 {
+		if err := o.OnlyNodes(flags.OnlyNode...); err != nil {
 			return err
 		}
 	}
@@ -177,6 +290,17 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) (err error) {
 		flags.Wait = 0
 	}
 
+	// eventually, instruct the cluster manager to record the commands run on the nodes, and write them
+	// into the command history file after the action is completed, also if the action fails
+	if flags.CommandHistory != "" {
+		o.RecordCommandHistory()
+		defer func() {
+			if historyErr := writeCommandHistory(o, flags.CommandHistory); historyErr != nil && err == nil {
+				err = historyErr
+			}
+		}()
+	}
+
 	// executed the requested action
 	action := args[0]
 	err = o.DoAction(action,
@@ -191,6 +315,19 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) (err error) {
 		actions.KubeadmConfigVersion(flags.KubeadmConfigVersion),
 		actions.FeatureGate(flags.FeatureGate),
 		actions.EncryptionAlgorithm(flags.EncryptionAlgorithm),
+		actions.CRISocket(flags.CRISocket),
+		actions.DNSDomain(flags.DNSDomain),
+		actions.SkipKubeProxy(flags.SkipKubeProxy),
+		actions.APIServerCertSANs(flags.APIServerCertSANs),
+		actions.EtcdSnapshotPath(flags.EtcdSnapshot),
+		actions.AuditPolicy(flags.AuditPolicy),
+		actions.ResetConfig(kubeadm.ResetConfigData{
+			CleanupTmpDir:   flags.ResetCleanupTmpDir,
+			CRISocket:       flags.CRISocket,
+			CertificatesDir: flags.ResetCertificatesDir,
+		}),
+		actions.NetworkChaosSettings(networkChaos, flags.NetworkLatency, flags.NetworkChaosPeers),
+		actions.CoreDNSSettings(flags.CoreDNSCorefile, flags.CoreDNSImageRepo, flags.CoreDNSImageTag),
 	)
 	if err != nil {
 		return errors.Wrapf(err, "failed to exec action %s", action)
@@ -198,3 +335,19 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) (err error) {
 
 	return nil
 }
+
+// writeCommandHistory writes the commands run on the nodes into the given file as a shell script
+func writeCommandHistory(o *manager.ClusterManager, path string) error {
+	// NB. the file is readable only by the owner, because commands might include sensitive data
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the command history file %s", path)
+	}
+	defer f.Close()
+
+	if err := o.DumpCommandHistory(f); err != nil {
+		return err
+	}
+	log.Infof("Command history written to %s", path)
+	return nil
+}
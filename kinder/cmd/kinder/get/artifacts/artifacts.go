@@ -17,6 +17,7 @@ limitations under the License.
 package artifacts
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -27,17 +28,43 @@ import (
 )
 
 const (
-	onlyKubeadmFlagName  = "only-kubeadm"
-	onlyKubeletFlagName  = "only-kubelet"
-	onlyBinariesFlagName = "only-binaries"
-	onlyImagesFLagName   = "only-images"
+	onlyKubeadmFlagName     = "only-kubeadm"
+	onlyKubeletFlagName     = "only-kubelet"
+	onlyBinariesFlagName    = "only-binaries"
+	onlyImagesFLagName      = "only-images"
+	writeChecksumsFlagName  = "write-checksums"
+	digestPinningFlagName   = "digest-pinning"
+	cacheDirFlagName        = "cache-dir"
+	versionOnlyFlagName     = "version-only"
+	mirrorFlagName          = "mirror"
+	imageRepositoryFlagName = "image-repository"
+	httpHeaderFlagName      = "http-header"
+	metadataFlagName        = "metadata"
+	verifyVersionFlagName   = "verify-version"
+	conformanceFlagName     = "conformance-image"
+	attestationsFlagName    = "attestations"
+	attestationTypeFlagName = "require-attestation"
+	manifestFlagName        = "manifest"
 )
 
 type flagpole struct {
-	OnlyKubeadm  bool
-	OnlyKubelet  bool
-	OnlyBinaries bool
-	OnlyImages   bool
+	OnlyKubeadm      bool
+	OnlyKubelet      bool
+	OnlyBinaries     bool
+	OnlyImages       bool
+	WriteChecksums   bool
+	DigestPinning    bool
+	CacheDir         string
+	VersionOnly      bool
+	Mirrors          []string
+	ImageRepository  string
+	HTTPHeaders      []string
+	Metadata         bool
+	VerifyVersion    bool
+	Conformance      bool
+	Attestations     bool
+	AttestationTypes []string
+	Manifest         string
 }
 
 // NewCommand returns a new cobra.Command for exec
@@ -80,6 +107,70 @@ func NewCommand() *cobra.Command {
 		onlyImagesFLagName, false,
 		"Gets only the kube-apiserver, kube-scheduler, kube-controller-manager and kube-proxy image tarballs (instead of all artifacts)",
 	)
+	cmd.Flags().BoolVar(&flags.WriteChecksums,
+		writeChecksumsFlagName, false,
+		"Writes a SHA256SUMS file with the digest of each artifact into the destination path",
+	)
+	cmd.Flags().BoolVar(&flags.DigestPinning,
+		digestPinningFlagName, false,
+		"Writes an IMAGEDIGESTS file with the digest-pinned reference of each image tarball into the destination path; "+
+			"supported only for release and ci builds",
+	)
+	cmd.Flags().StringVar(&flags.CacheDir,
+		cacheDirFlagName, "",
+		"Caches the artifacts downloaded from release and ci builds in the given folder, and re-uses them in the following runs; "+
+			"if empty, the cache is bypassed",
+	)
+	cmd.Flags().BoolVar(&flags.VersionOnly,
+		versionOnlyFlagName, false,
+		"Prints the Kubernetes version of the source without getting any artifact; "+
+			"this can be used for checking the source is reachable",
+	)
+	cmd.Flags().StringSliceVar(&flags.Mirrors,
+		mirrorFlagName, nil,
+		"Base URL of a mirror to be used if downloading from the source fails; mirrors are tried in the given order. "+
+			"For release and ci builds, the mirror replaces the release or ci build repository, e.g. https://mirror.example.com/release",
+	)
+	cmd.Flags().StringVar(&flags.ImageRepository,
+		imageRepositoryFlagName, "",
+		"Rewrites the repository of each image tarball, replacing the registry and the path of each image with the given "+
+			"image repository, e.g. registry.example.com/k8s",
+	)
+	cmd.Flags().StringArrayVar(&flags.HTTPHeaders,
+		httpHeaderFlagName, nil,
+		"HTTP header to be added to the requests for remote sources and mirrors whose host matches a pattern, "+
+			"e.g. \"dl.example.com=Authorization: Bearer <token>\" or \"*.example.com=X-Token: <token>\"; the flag can be repeated",
+	)
+	cmd.Flags().BoolVar(&flags.Metadata,
+		metadataFlagName, false,
+		"Writes a METADATA file with the resolved version and the commit of the build into the destination path, "+
+			"together with the build metadata files, if available; supported only for release and ci builds",
+	)
+	cmd.Flags().BoolVar(&flags.VerifyVersion,
+		verifyVersionFlagName, false,
+		"Checks that the version file published with the build matches the requested version; "+
+			"supported only for release and ci builds",
+	)
+	cmd.Flags().BoolVar(&flags.Conformance,
+		conformanceFlagName, false,
+		"Gets also the conformance image tarball, if shipped with the requested version; "+
+			"supported only for release and ci builds",
+	)
+	cmd.Flags().BoolVar(&flags.Attestations,
+		attestationsFlagName, false,
+		"Gets also the attestations of each image tarball, e.g. SBOMs, using the registry referrers API, and writes an "+
+			"ATTESTATIONS file listing them into the destination path; supported only for release and ci builds",
+	)
+	cmd.Flags().StringSliceVar(&flags.AttestationTypes,
+		attestationTypeFlagName, nil,
+		"Artifact type of an attestation required for each image tarball, e.g. application/spdx+json; "+
+			"getting the artifacts fails if a required attestation is missing. Implies --"+attestationsFlagName,
+	)
+	cmd.Flags().StringVar(&flags.Manifest,
+		manifestFlagName, "",
+		"Writes a JSON manifest describing the extraction, with the resolved version and the path, size and checksum of each "+
+			"artifact, into the given path; relative paths are relative to the destination path",
+	)
 
 	return cmd
 }
@@ -98,13 +189,47 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
 		dst = args[1]
 	}
 
+	if flags.VersionOnly {
+		version, err := extract.ExtractVersionOnly(src)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the version for %s", src)
+		}
+		fmt.Printf("v%s\n", version)
+		return nil
+	}
+
 	// Build an artifact extractor customized with the command options
-	e := extract.NewExtractor(src, dst,
+	options := []extract.Option{
 		extract.OnlyKubeadm(flags.OnlyKubeadm),
 		extract.OnlyKubelet(flags.OnlyKubelet),
 		extract.OnlyKubernetesBinaries(flags.OnlyBinaries),
 		extract.OnlyKubernetesImages(flags.OnlyImages),
-	)
+		extract.WithWriteChecksums(flags.WriteChecksums),
+		extract.WithDigestPinning(flags.DigestPinning),
+		extract.WithCacheDir(flags.CacheDir),
+		extract.WithMirrors(flags.Mirrors),
+		extract.WithImageRepository(flags.ImageRepository),
+		extract.WithMetadata(flags.Metadata),
+		extract.WithVerifyVersion(flags.VerifyVersion),
+		extract.WithConformanceImage(flags.Conformance),
+		extract.WithAttestations(flags.Attestations),
+		extract.WithManifest(flags.Manifest),
+	}
+	if len(flags.AttestationTypes) > 0 {
+		options = append(options, extract.WithAttestationVerifier(extract.RequireAttestations(flags.AttestationTypes...)))
+	}
+	for _, h := range flags.HTTPHeaders {
+		hostHeader := strings.SplitN(h, "=", 2)
+		if len(hostHeader) != 2 || strings.TrimSpace(hostHeader[0]) == "" {
+			return errors.Errorf("invalid --%s %q, it must be in the \"HostPattern=Key: Value\" format", httpHeaderFlagName, h)
+		}
+		kv := strings.SplitN(hostHeader[1], ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return errors.Errorf("invalid --%s %q, it must be in the \"HostPattern=Key: Value\" format", httpHeaderFlagName, h)
+		}
+		options = append(options, extract.WithHTTPHeader(strings.TrimSpace(hostHeader[0]), strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])))
+	}
+	e := extract.NewExtractor(src, dst, options...)
 
 	// Extracts the artifacts from the source
 	_, err := e.Extract()
@@ -21,6 +21,7 @@ import (
 
 	"k8s.io/kubeadm/kinder/cmd/kinder/get/artifacts"
 	"k8s.io/kubeadm/kinder/cmd/kinder/get/clusters"
+	"k8s.io/kubeadm/kinder/cmd/kinder/get/kubeadmconfig"
 	"k8s.io/kubeadm/kinder/cmd/kinder/get/kubeconfigpath"
 	"k8s.io/kubeadm/kinder/cmd/kinder/get/nodes"
 )
@@ -30,8 +31,8 @@ func NewCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Args:  cobra.NoArgs,
 		Use:   "get",
-		Short: "Gets one of [clusters, nodes, kubeconfig-path, artifacts]",
-		Long:  "Gets one of [clusters, nodes, kubeconfig-path, artifacts]",
+		Short: "Gets one of [clusters, nodes, kubeconfig-path, artifacts, kubeadm-config]",
+		Long:  "Gets one of [clusters, nodes, kubeconfig-path, artifacts, kubeadm-config]",
 	}
 
 	cmd.AddCommand(clusters.NewCommand())
@@ -42,5 +43,6 @@ func NewCommand() *cobra.Command {
 
 	// add kinder only commands
 	cmd.AddCommand(artifacts.NewCommand())
+	cmd.AddCommand(kubeadmconfig.NewCommand())
 	return cmd
 }
@@ -0,0 +1,191 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadmconfig
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+
+	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
+)
+
+type flagpole struct {
+	Name              string
+	KubernetesVersion string
+	ConfigVersion     string
+	NodeAddress       string
+	ControlPlane      bool
+	IPv6              bool
+	ResolvConf        string
+	ClusterDNS        []string
+	FailSwapOn        bool
+	SwapBehavior      string
+	CertSANs          []string
+	CoreDNSImageRepo  string
+	CoreDNSImageTag   string
+}
+
+// NewCommand returns a new cobra.Command for rendering the kubeadm config generated by kinder
+func NewCommand() *cobra.Command {
+	flags := &flagpole{}
+
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "kubeadm-config",
+		Short: "Prints the kubeadm config generated by kinder, without requiring a cluster",
+		Long: "Prints the kubeadm config generated by kinder, without requiring a cluster.\n\n" +
+			"Node specific settings, e.g. the node address, are replaced by the values passed via flags.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(flags, cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&flags.Name,
+		"name", constants.DefaultClusterName, "cluster name",
+	)
+	cmd.Flags().StringVar(
+		&flags.KubernetesVersion,
+		"kubernetes-version", "", "the Kubernetes version to be used in the kubeadm config",
+	)
+	cmd.Flags().StringVar(
+		&flags.ConfigVersion,
+		"kubeadm-config-version", "", "the kubeadm config API version; if empty, it is derived from the Kubernetes version",
+	)
+	cmd.Flags().StringVar(
+		&flags.NodeAddress,
+		"node-address", "127.0.0.1", "the node address to be used in the kubeadm config",
+	)
+	cmd.Flags().BoolVar(
+		&flags.ControlPlane,
+		"control-plane", true, "generate the kubeadm config for a control-plane node",
+	)
+	cmd.Flags().BoolVar(
+		&flags.IPv6,
+		"ipv6", false, "generate the kubeadm config for an IPv6 cluster",
+	)
+	cmd.Flags().StringVar(
+		&flags.ResolvConf,
+		"kubelet-resolv-conf", "", "the resolver configuration file to be used by the kubelet",
+	)
+	cmd.Flags().StringSliceVar(
+		&flags.ClusterDNS,
+		"kubelet-cluster-dns", nil, "the IP addresses of the cluster DNS server to be used by the kubelet",
+	)
+	cmd.Flags().BoolVar(
+		&flags.FailSwapOn,
+		"kubelet-fail-swap-on", false, "instruct the kubelet to fail if swap is enabled on the node",
+	)
+	cmd.Flags().StringVar(
+		&flags.SwapBehavior,
+		"kubelet-swap-behavior", "", "how the kubelet lets workloads use swap, e.g. LimitedSwap",
+	)
+	cmd.Flags().StringSliceVar(
+		&flags.CertSANs,
+		"apiserver-cert-sans", nil, "additional Subject Alternative Names for the API server serving certificate",
+	)
+	cmd.Flags().StringVar(
+		&flags.CoreDNSImageRepo,
+		"coredns-image-repository", "", "the repository of the CoreDNS image, e.g. docker.io/coredns",
+	)
+	cmd.Flags().StringVar(
+		&flags.CoreDNSImageTag,
+		"coredns-image-tag", "", "the tag of the CoreDNS image, e.g. v1.11.3",
+	)
+	return cmd
+}
+
+func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
+	if flags.KubernetesVersion == "" {
+		return errors.New("the --kubernetes-version flag is required")
+	}
+
+	kubernetesVersion, err := K8sVersion.ParseSemantic(flags.KubernetesVersion)
+	if err != nil {
+		return errors.Wrapf(err, "invalid Kubernetes version %s", flags.KubernetesVersion)
+	}
+
+	// nb. the config version is resolved like for the nodes of a cluster, assuming that
+	// the kubeadm version is the same as the Kubernetes version
+	node := configVersionNode{
+		name:           fmt.Sprintf("with Kubernetes %s", flags.KubernetesVersion),
+		kubeadmVersion: kubernetesVersion,
+	}
+	configVersion, _, err := kubeadm.ResolveConfigVersion(node, flags.ConfigVersion)
+	if err != nil {
+		return err
+	}
+
+	configData := kubeadm.ConfigData{
+		ClusterName:          flags.Name,
+		KubernetesVersion:    flags.KubernetesVersion,
+		ControlPlaneEndpoint: fmt.Sprintf("%s:%d", flags.NodeAddress, constants.APIServerPort),
+		APIBindPort:          constants.APIServerPort,
+		APIServerAddress:     flags.NodeAddress,
+		ControlPlane:         flags.ControlPlane,
+		NodeAddress:          flags.NodeAddress,
+		Token:                constants.Token,
+		PodSubnet:            "192.168.0.0/16", // default for kindnet
+		IPv6:                 flags.IPv6,
+		UpgradeVersion:       flags.KubernetesVersion,
+		ResolvConf:           flags.ResolvConf,
+		ClusterDNS:           flags.ClusterDNS,
+		FailSwapOn:           flags.FailSwapOn,
+		SwapBehavior:         flags.SwapBehavior,
+		CertSANs:             flags.CertSANs,
+	}
+	configData.CoreDNSImageRepository = flags.CoreDNSImageRepo
+	configData.CoreDNSImageTag = flags.CoreDNSImageTag
+
+	var jsonPatches []kubeadm.PatchJSON6902
+	if len(configData.CertSANs) > 0 {
+		certSANsPatch, err := kubeadm.GetCertSANsPatch(configVersion, configData.CertSANs)
+		if err != nil {
+			return err
+		}
+		jsonPatches = append(jsonPatches, certSANsPatch)
+	}
+
+	config, err := kubeadm.RenderConfig(configVersion, configData, nil, jsonPatches)
+	if err != nil {
+		return errors.Wrap(err, "failed to render the kubeadm config")
+	}
+
+	fmt.Print(config)
+	return nil
+}
+
+// configVersionNode implements kubeadm.ConfigVersionNode without requiring a node
+type configVersionNode struct {
+	name           string
+	kubeadmVersion *K8sVersion.Version
+}
+
+// Name returns the name of the node
+func (n configVersionNode) Name() string {
+	return n.name
+}
+
+// KubeadmVersion returns the kubeadm version of the node
+func (n configVersionNode) KubeadmVersion() (*K8sVersion.Version, error) {
+	return n.kubeadmVersion, nil
+}
@@ -17,8 +17,10 @@ limitations under the License.
 package workflow
 
 import (
+	"fmt"
 	"os"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"k8s.io/kubeadm/kinder/pkg/test/workflow"
@@ -28,6 +30,8 @@ type flagpole struct {
 	DryRun      bool
 	Verbose     bool
 	ExitOnError bool
+	Policy      string
+	Validate    bool
 }
 
 // NewCommand returns a new cobra.Command for e2e-kubeadm
@@ -61,6 +65,16 @@ func NewCommand() *cobra.Command {
 		"exit-on-task-error", false,
 		"exit after first task failed",
 	)
+	cmd.Flags().StringVar(
+		&flags.Policy,
+		"execution-policy", string(workflow.FailFast),
+		fmt.Sprintf("how a task failure propagates to the remaining tasks, one of %s", workflow.KnownExecutionPolicies()),
+	)
+	cmd.Flags().BoolVar(
+		&flags.Validate,
+		"validate", false,
+		"only validates the workflow file and its imports, reporting all the errors found, without executing it",
+	)
 	return cmd
 }
 
@@ -78,5 +92,13 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return w.Run(os.Stdout, flags.DryRun, flags.Verbose, flags.ExitOnError, artifacts)
+	if flags.Validate {
+		if err := w.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid workflow file %s", config)
+		}
+		fmt.Printf("workflow file %s is valid\n", config)
+		return nil
+	}
+
+	return w.Run(os.Stdout, flags.DryRun, flags.Verbose, flags.ExitOnError, workflow.ExecutionPolicy(flags.Policy), artifacts)
 }
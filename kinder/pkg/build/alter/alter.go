@@ -19,19 +19,24 @@ package alter
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"k8s.io/kubeadm/kinder/pkg/build/bits"
 	"k8s.io/kubeadm/kinder/pkg/cluster/manager/actions/assets"
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
 	"k8s.io/kubeadm/kinder/pkg/cri/host"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/containerd/config"
 	"k8s.io/kubeadm/kinder/pkg/exec"
 	"k8s.io/kubeadm/kinder/pkg/extract"
 	kindfs "sigs.k8s.io/kind/pkg/fs"
@@ -43,6 +48,9 @@ const DefaultBaseImage = "kindest/node:latest"
 // DefaultImage is the default name:tag for the alter image
 const DefaultImage = DefaultBaseImage
 
+// kubeletDropinDir is the folder containing the systemd drop-in files for the kubelet service
+const kubeletDropinDir = "/etc/systemd/system/kubelet.service.d"
+
 // Context is used to alter the kind node image, and contains
 // alter configuration
 type Context struct {
@@ -56,6 +64,11 @@ type Context struct {
 	kubeletSrc              string
 	prePullAdditionalImages bool
 	paths                   []string
+	kubeletDropins          []string
+	containerdConfigSrc     string
+	containerdConfig        string
+	excludeImages           []string
+	pullConcurrency         int
 }
 
 // Option is Context configuration option supplied to NewContext
@@ -131,17 +144,78 @@ func WithPath(paths []string) Option {
 	}
 }
 
+// WithKubeletDropin configures a NewContext to include a systemd drop-in file for the kubelet service,
+// e.g. for setting kubelet flags in the image; the file must have the .conf extension
+func WithKubeletDropin(path string) Option {
+	return func(b *Context) {
+		if path != "" {
+			b.kubeletDropins = append(b.kubeletDropins, path)
+		}
+	}
+}
+
+// WithContainerdConfig configures a NewContext to merge a containerd config.toml fragment,
+// e.g. registry mirrors, into the containerd config file of the image
+func WithContainerdConfig(path string) Option {
+	return func(b *Context) {
+		b.containerdConfigSrc = path
+	}
+}
+
+// WithExcludeImages configures a NewContext to skip the given Kubernetes images, e.g. kube-proxy,
+// when adding init, upgrade and image artifacts, and when pre-loading and pre-pulling images
+func WithExcludeImages(images []string) Option {
+	return func(b *Context) {
+		b.excludeImages = images
+	}
+}
+
+// WithPullConcurrency configures a NewContext to pull and save up to the given number of
+// additional images in parallel on the host; images are always imported into the image sequentially
+func WithPullConcurrency(concurrency int) Option {
+	return func(b *Context) {
+		b.pullConcurrency = concurrency
+	}
+}
+
 // NewContext creates a new Context with default configuration,
 // overridden by the options supplied in the order that they are supplied
 func NewContext(options ...Option) (ctx *Context, err error) {
 	// default options
-	ctx = &Context{}
+	ctx = &Context{
+		pullConcurrency: 1,
+	}
 
 	// apply user options
 	for _, option := range options {
 		option(ctx)
 	}
 
+	for _, dropin := range ctx.kubeletDropins {
+		if filepath.Ext(dropin) != ".conf" {
+			return nil, errors.Errorf("invalid kubelet drop-in %q, systemd drop-in files must have the .conf extension", dropin)
+		}
+	}
+
+	if ctx.pullConcurrency < 1 {
+		return nil, errors.Errorf("invalid pull concurrency %d, it must be greater than 0", ctx.pullConcurrency)
+	}
+
+	if _, err := extract.KubernetesImageTarballs(ctx.excludeImages); err != nil {
+		return nil, err
+	}
+
+	if ctx.containerdConfigSrc != "" {
+		data, err := os.ReadFile(ctx.containerdConfigSrc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the containerd config fragment %s", ctx.containerdConfigSrc)
+		}
+		if err := config.ValidateConfigFragment(string(data)); err != nil {
+			return nil, errors.Wrapf(err, "invalid containerd config fragment %s", ctx.containerdConfigSrc)
+		}
+		ctx.containerdConfig = string(data)
+	}
+
 	return ctx, nil
 }
 
@@ -167,7 +241,7 @@ func (c *Context) Alter() (err error) {
 	var bitsInstallers []bits.Installer
 
 	if c.initArtifactsSrc != "" {
-		bitsInstallers = append(bitsInstallers, bits.NewInitBits(c.initArtifactsSrc))
+		bitsInstallers = append(bitsInstallers, bits.NewInitBits(c.initArtifactsSrc, c.excludeImages))
 	}
 
 	if c.kubeadmSrc != "" {
@@ -178,7 +252,7 @@ func (c *Context) Alter() (err error) {
 	}
 
 	if len(c.imageSrcs) > 0 {
-		bitsInstallers = append(bitsInstallers, bits.NewImageBits(c.imageSrcs, c.imageNamePrefix))
+		bitsInstallers = append(bitsInstallers, bits.NewImageBits(c.imageSrcs, c.imageNamePrefix, c.excludeImages))
 	}
 
 	if c.upgradeArtifactsSrc != "" {
@@ -188,11 +262,18 @@ func (c *Context) Alter() (err error) {
 		if src == c.initArtifactsSrc {
 			src = filepath.Join(bc.HostBitsPath(), bits.InitBitsDir)
 		}
-		bitsInstallers = append(bitsInstallers, bits.NewUpgradeBits(src))
+		bitsInstallers = append(bitsInstallers, bits.NewUpgradeBits(src, c.excludeImages))
 	}
 
-	if len(c.paths) > 0 {
-		bitsInstallers = append(bitsInstallers, bits.NewPathBits(c.paths))
+	// kubelet drop-in files are copied into the image like any other path;
+	// NB. c.paths is copied, so appending does not alter the slice passed to WithPath
+	paths := append([]string{}, c.paths...)
+	for _, dropin := range c.kubeletDropins {
+		paths = append(paths, fmt.Sprintf("%s:%s", dropin, filepath.Join(kubeletDropinDir, filepath.Base(dropin))))
+	}
+
+	if len(paths) > 0 {
+		bitsInstallers = append(bitsInstallers, bits.NewPathBits(paths))
 	}
 
 	log.Infof("Altering node image in: %s", alterDir)
@@ -330,6 +411,15 @@ func (c *Context) alterImage(bitsInstallers []bits.Installer, bc *bits.BuildCont
 		return err
 	}
 
+	// remove the excluded images bundled with the base image, if any;
+	// NB. this happens before installing the bits, so image tarballs explicitly added, e.g. with --with-images, are preserved
+	excludedTarballs, _ := extract.KubernetesImageTarballs(c.excludeImages)
+	for _, tarball := range excludedTarballs {
+		if err := bc.RunInContainer("rm", "-f", filepath.Join("/kind/images", tarball)); err != nil {
+			return errors.Wrapf(err, "failed to remove the excluded image %s", tarball)
+		}
+	}
+
 	// install the bits that are used to alter the image
 	log.Info("Starting bits install ...")
 	for _, b := range bitsInstallers {
@@ -340,7 +430,7 @@ func (c *Context) alterImage(bitsInstallers []bits.Installer, bc *bits.BuildCont
 	}
 
 	log.Info("Setup CRI ...")
-	if err := alterHelper.SetupCRI(bc); err != nil {
+	if err := alterHelper.SetupCRI(bc, c.containerdConfig); err != nil {
 		return errors.Wrapf(err, "image build Failed! Failed to setup %s", runtime)
 	}
 
@@ -366,8 +456,9 @@ func (c *Context) alterImage(bitsInstallers []bits.Installer, bc *bits.BuildCont
 
 		// add the kindnet image
 		images = append(images, assets.KindnetImage054)
+		images = excludeImages(images, excludedTarballs)
 
-		if err := pullImages(alterHelper, bc, images, filepath.Join(initPath, "images"), containerID); err != nil {
+		if err := pullImages(alterHelper, bc, images, filepath.Join(initPath, "images"), containerID, c.pullConcurrency); err != nil {
 			return err
 		}
 
@@ -393,7 +484,8 @@ func (c *Context) alterImage(bitsInstallers []bits.Installer, bc *bits.BuildCont
 				return err
 			}
 
-			if err := pullImages(alterHelper, bc, upgradeImages, filepath.Join(upgradePath, version[0]), containerID); err != nil {
+			upgradeImages = excludeImages(upgradeImages, excludedTarballs)
+			if err := pullImages(alterHelper, bc, upgradeImages, filepath.Join(upgradePath, version[0]), containerID, c.pullConcurrency); err != nil {
 				return err
 			}
 		}
@@ -414,45 +506,129 @@ func (c *Context) alterImage(bitsInstallers []bits.Installer, bc *bits.BuildCont
 	return nil
 }
 
-func pullImages(alterHelper *nodes.AlterHelper, bc *bits.BuildContext, images []string, savePath, containerID string) error {
+// imageRegExp defines the separators of the parts of an image reference
+var imageRegExp = regexp.MustCompile("[/:]")
+
+// excludeImages returns the images not matching the excluded image tarballs,
+// e.g. registry.k8s.io/kube-proxy:v1.31.0 matches kube-proxy.tar
+func excludeImages(images, excludedTarballs []string) []string {
+	filtered := []string{}
+	for _, image := range images {
+		name := path.Base(strings.Split(path.Base(image), ":")[0])
+		skip := false
+		for _, tarball := range excludedTarballs {
+			if name+".tar" == tarball {
+				skip = true
+				break
+			}
+		}
+		if skip {
+			log.Infof("Skipping excluded image %s", image)
+			continue
+		}
+		filtered = append(filtered, image)
+	}
+	return filtered
+}
+
+// pullImages pulls the given images on the host, and then imports them into the container runtime of the alter container.
+// With concurrency 1, each image is pulled, saved and imported before moving to the next one, so only one image
+// tarball at a time is kept on the host; otherwise images are pulled and saved by a pool of concurrency workers,
+// while copying and importing images into the alter container is serialized, in order to avoid contention
+// on the container runtime.
+func pullImages(alterHelper *nodes.AlterHelper, bc *bits.BuildContext, images []string, savePath, containerID string, concurrency int) error {
 	tempDir, err := os.MkdirTemp("", "kinder-image-path")
 	if err != nil {
 		return err
 	}
 	defer os.RemoveAll(tempDir)
 
-	imageRegExp := regexp.MustCompile("[/:]")
-
-	for _, image := range images {
-		// Pull the image on the host
-		if err := exec.NewHostCmd("docker", "pull", image).Run(); err != nil {
-			return errors.Wrapf(err, "failed to pull image %q on the host", image)
+	// NB. each image is saved in a separated folder, so images with the same file name do not conflict
+	if concurrency <= 1 {
+		for i, image := range images {
+			hostPath, err := pullAndSaveImage(image, filepath.Join(tempDir, strconv.Itoa(i)))
+			if err != nil {
+				return err
+			}
+			if err := importSavedImage(alterHelper, bc, image, hostPath, savePath, containerID); err != nil {
+				return err
+			}
 		}
+		return nil
+	}
+
+	// pull and save the images on the host
+	hostPaths := make([]string, len(images))
+	errs := make([]error, len(images))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, image := range images {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, image string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			hostPaths[i], errs[i] = pullAndSaveImage(image, filepath.Join(tempDir, strconv.Itoa(i)))
+		}(i, image)
+	}
+	wg.Wait()
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return err
+	}
 
-		// Create the path where the tar is going to be saved
-		s := imageRegExp.Split(image, -1)
-		if len(s) < 3 {
-			return errors.Errorf("unsupported image URL: %s", image)
+	// copy and import the images into the alter container
+	for i, image := range images {
+		if err := importSavedImage(alterHelper, bc, image, hostPaths[i], savePath, containerID); err != nil {
+			return err
 		}
-		fileName := s[len(s)-2] + ".tar"
-		hostPath := filepath.Join(tempDir, fileName)
+	}
+	return nil
+}
 
-		// Save the tar
-		if err := exec.NewHostCmd("docker", "save", "-o="+hostPath, image).Run(); err != nil {
-			return errors.Wrapf(err, "failed to save image %q to path %q", image, hostPath)
-		}
+// importSavedImage copies an image tarball saved on the host into the alter container, and then imports
+// it into the container runtime; the tarball is removed from the host after copy.
+func importSavedImage(alterHelper *nodes.AlterHelper, bc *bits.BuildContext, image, hostPath, savePath, containerID string) error {
+	fileName := filepath.Base(hostPath)
 
-		// Copy the tar to the container
-		if err := exec.NewHostCmd("docker", "cp", hostPath, containerID+":"+savePath).Run(); err != nil {
-			return errors.Wrapf(err, "failed to copy the file %q to container %q", image, containerID)
-		}
+	// Copy the tar to the container
+	if err := exec.NewHostCmd("docker", "cp", hostPath, containerID+":"+savePath).Run(); err != nil {
+		return errors.Wrapf(err, "failed to copy the file %q to container %q", image, containerID)
+	}
+	os.Remove(hostPath)
 
-		// Import the image in the runtime (containerd only, deletes the file from the container after import)
-		if err := alterHelper.ImportImage(bc, filepath.Join(savePath, fileName)); err != nil {
-			return err
+	// Import the image in the runtime (containerd only, deletes the file from the container after import)
+	return alterHelper.ImportImage(bc, filepath.Join(savePath, fileName))
+}
+
+// pullAndSaveImage pulls an image on the host and saves it into a tar file in the given folder;
+// the path of the tar file is returned. In case of errors, the folder is removed.
+func pullAndSaveImage(image, dir string) (hostPath string, err error) {
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
 		}
+	}()
+
+	// Pull the image on the host
+	if err := exec.NewHostCmd("docker", "pull", image).Run(); err != nil {
+		return "", errors.Wrapf(err, "failed to pull image %q on the host", image)
 	}
-	return nil
+
+	// Create the path where the tar is going to be saved
+	s := imageRegExp.Split(image, -1)
+	if len(s) < 3 {
+		return "", errors.Errorf("unsupported image URL: %s", image)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	hostPath = filepath.Join(dir, s[len(s)-2]+".tar")
+
+	// Save the tar
+	if err := exec.NewHostCmd("docker", "save", "-o="+hostPath, image).Run(); err != nil {
+		return "", errors.Wrapf(err, "failed to save image %q to path %q", image, hostPath)
+	}
+	return hostPath, nil
 }
 
 func (c *Context) createAlterContainer(bc *bits.BuildContext, runArgs, containerArgs []string) (id string, err error) {
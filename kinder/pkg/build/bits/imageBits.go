@@ -29,17 +29,19 @@ import (
 // imageBits defines a bit installer that allows to add new images tarball in the /kind/images folder into the node image;
 // those images will be automatically loaded into docker when the container/the node will start
 type imageBits struct {
-	srcs       []string
-	namePrefix string
+	srcs          []string
+	namePrefix    string
+	excludeImages []string
 }
 
 var _ Installer = &imageBits{}
 
-// NewImageBits returns a new imageBits
-func NewImageBits(args []string, namePrefix string) Installer {
+// NewImageBits returns a new imageBits; excludeImages are Kubernetes images to be skipped, e.g. kube-proxy
+func NewImageBits(args []string, namePrefix string, excludeImages []string) Installer {
 	return &imageBits{
-		srcs:       args,
-		namePrefix: namePrefix,
+		srcs:          args,
+		namePrefix:    namePrefix,
+		excludeImages: excludeImages,
 	}
 }
 
@@ -61,6 +63,7 @@ func (b *imageBits) Prepare(c *BuildContext) (map[string]string, error) {
 			src, dst,
 			extract.OnlyKubernetesImages(true),
 			extract.WithNamePrefix(b.namePrefix),
+			extract.WithExcludeImages(b.excludeImages),
 		)
 
 		// if the source is a local repository
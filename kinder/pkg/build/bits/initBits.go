@@ -34,15 +34,17 @@ const (
 // initBits defines a bit installer that allows to add Kubernetes binaries & images to the node image;
 // those artifact will be used by the kinder do kubeadm-init script
 type initBits struct {
-	src string
+	src           string
+	excludeImages []string
 }
 
 var _ Installer = &initBits{}
 
-// NewInitBits returns a new initBits
-func NewInitBits(arg string) Installer {
+// NewInitBits returns a new initBits; excludeImages are Kubernetes images to be skipped, e.g. kube-proxy
+func NewInitBits(arg string, excludeImages []string) Installer {
 	return &initBits{
-		src: arg,
+		src:           arg,
+		excludeImages: excludeImages,
 	}
 }
 
@@ -59,6 +61,7 @@ func (b *initBits) Prepare(c *BuildContext) (map[string]string, error) {
 	// and save it to the dst folder
 	e := extract.NewExtractor(
 		b.src, dst,
+		extract.WithExcludeImages(b.excludeImages),
 	)
 
 	// Extracts the binaries & images
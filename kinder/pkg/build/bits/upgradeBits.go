@@ -29,15 +29,17 @@ import (
 // upgradeBits defines a bit installer that allows to add Kubernetes binaries & images to the /kinder/upgrade folder into the node image;
 // those artifact will be used by the kinder do kubeadm-upgrade script
 type upgradeBits struct {
-	src string
+	src           string
+	excludeImages []string
 }
 
 var _ Installer = &upgradeBits{}
 
-// NewUpgradeBits returns a new upgradeBits
-func NewUpgradeBits(arg string) Installer {
+// NewUpgradeBits returns a new upgradeBits; excludeImages are Kubernetes images to be skipped, e.g. kube-proxy
+func NewUpgradeBits(arg string, excludeImages []string) Installer {
 	return &upgradeBits{
-		src: arg,
+		src:           arg,
+		excludeImages: excludeImages,
 	}
 }
 
@@ -55,6 +57,7 @@ func (b *upgradeBits) Prepare(c *BuildContext) (map[string]string, error) {
 	e := extract.NewExtractor(
 		b.src, dst,
 		extract.WithVersionFolder(true),
+		extract.WithExcludeImages(b.excludeImages),
 	)
 
 	// Extracts the binary bit
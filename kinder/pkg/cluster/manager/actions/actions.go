@@ -28,6 +28,7 @@ import (
 
 	K8sVersion "k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
 )
 
 // action registry defines the list of available actions and the corresponding entry point.
@@ -40,19 +41,32 @@ var actionRegistry = map[string]func(*status.Cluster, *RunOptions) error{
 	"kubeadm-config": func(c *status.Cluster, flags *RunOptions) error {
 		// Nb. this action is invoked automatically at kubeadm init/join time, but it is possible
 		// to invoke it separately as well
-		return KubeadmConfig(c, flags.kubeadmConfigVersion, flags.copyCertsMode, flags.discoveryMode, flags.featureGate, flags.encryptionAlgorithm, flags.ignorePreflightErrors, flags.upgradeVersion, c.K8sNodes().EligibleForActions()...)
+		return KubeadmConfig(c, flags.kubeadmConfigVersion, flags.copyCertsMode, flags.discoveryMode, flags.featureGate, flags.encryptionAlgorithm, flags.criSocket, flags.dnsDomain, flags.ignorePreflightErrors, flags.upgradeVersion, flags.skipKubeProxy, flags.apiServerCertSANs, c.K8sNodes().EligibleForActions()...)
+	},
+	"kubeadm-patches": func(c *status.Cluster, flags *RunOptions) error {
+		return KubeadmPatches(c, flags.patchesDir, flags.upgradeVersion, c.K8sNodes().EligibleForActions()...)
 	},
 	"kubeadm-init": func(c *status.Cluster, flags *RunOptions) error {
-		return KubeadmInit(c, flags.usePhases, flags.copyCertsMode, flags.kubeadmConfigVersion, flags.patchesDir, flags.ignorePreflightErrors, flags.featureGate, flags.encryptionAlgorithm, flags.wait, flags.vLevel)
+		return KubeadmInit(c, flags.usePhases, flags.copyCertsMode, flags.kubeadmConfigVersion, flags.patchesDir, flags.ignorePreflightErrors, flags.featureGate, flags.encryptionAlgorithm, flags.criSocket, flags.dnsDomain, flags.skipKubeProxy, flags.apiServerCertSANs, flags.wait, flags.vLevel)
 	},
 	"kubeadm-join": func(c *status.Cluster, flags *RunOptions) error {
-		return KubeadmJoin(c, flags.usePhases, flags.copyCertsMode, flags.discoveryMode, flags.kubeadmConfigVersion, flags.patchesDir, flags.ignorePreflightErrors, flags.wait, flags.vLevel)
+		return KubeadmJoin(c, flags.usePhases, flags.copyCertsMode, flags.discoveryMode, flags.kubeadmConfigVersion, flags.patchesDir, flags.criSocket, flags.ignorePreflightErrors, flags.apiServerCertSANs, flags.wait, flags.vLevel)
 	},
 	"kubeadm-upgrade": func(c *status.Cluster, flags *RunOptions) error {
-		return KubeadmUpgrade(c, flags.upgradeVersion, flags.patchesDir, flags.ignorePreflightErrors, flags.wait, flags.vLevel)
+		return KubeadmUpgrade(c, flags.kubeadmConfigVersion, flags.upgradeVersion, flags.patchesDir, flags.ignorePreflightErrors, flags.skipKubeProxy, flags.wait, flags.vLevel)
+	},
+	"kubeadm-upgrade-plan": func(c *status.Cluster, flags *RunOptions) error {
+		plan, err := KubeadmUpgradePlan(c, flags.kubeadmConfigVersion, flags.upgradeVersion, flags.vLevel)
+		if err != nil {
+			return err
+		}
+		if !plan.HasTarget(flags.upgradeVersion) {
+			return errors.Errorf("kubeadm upgrade plan does not offer an upgrade to v%s", flags.upgradeVersion)
+		}
+		return nil
 	},
 	"kubeadm-reset": func(c *status.Cluster, flags *RunOptions) error {
-		return KubeadmReset(c, flags.vLevel)
+		return KubeadmReset(c, flags.kubeadmConfigVersion, flags.resetConfig, flags.vLevel)
 	},
 	"copy-certs": func(c *status.Cluster, flags *RunOptions) error {
 		return CopyCertificates(c)
@@ -66,6 +80,45 @@ var actionRegistry = map[string]func(*status.Cluster, *RunOptions) error{
 	"smoke-test": func(c *status.Cluster, flags *RunOptions) error {
 		return SmokeTest(c, flags.wait)
 	},
+	"verify-control-plane": func(c *status.Cluster, flags *RunOptions) error {
+		return verifyControlPlane(c, flags.wait)
+	},
+	"etcd-snapshot": func(c *status.Cluster, flags *RunOptions) error {
+		return EtcdSnapshot(c, flags.etcdSnapshot)
+	},
+	"etcd-restore": func(c *status.Cluster, flags *RunOptions) error {
+		return EtcdRestore(c, flags.etcdSnapshot, flags.wait)
+	},
+	"audit-logging": func(c *status.Cluster, flags *RunOptions) error {
+		return EnableAuditLogging(c, flags.auditPolicy, flags.wait)
+	},
+	"network-chaos": func(c *status.Cluster, flags *RunOptions) error {
+		peers, err := c.SelectNodesByName(flags.networkChaosPeers...)
+		if err != nil {
+			return err
+		}
+		// NB. the network chaos is not removed when the action completes; use the clear-network-chaos action
+		_, err = NetworkChaos(c, flags.networkChaosMode, flags.networkLatency, peers, c.K8sNodes().EligibleForActions()...)
+		return err
+	},
+	"clear-network-chaos": func(c *status.Cluster, flags *RunOptions) error {
+		return ClearNetworkChaos(c, c.K8sNodes().EligibleForActions()...)
+	},
+	"configure-coredns": func(c *status.Cluster, flags *RunOptions) error {
+		return ConfigureCoreDNS(c, flags.corednsCorefile, flags.corednsImageRepo, flags.corednsImageTag, flags.wait)
+	},
+	"set-bootstrap-control-plane": func(c *status.Cluster, flags *RunOptions) error {
+		nodes := c.ControlPlanes().EligibleForActions()
+		if len(nodes) != 1 {
+			return errors.Errorf("set-bootstrap-control-plane requires exactly one control-plane node, got %d; use --only-node for selecting it", len(nodes))
+		}
+		nodes[0].Infof("Setting the bootstrap control plane")
+		return c.SetBootstrapControlPlane(nodes[0])
+	},
+	"upload-certs": func(c *status.Cluster, flags *RunOptions) error {
+		_, err := UploadCerts(c, flags.kubeadmConfigVersion, flags.criSocket, flags.ignorePreflightErrors, flags.copyCertsMode == CopyCertsModeAuto, flags.vLevel)
+		return err
+	},
 }
 
 // KnownActions returns the list of known actions
@@ -167,6 +220,79 @@ func EncryptionAlgorithm(algorithm string) Option {
 	}
 }
 
+// CRISocket option sets the CRI socket to be used by kubeadm init and join,
+// overriding the default CRI socket of the CRI installed on the nodes
+func CRISocket(criSocket string) Option {
+	return func(r *RunOptions) {
+		r.criSocket = criSocket
+	}
+}
+
+// DNSDomain option sets the DNS domain used by services in the cluster created by kubeadm init
+func DNSDomain(dnsDomain string) Option {
+	return func(r *RunOptions) {
+		r.dnsDomain = dnsDomain
+	}
+}
+
+// APIServerCertSANs option sets additional Subject Alternative Names for the API server serving certificate
+// generated by kubeadm init; the SANs are verified on the control plane nodes after kubeadm init and kubeadm join
+func APIServerCertSANs(certSANs []string) Option {
+	return func(r *RunOptions) {
+		r.apiServerCertSANs = certSANs
+	}
+}
+
+// SkipKubeProxy option instructs kubeadm init and kubeadm upgrade to skip the kube-proxy addon, e.g. for testing CNI plugins
+// replacing kube-proxy; skipping kube-proxy during upgrades requires the v1beta4 kubeadm config version
+func SkipKubeProxy(skipKubeProxy bool) Option {
+	return func(r *RunOptions) {
+		r.skipKubeProxy = skipKubeProxy
+	}
+}
+
+// EtcdSnapshotPath option sets the path on the host of the etcd snapshot saved by the etcd-snapshot action
+// and restored by the etcd-restore action
+func EtcdSnapshotPath(path string) Option {
+	return func(r *RunOptions) {
+		r.etcdSnapshot = path
+	}
+}
+
+// AuditPolicy option sets the path on the host of the audit policy used by the audit-logging action
+func AuditPolicy(path string) Option {
+	return func(r *RunOptions) {
+		r.auditPolicy = path
+	}
+}
+
+// ResetConfig option customizes the ResetConfiguration used by kubeadm reset, e.g. for testing reset edge cases
+func ResetConfig(reset kubeadm.ResetConfigData) Option {
+	return func(r *RunOptions) {
+		r.resetConfig = reset
+	}
+}
+
+// NetworkChaosSettings option sets the network chaos injected by the network-chaos action, the latency to be added
+// in latency mode, and the names of the peers; if no peers are set, all the other K8s nodes are used as peers
+func NetworkChaosSettings(mode NetworkChaosMode, latency time.Duration, peers []string) Option {
+	return func(r *RunOptions) {
+		r.networkChaosMode = mode
+		r.networkLatency = latency
+		r.networkChaosPeers = peers
+	}
+}
+
+// CoreDNSSettings option sets the path on the host of the Corefile, the image repository and the image tag
+// used by the configure-coredns action; empty values are not changed
+func CoreDNSSettings(corefile, imageRepository, imageTag string) Option {
+	return func(r *RunOptions) {
+		r.corednsCorefile = corefile
+		r.corednsImageRepo = imageRepository
+		r.corednsImageTag = imageTag
+	}
+}
+
 // RunOptions holds options supplied to actions.Run
 type RunOptions struct {
 	usePhases             bool
@@ -180,6 +306,19 @@ type RunOptions struct {
 	kubeadmConfigVersion  string
 	featureGate           string
 	encryptionAlgorithm   string
+	criSocket             string
+	dnsDomain             string
+	skipKubeProxy         bool
+	apiServerCertSANs     []string
+	etcdSnapshot          string
+	auditPolicy           string
+	resetConfig           kubeadm.ResetConfigData
+	networkChaosMode      NetworkChaosMode
+	networkLatency        time.Duration
+	networkChaosPeers     []string
+	corednsCorefile       string
+	corednsImageRepo      string
+	corednsImageTag       string
 }
 
 // DiscoveryMode defines discovery mode supported by kubeadm join
@@ -0,0 +1,190 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
+)
+
+const (
+	// auditPolicyDir defines the folder where the audit policy is staged on the control-plane nodes
+	auditPolicyDir = "/etc/kubernetes/audit"
+
+	// auditPolicyPath defines the path of the audit policy on the control-plane nodes
+	auditPolicyPath = auditPolicyDir + "/policy.yaml"
+
+	// auditLogDir defines the folder where the API server writes the audit log
+	auditLogDir = "/var/log/kubernetes/audit"
+
+	// auditLogPath defines the path of the audit log on the control-plane nodes
+	auditLogPath = auditLogDir + "/audit.log"
+
+	// auditClusterConfigPath defines the path where the patched ClusterConfiguration is staged before uploading it
+	auditClusterConfigPath = "/kinder/audit-cluster-config.yaml"
+)
+
+// defaultAuditPolicy defines the audit policy used when no policy file is provided;
+// it logs the metadata of all the requests
+const defaultAuditPolicy = `apiVersion: audit.k8s.io/v1
+kind: Policy
+rules:
+- level: Metadata
+`
+
+// EnableAuditLogging action enables the API server audit logging on the control-plane nodes.
+// The ClusterConfiguration stored in the kubeadm-config ConfigMap is patched for adding the audit extra args and
+// extra volumes to the API server, and uploaded back into the cluster, so audit logging is preserved by kubeadm upgrade;
+// then the audit policy is staged on each node, and the API server static pod manifest is regenerated from the
+// ClusterConfiguration stored in the cluster.
+// If policyFile is empty, a default policy logging the metadata of all the requests is used.
+func EnableAuditLogging(c *status.Cluster, policyFile string, wait time.Duration) error {
+	policy := []byte(defaultAuditPolicy)
+	if policyFile != "" {
+		var err error
+		policy, err = os.ReadFile(policyFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the audit policy %s", policyFile)
+		}
+	}
+
+	// NB. the ClusterConfiguration stored in the cluster is patched, and not the kubeadm config used for kubeadm init,
+	// so changes applied after init, e.g. by kubeadm upgrade, are preserved
+	cp1 := c.BootstrapControlPlane()
+	cp1.Infof("Adding audit settings to the ClusterConfiguration stored in the cluster")
+
+	lines, err := cp1.Command(
+		"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "-n=kube-system",
+		"get", "configmap/kubeadm-config", "-o=jsonpath={.data.ClusterConfiguration}",
+	).Silent().RunAndCapture()
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the kubeadm-config ConfigMap from node %s", cp1.Name())
+	}
+	clusterConfiguration := strings.Join(lines, "\n")
+
+	// the patch must use the kubeadm config version of the stored ClusterConfiguration
+	config, err := kubeadm.ParseConfig(clusterConfiguration)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the ClusterConfiguration stored in the kubeadm-config ConfigMap")
+	}
+	if config.ClusterConfiguration == nil {
+		return errors.New("the kubeadm-config ConfigMap does not contain a ClusterConfiguration")
+	}
+
+	patch, err := kubeadm.GetControlPlaneComponentPatch(config.ConfigVersion, "apiServer",
+		map[string]string{
+			"audit-policy-file": auditPolicyPath,
+			"audit-log-path":    auditLogPath,
+		},
+		[]kubeadm.ExtraVolume{
+			{Name: "audit-policy", HostPath: auditPolicyDir, MountPath: auditPolicyDir, ReadOnly: true, PathType: "DirectoryOrCreate"},
+			{Name: "audit-log", HostPath: auditLogDir, MountPath: auditLogDir, PathType: "DirectoryOrCreate"},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	patched, err := kubeadm.BuildWithStrategicMerge(clusterConfiguration, nil, []string{patch}, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to patch the ClusterConfiguration stored in the kubeadm-config ConfigMap")
+	}
+	if err := cp1.WriteFile(auditClusterConfigPath, []byte(patched)); err != nil {
+		return errors.Wrapf(err, "failed to write the ClusterConfiguration to node %s", cp1.Name())
+	}
+
+	if err := cp1.Command(
+		"kubeadm", "init", "phase", "upload-config", "kubeadm",
+		fmt.Sprintf("--config=%s", auditClusterConfigPath),
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to upload the kubeadm config from node %s", cp1.Name())
+	}
+
+	for _, n := range c.ControlPlanes().EligibleForActions() {
+		n.Infof("Enabling audit logging")
+
+		if err := n.Command("mkdir", "-p", auditPolicyDir).Silent().Run(); err != nil {
+			return errors.Wrapf(err, "failed to create the %s folder on node %s", auditPolicyDir, n.Name())
+		}
+		if err := n.WriteFile(auditPolicyPath, policy); err != nil {
+			return errors.Wrapf(err, "failed to write the audit policy to node %s", n.Name())
+		}
+
+		// regenerates the control-plane static pod manifests from the ClusterConfiguration stored in the cluster;
+		// only the API server manifest changes, and the kubelet restarts the API server as soon as it changes
+		args := []string{
+			"upgrade", "node", "phase", "control-plane",
+			"--certificate-renewal=false", "--etcd-upgrade=false",
+		}
+		patches, err := nodeHasPatches(n)
+		if err != nil {
+			return err
+		}
+		if patches {
+			args = append(args, fmt.Sprintf("--patches=%s", constants.PatchesDir))
+		}
+		if err := n.Command("kubeadm", args...).RunWithEcho(); err != nil {
+			return errors.Wrapf(err, "failed to regenerate the kube-apiserver manifest on node %s", n.Name())
+		}
+
+		n.Infof("waiting for the audit log to be created (timeout %s)", wait)
+		if pass := waitFor(c, n, wait, auditLogExists); !pass {
+			return errors.Errorf("timeout: the audit log was not created on node %s", n.Name())
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// auditLogExists implements a function that tests when the API server audit log exists on a node
+func auditLogExists(c *status.Cluster, n *status.Node) bool {
+	if err := n.Command("test", "-s", auditLogPath).Silent().Run(); err != nil {
+		return false
+	}
+	fmt.Printf("Audit log %s exists on node %s\n", auditLogPath, n.Name())
+	return true
+}
+
+// ReadAuditLog returns the lines of the API server audit log on a control-plane node containing the given text,
+// e.g. the name of a resource; if text is empty, all the lines are returned
+func ReadAuditLog(n *status.Node, text string) ([]string, error) {
+	if !n.IsControlPlane() {
+		return nil, errors.Errorf("node %s is not a control-plane node", n.Name())
+	}
+
+	lines, err := n.Command("cat", auditLogPath).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the audit log %s from node %s", auditLogPath, n.Name())
+	}
+
+	matches := []string{}
+	for _, l := range lines {
+		if strings.Contains(l, text) {
+			matches = append(matches, l)
+		}
+	}
+	return matches, nil
+}
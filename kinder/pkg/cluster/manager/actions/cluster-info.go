@@ -62,31 +62,9 @@ func CluterInfo(c *status.Cluster) error {
 		// local etcd is listening on localhost and on the advertise address; we are
 		// using localhost to accommodate both the use cases
 
-		etcdArgs := []string{
-			"--kubeconfig=/etc/kubernetes/admin.conf", "exec", "-n=kube-system", fmt.Sprintf("etcd-%s", c.BootstrapControlPlane().Name()),
-			"--",
-		}
-
-		var lines []string
-		var err error
-
-		// Get the version of etcdctl from the etcd binary
-		// Retry the version command for a while to avoid "exec" flakes
-		versionArgs := append(etcdArgs, "etcd", "--version")
-		versionArgs = append([]string{"--request-timeout=2"}, versionArgs...) // Ensure shorter timeout
-		for i := 0; i < 10; i++ {
-			lines, err = cp1.Command("kubectl", versionArgs...).RunAndCapture()
-			if err == nil {
-				break
-			}
-			cp1.Infof("Could not execute 'etcd --version' inside %q (attempt %d/%d): %v\n", cp1.Name(), i+1, 10,
-				errors.Wrap(err, strings.Join(lines, "\n")))
-		}
-		if err != nil {
-			return err
-		}
+		etcdArgs := stackedEtcdExecArgs(cp1)
 
-		etcdctlVersion, err := parseEtcdctlVersion(lines)
+		etcdctlVersion, err := stackedEtcdVersion(cp1)
 		if err != nil {
 			return err
 		}
@@ -112,6 +90,40 @@ func CluterInfo(c *status.Cluster) error {
 	return nil
 }
 
+// stackedEtcdExecArgs returns the kubectl arguments for executing a command inside the etcd static pod
+// running on the given control-plane node
+func stackedEtcdExecArgs(n *status.Node) []string {
+	return []string{
+		"--kubeconfig=/etc/kubernetes/admin.conf", "exec", "-n=kube-system", fmt.Sprintf("etcd-%s", n.Name()),
+		"--",
+	}
+}
+
+// stackedEtcdVersion returns the version of the etcd binary inside the etcd static pod
+// running on the given control-plane node
+func stackedEtcdVersion(n *status.Node) (string, error) {
+	var lines []string
+	var err error
+
+	// Get the version of etcdctl from the etcd binary
+	// Retry the version command for a while to avoid "exec" flakes
+	versionArgs := append(stackedEtcdExecArgs(n), "etcd", "--version")
+	versionArgs = append([]string{"--request-timeout=2"}, versionArgs...) // Ensure shorter timeout
+	for i := 0; i < 10; i++ {
+		lines, err = n.Command("kubectl", versionArgs...).RunAndCapture()
+		if err == nil {
+			break
+		}
+		n.Infof("Could not execute 'etcd --version' inside %q (attempt %d/%d): %v\n", n.Name(), i+1, 10,
+			errors.Wrap(err, strings.Join(lines, "\n")))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return parseEtcdctlVersion(lines)
+}
+
 // parseEtcdctlVersion takes the output lines of 'etcdctl version' and returns the version
 func parseEtcdctlVersion(lines []string) (string, error) {
 	if len(lines) < 1 {
@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+)
+
+// corednsConfigMap defines the subset of the coredns ConfigMap created by kubeadm that is updated
+// when configuring a custom Corefile
+type corednsConfigMap struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   map[string]string `json:"metadata"`
+	Data       map[string]string `json:"data"`
+}
+
+// ConfigureCoreDNS action configures the CoreDNS addon installed by kubeadm init, e.g. for testing DNS plugins
+// or CoreDNS upgrades; the coredns ConfigMap is updated with the Corefile read from the corefile path on the host,
+// if not empty, and the image of the coredns Deployment is updated with the given image repository and tag,
+// if not empty. Then the action waits for the coredns Deployment to roll out, unless wait is 0.
+// NB. the ClusterConfiguration stored in the cluster is not changed, so kubeadm upgrade reverts these settings;
+// use kubeadm.ConfigData CoreDNSImageTag and CoreDNSImageRepository for preserving the image across upgrades.
+func ConfigureCoreDNS(c *status.Cluster, corefile, imageRepository, imageTag string, wait time.Duration) error {
+	if corefile == "" && imageRepository == "" && imageTag == "" {
+		return errors.New("at least one of the Corefile, the image repository or the image tag must be set for configuring CoreDNS")
+	}
+
+	cp1 := c.BootstrapControlPlane()
+
+	if corefile != "" {
+		content, err := os.ReadFile(corefile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the Corefile %s", corefile)
+		}
+
+		cp1.Infof("Updating the coredns ConfigMap with the Corefile %s", corefile)
+		manifest, err := yaml.Marshal(corednsConfigMap{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Metadata:   map[string]string{"name": "coredns", "namespace": "kube-system"},
+			Data:       map[string]string{"Corefile": string(content)},
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to create the coredns ConfigMap")
+		}
+		if _, err := cp1.ApplyManifest(manifest); err != nil {
+			return err
+		}
+
+		// NB. the CoreDNS reload plugin is optional, so CoreDNS is restarted for picking up the new Corefile
+		if err := cp1.Command(
+			"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "-n=kube-system",
+			"rollout", "restart", "deployment/coredns",
+		).RunWithEcho(); err != nil {
+			return errors.Wrapf(err, "failed to restart the coredns Deployment from node %s", cp1.Name())
+		}
+	}
+
+	if imageRepository != "" || imageTag != "" {
+		lines, err := cp1.Command(
+			"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "-n=kube-system",
+			"get", "deployment/coredns", "-o=jsonpath={.spec.template.spec.containers[0].image}",
+		).Silent().RunAndCapture()
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the image of the coredns Deployment from node %s", cp1.Name())
+		}
+		if len(lines) != 1 {
+			return errors.Errorf("failed to read the image of the coredns Deployment from node %s", cp1.Name())
+		}
+
+		image, err := corednsImage(lines[0], imageRepository, imageTag)
+		if err != nil {
+			return err
+		}
+
+		cp1.Infof("Updating the coredns Deployment image to %s", image)
+		if err := cp1.Command(
+			"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "-n=kube-system",
+			"set", "image", "deployment/coredns", fmt.Sprintf("coredns=%s", image),
+		).RunWithEcho(); err != nil {
+			return errors.Wrapf(err, "failed to update the image of the coredns Deployment from node %s", cp1.Name())
+		}
+	}
+
+	// if wait is 0, exit fast like the other waits in kinder; nb. kubectl rollout status --timeout=0s waits forever
+	if wait == time.Duration(0) {
+		fmt.Println("Timeout set 0, skipping wait")
+		return nil
+	}
+
+	cp1.Infof("waiting for the coredns Deployment to roll out (timeout %s)", wait)
+	if err := cp1.Command(
+		"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "-n=kube-system",
+		"rollout", "status", "deployment/coredns", fmt.Sprintf("--timeout=%s", wait),
+	).RunWithEcho(); err != nil {
+		return errors.Wrap(err, "the coredns Deployment did not roll out")
+	}
+
+	return nil
+}
+
+// corednsImage returns the given image with the repository and the tag replaced by imageRepository and imageTag,
+// if not empty, e.g. registry.k8s.io/coredns/coredns:v1.11.3 with imageTag v1.12.0 becomes
+// registry.k8s.io/coredns/coredns:v1.12.0; like in kubeadm, the image repository does not include the image name.
+func corednsImage(image, imageRepository, imageTag string) (string, error) {
+	name, tag := image, ""
+	// NB. the tag separator is after the last path separator, because the registry host can include a port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, tag = image[:i], image[i+1:]
+	}
+
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return "", errors.Errorf("invalid CoreDNS image %q, the image repository is missing", image)
+	}
+	repository, name := name[:i], name[i+1:]
+
+	if imageRepository != "" {
+		repository = imageRepository
+	}
+	if imageTag != "" {
+		tag = imageTag
+	}
+	if tag == "" {
+		return "", errors.Errorf("invalid CoreDNS image %q, the image tag is missing", image)
+	}
+	return fmt.Sprintf("%s/%s:%s", repository, name, tag), nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"testing"
+)
+
+func TestCoreDNSImage(t *testing.T) {
+	tests := []struct {
+		name            string
+		image           string
+		imageRepository string
+		imageTag        string
+		expectedImage   string
+		expectedError   bool
+	}{
+		{
+			name:          "valid: image tag",
+			image:         "registry.k8s.io/coredns/coredns:v1.11.3",
+			imageTag:      "v1.12.0",
+			expectedImage: "registry.k8s.io/coredns/coredns:v1.12.0",
+		},
+		{
+			name:            "valid: image repository and tag",
+			image:           "registry.k8s.io/coredns/coredns:v1.11.3",
+			imageRepository: "docker.io/coredns",
+			imageTag:        "1.12.0",
+			expectedImage:   "docker.io/coredns/coredns:1.12.0",
+		},
+		{
+			name:            "valid: image repository with a registry port",
+			image:           "registry.k8s.io/coredns/coredns:v1.11.3",
+			imageRepository: "localhost:5000/coredns",
+			expectedImage:   "localhost:5000/coredns/coredns:v1.11.3",
+		},
+		{
+			name:          "valid: image tag for an image from a registry with a port",
+			image:         "localhost:5000/coredns:v1.11.3",
+			imageTag:      "v1.12.0",
+			expectedImage: "localhost:5000/coredns:v1.12.0",
+		},
+		{
+			name:          "invalid: image without repository",
+			image:         "coredns:v1.11.3",
+			imageTag:      "v1.12.0",
+			expectedError: true,
+		},
+		{
+			name:            "invalid: image without tag",
+			image:           "registry.k8s.io/coredns/coredns",
+			imageRepository: "docker.io/coredns",
+			expectedError:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			image, err := corednsImage(test.image, test.imageRepository, test.imageTag)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if image != test.expectedImage {
+				t.Errorf("expected image %q, got %q", test.expectedImage, image)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	versionutils "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+)
+
+const (
+	// etcdDataDir defines the etcd data dir used by kubeadm for stacked etcd members;
+	// the same folder exists in the etcd image, so it is used for staging snapshots
+	// for external etcd members as well
+	etcdDataDir = "/var/lib/etcd"
+
+	// etcdSnapshotPath defines the path where snapshots are staged on the etcd nodes
+	etcdSnapshotPath = etcdDataDir + "/kinder-snapshot.db"
+
+	// etcdRestoreDataDirName defines the name of the folder in the etcd data dir
+	// where snapshots are restored, before replacing the etcd data dir
+	etcdRestoreDataDirName = "kinder-restore"
+
+	// etcdRestoreDataDir defines the path where snapshots are restored on the etcd nodes
+	etcdRestoreDataDir = etcdDataDir + "/" + etcdRestoreDataDirName
+
+	// etcdBackupDataDir defines the path where the etcd data dir is moved when restoring a snapshot
+	etcdBackupDataDir = "/var/lib/etcd-backup"
+
+	// etcdManifestPath defines the path of the etcd static pod manifest
+	etcdManifestPath = "/etc/kubernetes/manifests/etcd.yaml"
+
+	// etcdStoppedManifestPath defines the path where the etcd static pod manifest is moved
+	// in order to stop etcd while restoring a snapshot
+	etcdStoppedManifestPath = "/kind/etcd.yaml"
+)
+
+// EtcdSnapshot saves a snapshot of etcd and copies it to the given path on the host.
+// In case of stacked etcd, the snapshot is taken from the etcd member running on the bootstrap
+// control-plane; in case of external etcd, the snapshot is taken from the external etcd member.
+func EtcdSnapshot(c *status.Cluster, snapshotPath string) error {
+	if snapshotPath == "" {
+		return errors.New("etcd-snapshot actions requires the --etcd-snapshot parameter to be set")
+	}
+
+	var n *status.Node
+	if etcd := c.ExternalEtcd(); etcd != nil {
+		n = etcd
+		n.Infof("Saving a snapshot of the external etcd")
+
+		if err := n.Command(
+			"etcdctl", "--endpoints=http://127.0.0.1:2379", "snapshot", "save", etcdSnapshotPath,
+		).RunWithEcho(); err != nil {
+			return errors.Wrapf(err, "failed to save the etcd snapshot on %s", n.Name())
+		}
+	} else {
+		n = c.BootstrapControlPlane()
+		n.Infof("Saving a snapshot of the stacked etcd")
+
+		etcdctlVersion, err := stackedEtcdVersion(n)
+		if err != nil {
+			return err
+		}
+
+		// NB. the etcd data dir is a hostPath volume, so the snapshot saved inside
+		// the etcd static pod is available on the node as well
+		etcdArgs := append(stackedEtcdExecArgs(n), "etcdctl", "--endpoints=https://127.0.0.1:2379")
+		if err := appendEtcdctlCertArgs(etcdctlVersion, &etcdArgs); err != nil {
+			return err
+		}
+		etcdArgs = append(etcdArgs, "snapshot", "save", etcdSnapshotPath)
+
+		if err := n.Command(
+			"kubectl", etcdArgs...,
+		).RunWithEcho(); err != nil {
+			return errors.Wrapf(err, "failed to save the etcd snapshot on %s", n.Name())
+		}
+	}
+
+	if err := n.CopyFrom(etcdSnapshotPath, snapshotPath); err != nil {
+		return errors.Wrapf(err, "failed to copy the etcd snapshot from %s", n.Name())
+	}
+
+	fmt.Printf("etcd snapshot saved to %s\n", snapshotPath)
+	return nil
+}
+
+// EtcdRestore restores the etcd snapshot available at the given path on the host.
+// The snapshot is restored into a new data dir using the etcd binaries in the etcd static pod;
+// then etcd is stopped by moving the static pod manifest out of the manifests folder,
+// the new data dir replaces the existing one (that is kept as a backup), and finally
+// etcd is restarted by moving back the static pod manifest.
+// Please note that restoring snapshots is supported only for stacked etcd with a single member.
+func EtcdRestore(c *status.Cluster, snapshotPath string, wait time.Duration) error {
+	if snapshotPath == "" {
+		return errors.New("etcd-restore actions requires the --etcd-snapshot parameter to be set")
+	}
+
+	// NB. the external etcd member is started with a fixed, implicit data dir by
+	// the etcd entry point, and the etcd image does not provide the tools for replacing it
+	if c.ExternalEtcd() != nil {
+		return errors.New("etcd-restore actions does not support clusters with external etcd")
+	}
+	if len(c.ControlPlanes()) > 1 {
+		return errors.New("etcd-restore actions does not support clusters with more than one control-plane node")
+	}
+
+	cp1 := c.BootstrapControlPlane()
+
+	if err := cp1.CopyTo(snapshotPath, etcdSnapshotPath); err != nil {
+		return errors.Wrapf(err, "failed to copy the etcd snapshot to %s", cp1.Name())
+	}
+
+	restoreArgs, err := etcdRestoreArgs(cp1)
+	if err != nil {
+		return err
+	}
+
+	cp1.Infof("Restoring the etcd snapshot into %s", etcdRestoreDataDir)
+	if err := cp1.Command(
+		"kubectl", append(stackedEtcdExecArgs(cp1), restoreArgs...)...,
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to restore the etcd snapshot on %s", cp1.Name())
+	}
+
+	cp1.Infof("Stopping etcd")
+	if err := cp1.Command(
+		"mv", etcdManifestPath, etcdStoppedManifestPath,
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to move the etcd static pod manifest on %s", cp1.Name())
+	}
+	if err := waitForEtcdStopped(cp1, 2*time.Minute); err != nil {
+		return err
+	}
+
+	cp1.Infof("Replacing the etcd data dir; the existing data dir is moved to %s", etcdBackupDataDir)
+	for _, args := range [][]string{
+		{"rm", "-rf", etcdBackupDataDir},
+		{"mv", etcdDataDir, etcdBackupDataDir},
+		{"mv", etcdBackupDataDir + "/" + etcdRestoreDataDirName, etcdDataDir},
+	} {
+		if err := cp1.Command(args[0], args[1:]...).RunWithEcho(); err != nil {
+			return errors.Wrapf(err, "failed to replace the etcd data dir on %s", cp1.Name())
+		}
+	}
+
+	cp1.Infof("Starting etcd")
+	if err := cp1.Command(
+		"mv", etcdStoppedManifestPath, etcdManifestPath,
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to move back the etcd static pod manifest on %s", cp1.Name())
+	}
+
+	cp1.Infof("waiting for etcd to become Ready (timeout %s)", wait)
+	if pass := waitFor(c, cp1, wait,
+		staticPodIsReady("etcd"),
+	); !pass {
+		return errors.New("timeout: etcd did not reach target state")
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// etcdRestoreArgs returns the args for restoring the staged etcd snapshot inside the etcd static pod
+// running on the given node; etcdutl is used when available, because snapshot restore
+// is deprecated in etcdctl since v3.5
+func etcdRestoreArgs(n *status.Node) ([]string, error) {
+	etcdVersion, err := stackedEtcdVersion(n)
+	if err != nil {
+		return nil, err
+	}
+	version, err := versionutils.ParseGeneric(etcdVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse etcd version")
+	}
+
+	ipv4, ipv6, err := n.IP()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the IP address of %s", n.Name())
+	}
+	peerURL := fmt.Sprintf("https://%s:2380", ipv4)
+	if ipv4 == "" {
+		peerURL = fmt.Sprintf("https://[%s]:2380", ipv6)
+	}
+
+	binary := "etcdctl"
+	if version.AtLeast(versionutils.MustParseGeneric("v3.5.0")) {
+		binary = "etcdutl"
+	}
+
+	return []string{
+		binary, "snapshot", "restore", etcdSnapshotPath,
+		fmt.Sprintf("--data-dir=%s", etcdRestoreDataDir),
+		fmt.Sprintf("--name=%s", n.Name()),
+		fmt.Sprintf("--initial-cluster=%s=%s", n.Name(), peerURL),
+		fmt.Sprintf("--initial-advertise-peer-urls=%s", peerURL),
+	}, nil
+}
+
+// waitForEtcdStopped waits for the etcd container running on the given node to be stopped
+func waitForEtcdStopped(n *status.Node, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		lines, err := n.Command(
+			"crictl", "ps", "--quiet", "--name", "^etcd$",
+		).Silent().RunAndCapture()
+		if err == nil && len(lines) == 0 {
+			return nil
+		}
+		time.Sleep(1 * time.Second)
+	}
+	return errors.Errorf("timeout: etcd on %s did not stop", n.Name())
+}
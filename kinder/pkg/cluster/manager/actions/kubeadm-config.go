@@ -34,60 +34,117 @@ import (
 
 // kubeadmConfigOptionsall stores all the kinder flags that impact on the kubeadm config generation
 type kubeadmConfigOptions struct {
-	configVersion string
-	copyCertsMode CopyCertsMode
-	discoveryMode DiscoveryMode
+	configVersion  string
+	copyCertsMode  CopyCertsMode
+	discoveryMode  DiscoveryMode
+	certificateKey string
 }
 
 // KubeadmInitConfig action writes the InitConfiguration into /kind/kubeadm.conf file on all the K8s nodes in the cluster.
 // Please note that this action is automatically executed at create time, but it is possible
 // to invoke it separately as well.
-func KubeadmInitConfig(c *status.Cluster, kubeadmConfigVersion string, copyCertsMode CopyCertsMode, featureGate, encryptionAlgorithm, ignorePreflightErrors string, nodes ...*status.Node) error {
+func KubeadmInitConfig(c *status.Cluster, kubeadmConfigVersion string, copyCertsMode CopyCertsMode, featureGate, encryptionAlgorithm, criSocket, dnsDomain, ignorePreflightErrors string, skipKubeProxy bool, certSANs []string, nodes ...*status.Node) error {
 	// defaults everything not relevant for the Init Config
-	return KubeadmConfig(c, kubeadmConfigVersion, copyCertsMode, TokenDiscovery, featureGate, encryptionAlgorithm, ignorePreflightErrors, nil, nodes...)
+	return KubeadmConfig(c, kubeadmConfigVersion, copyCertsMode, TokenDiscovery, featureGate, encryptionAlgorithm, criSocket, dnsDomain, ignorePreflightErrors, nil, skipKubeProxy, certSANs, nodes...)
 }
 
 // KubeadmJoinConfig action writes the JoinConfiguration into /kind/kubeadm.conf file on all the K8s nodes in the cluster.
 // Please note that this action is automatically executed at create time, but it is possible
 // to invoke it separately as well.
-func KubeadmJoinConfig(c *status.Cluster, kubeadmConfigVersion string, copyCertsMode CopyCertsMode, discoveryMode DiscoveryMode, ignorePreflightErrors string, nodes ...*status.Node) error {
+func KubeadmJoinConfig(c *status.Cluster, kubeadmConfigVersion string, copyCertsMode CopyCertsMode, discoveryMode DiscoveryMode, criSocket, ignorePreflightErrors string, nodes ...*status.Node) error {
 	// defaults everything not relevant for the join Config
-	return KubeadmConfig(c, kubeadmConfigVersion, copyCertsMode, discoveryMode, "", "", ignorePreflightErrors, nil, nodes...)
+	return KubeadmConfig(c, kubeadmConfigVersion, copyCertsMode, discoveryMode, "", "", criSocket, "", ignorePreflightErrors, nil, false, nil, nodes...)
 }
 
 // KubeadmUpgradeConfig action writes the UpgradeConfiguration into /kind/kubeadm.conf file on all the K8s nodes in the cluster.
-func KubeadmUpgradeConfig(c *status.Cluster, ignorePreflightErrors string, upgradeVersion *version.Version, nodes ...*status.Node) error {
-	return KubeadmConfig(c, "", "", "", "", "", ignorePreflightErrors, upgradeVersion, nodes...)
+func KubeadmUpgradeConfig(c *status.Cluster, kubeadmConfigVersion, ignorePreflightErrors string, skipKubeProxy bool, upgradeVersion *version.Version, nodes ...*status.Node) error {
+	return KubeadmConfig(c, kubeadmConfigVersion, "", "", "", "", "", "", ignorePreflightErrors, upgradeVersion, skipKubeProxy, nil, nodes...)
 }
 
-// KubeadmResetConfig action writes the UpgradeConfiguration into /kind/kubeadm.conf file on all the K8s nodes in the cluster.
-func KubeadmResetConfig(c *status.Cluster, ignorePreflightErrors string, nodes ...*status.Node) error {
-	return KubeadmConfig(c, "", "", "", "", "", ignorePreflightErrors, nil, nodes...)
+// KubeadmResetConfig action writes the ResetConfiguration into /kind/kubeadm.conf file on all the K8s nodes in the cluster;
+// reset allows to customize the ResetConfiguration, and it is validated against the kubeadm version of each node.
+func KubeadmResetConfig(c *status.Cluster, kubeadmConfigVersion, ignorePreflightErrors string, reset kubeadm.ResetConfigData, nodes ...*status.Node) error {
+	configData, err := kubeadmConfigData(c, "", "", "", "", ignorePreflightErrors, nil)
+	if err != nil {
+		return err
+	}
+	configData.Reset = reset
+
+	configOptions := kubeadmConfigOptions{
+		configVersion: kubeadmConfigVersion,
+		copyCertsMode: CopyCertsModeAuto,
+		discoveryMode: TokenDiscovery,
+	}
+
+	for _, node := range nodes {
+		if err := writeKubeadmConfig(c, node, configData, configOptions); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // KubeadmConfig action writes the /kind/kubeadm.conf file on all the K8s nodes in the cluster.
 // Please note that this action is automatically executed at create time, but it is possible
 // to invoke it separately as well.
-func KubeadmConfig(c *status.Cluster, kubeadmConfigVersion string, copyCertsMode CopyCertsMode, discoveryMode DiscoveryMode, featureGate, encryptionAlgorithm, ignorePreflightErrors string, upgradeVersion *version.Version, nodes ...*status.Node) error {
+// If certSANs is not empty, the given Subject Alternative Names are added to the API server serving certificate.
+func KubeadmConfig(c *status.Cluster, kubeadmConfigVersion string, copyCertsMode CopyCertsMode, discoveryMode DiscoveryMode, featureGate, encryptionAlgorithm, criSocket, dnsDomain, ignorePreflightErrors string, upgradeVersion *version.Version, skipKubeProxy bool, certSANs []string, nodes ...*status.Node) error {
+	// create configData with all the configurations supported by the kubeadm config template implemented in kind
+	configData, err := kubeadmConfigData(c, featureGate, encryptionAlgorithm, criSocket, dnsDomain, ignorePreflightErrors, upgradeVersion)
+	if err != nil {
+		return err
+	}
+	configData.SkipKubeProxy = skipKubeProxy
+	configData.CertSANs = certSANs
+
+	if copyCertsMode == "" {
+		copyCertsMode = CopyCertsModeAuto
+	}
+
+	if discoveryMode == "" {
+		discoveryMode = TokenDiscovery
+	}
+
+	// create configOptions with all the kinder flags that impact on the kubeadm config generation
+	configOptions := kubeadmConfigOptions{
+		configVersion: kubeadmConfigVersion,
+		copyCertsMode: copyCertsMode,
+		discoveryMode: discoveryMode,
+	}
+
+	// writs the kubeadm config file on all the K8s nodes.
+	for _, node := range nodes {
+		if err := writeKubeadmConfig(c, node, configData, configOptions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// kubeadmConfigData returns the ConfigData with the cluster wide settings used for generating the kubeadm config;
+// node specific settings are added by nodeKubeadmConfigData
+func kubeadmConfigData(c *status.Cluster, featureGate, encryptionAlgorithm, criSocket, dnsDomain, ignorePreflightErrors string, upgradeVersion *version.Version) (kubeadm.ConfigData, error) {
 	cp1 := c.BootstrapControlPlane()
 
 	// get installed kubernetes version from the node image
 	kubeVersion, err := cp1.KubeVersion()
 	if err != nil {
-		return errors.Wrap(err, "failed to get kubernetes version from node")
+		return kubeadm.ConfigData{}, errors.Wrap(err, "failed to get kubernetes version from node")
 	}
 
 	// gets the IP of the bootstrap control plane node
 	controlPlaneIP, controlPlaneIPV6, err := c.BootstrapControlPlane().IP()
 	if err != nil {
-		return errors.Wrapf(err, "failed to get IP for node: %s", c.BootstrapControlPlane().Name())
+		return kubeadm.ConfigData{}, errors.Wrapf(err, "failed to get IP for node: %s", c.BootstrapControlPlane().Name())
 	}
 
 	// get the control plane endpoint, in case the cluster has an external load balancer in
 	// front of the control-plane nodes
 	controlPlaneEndpoint, controlPlaneEndpointIPv6, ControlPlanePort, err := getControlPlaneAddress(c)
 	if err != nil {
-		return err
+		return kubeadm.ConfigData{}, err
 	}
 
 	// configure the right protocol addresses
@@ -101,26 +158,24 @@ func KubeadmConfig(c *status.Cluster, kubeadmConfigVersion string, copyCertsMode
 	if len(featureGate) > 0 {
 		split := strings.Split(featureGate, "=")
 		if len(split) != 2 {
-			return errors.New("feature gate must be formatted as 'key=value'")
+			return kubeadm.ConfigData{}, errors.New("feature gate must be formatted as 'key=value'")
 		}
 		featureGateName = split[0]
 		featureGateValue = split[1]
 	}
 
-	if copyCertsMode == "" {
-		copyCertsMode = CopyCertsModeAuto
-	}
-
-	if discoveryMode == "" {
-		discoveryMode = TokenDiscovery
-	}
-
 	// Use a placeholder upgrade version for non-upgrade actions.
 	if upgradeVersion == nil {
 		upgradeVersion = version.MustParseSemantic("v1.0.0")
 	}
 
 	// create configData with all the configurations supported by the kubeadm config template implemented in kind
+	// an empty flag value means no preflight errors to ignore
+	var ignorePreflightErrorsList []string
+	if ignorePreflightErrors != "" {
+		ignorePreflightErrorsList = strings.Split(ignorePreflightErrors, ",")
+	}
+
 	configData := kubeadm.ConfigData{
 		ClusterName:           c.Name(),
 		KubernetesVersion:     kubeVersion,
@@ -129,30 +184,18 @@ func KubeadmConfig(c *status.Cluster, kubeadmConfigVersion string, copyCertsMode
 		APIServerAddress:      controlPlaneIP,
 		Token:                 constants.Token,
 		PodSubnet:             "192.168.0.0/16", // default for kindnet
+		DNSDomain:             dnsDomain,
 		ControlPlane:          true,
 		IPv6:                  c.Settings.IPFamily == status.IPv6Family,
 		FeatureGateName:       featureGateName,
 		FeatureGateValue:      featureGateValue,
 		EncryptionAlgorithm:   encryptionAlgorithm,
+		CRISocket:             criSocket,
 		UpgradeVersion:        fmt.Sprintf("v%s", upgradeVersion.String()),
-		IgnorePreflightErrors: strings.Split(ignorePreflightErrors, ","),
-	}
-
-	// create configOptions with all the kinder flags that impact on the kubeadm config generation
-	configOptions := kubeadmConfigOptions{
-		configVersion: kubeadmConfigVersion,
-		copyCertsMode: copyCertsMode,
-		discoveryMode: discoveryMode,
+		IgnorePreflightErrors: ignorePreflightErrorsList,
 	}
 
-	// writs the kubeadm config file on all the K8s nodes.
-	for _, node := range nodes {
-		if err := writeKubeadmConfig(c, node, configData, configOptions); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return configData, nil
 }
 
 // getControlPlaneAddress return the join address that is the control plane endpoint in case the cluster has
@@ -185,19 +228,16 @@ func writeKubeadmConfig(c *status.Cluster, n *status.Node, data kubeadm.ConfigDa
 	n.Infof("Preparing %s", constants.KubeadmConfigPath)
 
 	// Amends the ConfigData struct with node specific settings
-
-	// control plane/worker role
-	data.ControlPlane = n.IsControlPlane()
-
-	// the node address
-	nodeAddress, nodeAddressIPv6, err := n.IP()
+	data, err := nodeKubeadmConfigData(c, n, data)
 	if err != nil {
-		return errors.Wrap(err, "failed to get IP for node")
+		return err
 	}
 
-	data.NodeAddress = nodeAddress
-	if c.Settings.IPFamily == status.IPv6Family {
-		data.NodeAddress = nodeAddressIPv6
+	// the kubeadm config pins the kubelet cgroup driver to systemd, so warn if the container runtime uses a different one
+	if cgroupDriver, err := n.CgroupDriver(); err != nil {
+		log.Debugf("failed to detect the cgroup driver on node %s: %v", n.Name(), err)
+	} else if cgroupDriver != status.SystemdCgroupDriver {
+		log.Warnf("the container runtime on node %s uses the %s cgroup driver, while the kubelet is configured to use the %s cgroup driver", n.Name(), cgroupDriver, status.SystemdCgroupDriver)
 	}
 
 	// Gets the kubeadm config customize for this node
@@ -219,24 +259,39 @@ func writeKubeadmConfig(c *status.Cluster, n *status.Node, data kubeadm.ConfigDa
 	return nil
 }
 
+// nodeKubeadmConfigData amends the ConfigData with the settings specific for a node
+func nodeKubeadmConfigData(c *status.Cluster, n *status.Node, data kubeadm.ConfigData) (kubeadm.ConfigData, error) {
+	// control plane/worker role
+	data.ControlPlane = n.IsControlPlane()
+
+	// the node address
+	nodeAddress, nodeAddressIPv6, err := n.IP()
+	if err != nil {
+		return data, errors.Wrap(err, "failed to get IP for node")
+	}
+
+	data.NodeAddress = nodeAddress
+	if c.Settings.IPFamily == status.IPv6Family {
+		data.NodeAddress = nodeAddressIPv6
+	}
+
+	return data, nil
+}
+
 // getKubeadmConfig generates the kubeadm config customized for a specific node
 func getKubeadmConfig(c *status.Cluster, n *status.Node, data kubeadm.ConfigData, options kubeadmConfigOptions) (string, error) {
-	kubeadmVersion, err := n.KubeadmVersion()
+	kubeadmConfigVersion, kubeadmVersion, err := kubeadm.ResolveConfigVersion(n, options.configVersion)
 	if err != nil {
 		return "", err
 	}
-	log.Debugf("kubeadm version %s", kubeadmVersion)
+	log.Debugf("using kubeadm config version %s", kubeadmConfigVersion)
 
-	kubeadmConfigVersion := options.configVersion
-	if len(kubeadmConfigVersion) == 0 {
-		kubeadmConfigVersion = kubeadm.GetKubeadmConfigVersion(kubeadmVersion)
+	if err := kubeadm.ValidateIgnorePreflightErrors(data.IgnorePreflightErrors); err != nil {
+		return "", errors.Wrapf(err, "invalid preflight errors to ignore for node %s", n.Name())
 	}
-	log.Debugf("using kubeadm config version %s", kubeadmConfigVersion)
 
-	// generate the "raw config", using the kubeadm config template provided by kind
-	rawconfig, err := kubeadm.Config(kubeadmConfigVersion, data)
-	if err != nil {
-		return "", err
+	if err := kubeadm.ValidateResetConfigData(data.Reset, kubeadmVersion); err != nil {
+		return "", errors.Wrapf(err, "invalid reset config for node %s", n.Name())
 	}
 
 	// apply all the kinder specific settings using patches
@@ -264,12 +319,21 @@ func getKubeadmConfig(c *status.Cluster, n *status.Node, data kubeadm.ConfigData
 
 	patches = append(patches, criPatches...)
 
+	// eventually override the default CRI socket of the CRI installed on the node
+	if len(data.CRISocket) > 0 {
+		criSocketPatches, err := kubeadm.GetCRISocketPatch(kubeadmConfigVersion, data.CRISocket)
+		if err != nil {
+			return "", err
+		}
+		patches = append(patches, criSocketPatches...)
+	}
+
 	// if requested automatic copy certs and the node is a controlplane node,
 	// add patches for adding the certificateKey value
 	// NB. this is a no-op in case of kubeadm config API older than v1beta2, because
 	// this feature was not supported before (the --certificate-key flag should be used instead)
 	if options.copyCertsMode == CopyCertsModeAuto && n.IsControlPlane() {
-		automaticCopyCertsPatches, err := kubeadm.GetAutomaticCopyCertsPatches(kubeadmConfigVersion)
+		automaticCopyCertsPatches, err := kubeadm.GetAutomaticCopyCertsPatches(kubeadmConfigVersion, options.certificateKey)
 		if err != nil {
 			return "", err
 		}
@@ -340,43 +404,61 @@ func getKubeadmConfig(c *status.Cluster, n *status.Node, data kubeadm.ConfigData
 
 	// encryption algorithm
 	if len(data.EncryptionAlgorithm) > 0 {
-		encryptionAlgorithmPatch, err := kubeadm.GetEncryptionAlgorithmPatch(kubeadmConfigVersion, data.EncryptionAlgorithm)
+		encryptionAlgorithmPatch, err := kubeadm.GetEncryptionAlgorithmPatch(kubeadmConfigVersion, data.EncryptionAlgorithm, kubeadmVersion)
 		if err != nil {
 			return "", err
 		}
 		patches = append(patches, encryptionAlgorithmPatch)
 	}
 
-	// apply patches
-	patched, err := kubeadm.Build(rawconfig, patches, jsonPatches)
+	// additional API server cert SANs
+	if len(data.CertSANs) > 0 {
+		certSANsPatch, err := kubeadm.GetCertSANsPatch(kubeadmConfigVersion, data.CertSANs)
+		if err != nil {
+			return "", err
+		}
+		jsonPatches = append(jsonPatches, certSANsPatch)
+	}
+
+	// generate the config, using the kubeadm config template provided by kind, and apply patches
+	patched, err := kubeadm.RenderConfig(kubeadmConfigVersion, data, patches, jsonPatches)
 	if err != nil {
 		return "", err
 	}
 
 	// Select the objects that are relevant for a specific node;
-	// if the node is the bootstrap control plane, then all the objects used as init time
+	// if the node is the bootstrap control plane, then all the objects used as init time,
+	// otherwise select only the JoinConfiguration
+	var kubeadmConfig string
 	if n == c.BootstrapControlPlane() {
-		return selectYamlFramentByKind(patched,
+		kubeadmConfig = selectYamlFramentByKind(patched,
 			"ClusterConfiguration",
 			"InitConfiguration",
 			"UpgradeConfiguration",
 			"ResetConfiguration",
 			"KubeletConfiguration",
-			"KubeProxyConfiguration"), nil
+			"KubeProxyConfiguration")
+	} else {
+		kubeadmConfig = selectYamlFramentByKind(patched,
+			"JoinConfiguration",
+			"UpgradeConfiguration",
+			"ResetConfiguration",
+		)
 	}
 
-	// otherwise select only the JoinConfiguration
-	return selectYamlFramentByKind(patched,
-		"JoinConfiguration",
-		"UpgradeConfiguration",
-		"ResetConfiguration",
-	), nil
+	// validate the config offline, so issues introduced by patches are reported before running kubeadm
+	if err := kubeadm.ValidateConfig(kubeadmConfigVersion, kubeadmConfig); err != nil {
+		log.Warnf("kubeadm config for node %s: %v", n.Name(), err)
+	}
+
+	return kubeadmConfig, nil
 }
 
 func createDiscoveryFile(c *status.Cluster, n *status.Node, discoveryMode DiscoveryMode) error {
-	// the discovery file is a kubeaconfig file, so for sake of semplicity in setting up this test,
-	// we are using the admin.conf file created by kubeadm on the bootstrap control plane node
-	// as a starting point (e.g. it already contains the necessary server address/server certificate)
+	// the discovery file is a minimal kubeconfig file, built with the server address and the cluster CA
+	// read from the admin.conf file created by kubeadm on the bootstrap control plane node;
+	// for sake of semplicity in setting up this test, the discovery modes with client certificates
+	// use the client certificate embedded in admin.conf.
 	// IMPORTANT. Don't do this in production, admin.conf contains cluster-admin credentials.
 	lines, err := c.BootstrapControlPlane().Command(
 		"cat", "/etc/kubernetes/admin.conf",
@@ -388,44 +470,52 @@ func createDiscoveryFile(c *status.Cluster, n *status.Node, discoveryMode Discov
 		return errors.Errorf("failed to read /etc/kubernetes/admin.conf from %s", c.BootstrapControlPlane().Name())
 	}
 
-	configBytes := []byte(strings.Join(lines, "\n"))
-	config, err := clientcmd.Load(configBytes)
+	admin, err := clientcmd.Load([]byte(strings.Join(lines, "\n")))
 	if err != nil {
 		return errors.Wrapf(err, "failed to parse /etc/kubernetes/admin.conf from %s", c.BootstrapControlPlane().Name())
 	}
+	adminContext, ok := admin.Contexts[admin.CurrentContext]
+	if !ok || admin.Clusters[adminContext.Cluster] == nil || admin.AuthInfos[adminContext.AuthInfo] == nil {
+		return errors.Errorf("invalid current context in /etc/kubernetes/admin.conf from %s", c.BootstrapControlPlane().Name())
+	}
+	adminCluster := admin.Clusters[adminContext.Cluster]
+	adminAuthInfo := admin.AuthInfos[adminContext.AuthInfo]
+
+	configBytes, err := kubeadm.BuildDiscoveryKubeconfig(adminCluster.CertificateAuthorityData, adminCluster.Server)
+	if err != nil {
+		return err
+	}
+	config, err := clientcmd.Load(configBytes)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse %s", constants.DiscoveryFile)
+	}
 
-	// tweak admin.conf into a discovery file that comply the expected Discovery Mode variant
-	user := config.Contexts[config.CurrentContext].AuthInfo
-	authInfo := config.AuthInfos[user]
+	// augment the discovery file in order to comply the expected Discovery Mode variant
+	authInfo := config.AuthInfos[kubeadm.DiscoveryUserName]
 
 	switch discoveryMode {
 	case FileDiscoveryWithoutCredentials:
-		// Nuke X509 credentials embedded in the admin.conf file
-		authInfo.ClientKeyData = []byte{}
-		authInfo.ClientCertificateData = []byte{}
+		// This is NOP, because the discovery file does not contain credentials
 	case FileDiscoveryWithToken:
-		// Nuke X509 credentials embedded in the admin.conf file
-		authInfo.ClientKeyData = []byte{}
-		authInfo.ClientCertificateData = []byte{}
 		// Add a token
 		authInfo.Token = constants.Token
 	case FileDiscoveryWithEmbeddedClientCerts:
-		// This is NOP, because admin.conf already contains embedded client certs
+		// Embed the client certs from the admin.conf file
+		authInfo.ClientKeyData = adminAuthInfo.ClientKeyData
+		authInfo.ClientCertificateData = adminAuthInfo.ClientCertificateData
 	case FileDiscoveryWithExternalClientCerts:
 		// Save the client certificate key embedded in admin.conf into an external file and update authinfo accordingly
 		keyFile := "/kinder/discovery-client-key.pem"
-		if err := n.WriteFile(keyFile, authInfo.ClientKeyData); err != nil {
+		if err := n.WriteFile(keyFile, adminAuthInfo.ClientKeyData); err != nil {
 			return err
 		}
-		authInfo.ClientKeyData = []byte{}
 		authInfo.ClientKey = keyFile
 
 		// Save the client certificate embedded in admin.conf into an external file and update authinfo accordingly
 		certFile := "/kinder/discovery-client-cert.pem"
-		if err := n.WriteFile(certFile, authInfo.ClientCertificateData); err != nil {
+		if err := n.WriteFile(certFile, adminAuthInfo.ClientCertificateData); err != nil {
 			return err
 		}
-		authInfo.ClientCertificateData = []byte{}
 		authInfo.ClientCertificate = certFile
 	}
 
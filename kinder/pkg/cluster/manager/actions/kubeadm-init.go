@@ -35,8 +35,10 @@ import (
 )
 
 // KubeadmInit executes the kubeadm init workflow including also post init task
-// like installing the CNI network plugin
-func KubeadmInit(c *status.Cluster, usePhases bool, copyCertsMode CopyCertsMode, kubeadmConfigVersion, patchesDir, ignorePreflightErrors, featureGates, encryptionAlgorithm string, wait time.Duration, vLevel int) (err error) {
+// like installing the CNI network plugin; if skipKubeProxy is set, the kube-proxy addon is not installed,
+// e.g. for testing CNI plugins replacing kube-proxy, and its absence is verified after init; if certSANs is not empty,
+// the given Subject Alternative Names are added to the API server serving certificate, and verified after init
+func KubeadmInit(c *status.Cluster, usePhases bool, copyCertsMode CopyCertsMode, kubeadmConfigVersion, patchesDir, ignorePreflightErrors, featureGates, encryptionAlgorithm, criSocket, dnsDomain string, skipKubeProxy bool, certSANs []string, wait time.Duration, vLevel int) (err error) {
 	cp1 := c.BootstrapControlPlane()
 
 	if err := copyPatchesToNode(cp1, patchesDir); err != nil {
@@ -54,7 +56,7 @@ func KubeadmInit(c *status.Cluster, usePhases bool, copyCertsMode CopyCertsMode,
 	}
 
 	// prepares the kubeadm config on this node
-	if err := KubeadmInitConfig(c, kubeadmConfigVersion, copyCertsMode, featureGates, encryptionAlgorithm, ignorePreflightErrors, cp1); err != nil {
+	if err := KubeadmInitConfig(c, kubeadmConfigVersion, copyCertsMode, featureGates, encryptionAlgorithm, criSocket, dnsDomain, ignorePreflightErrors, skipKubeProxy, certSANs, cp1); err != nil {
 		return err
 	}
 
@@ -65,7 +67,7 @@ func KubeadmInit(c *status.Cluster, usePhases bool, copyCertsMode CopyCertsMode,
 
 	// execs the kubeadm init workflow
 	if usePhases {
-		err = kubeadmInitWithPhases(cp1, copyCertsMode, vLevel)
+		err = kubeadmInitWithPhases(cp1, copyCertsMode, skipKubeProxy, vLevel)
 	} else {
 		err = kubeadmInit(cp1, copyCertsMode, vLevel)
 	}
@@ -74,7 +76,17 @@ func KubeadmInit(c *status.Cluster, usePhases bool, copyCertsMode CopyCertsMode,
 	}
 
 	// completes post init task by installing the CNI network plugin
-	if err := postInit(c, wait); err != nil {
+	if err := postInit(c, skipKubeProxy, wait); err != nil {
+		return err
+	}
+
+	if skipKubeProxy {
+		if err := verifyKubeProxyAbsent(cp1); err != nil {
+			return err
+		}
+	}
+
+	if err := verifyAPIServerCertSANs(cp1, certSANs); err != nil {
 		return err
 	}
 
@@ -103,7 +115,7 @@ func kubeadmInit(cp1 *status.Node, copyCertsMode CopyCertsMode, vLevel int) erro
 	return nil
 }
 
-func kubeadmInitWithPhases(cp1 *status.Node, copyCertsMode CopyCertsMode, vLevel int) error {
+func kubeadmInitWithPhases(cp1 *status.Node, copyCertsMode CopyCertsMode, skipKubeProxy bool, vLevel int) error {
 	if err := cp1.Command(
 		"kubeadm", "init", "phase", "preflight", fmt.Sprintf("--config=%s", constants.KubeadmConfigPath), fmt.Sprintf("--v=%d", vLevel),
 	).RunWithEcho(); err != nil {
@@ -185,8 +197,13 @@ func kubeadmInitWithPhases(cp1 *status.Node, copyCertsMode CopyCertsMode, vLevel
 		return err
 	}
 
+	// NB. skipPhases in the kubeadm config is ignored when executing single phases
+	addon := "all"
+	if skipKubeProxy {
+		addon = "coredns"
+	}
 	if err := cp1.Command(
-		"kubeadm", "init", "phase", "addon", "all", fmt.Sprintf("--config=%s", constants.KubeadmConfigPath), fmt.Sprintf("--v=%d", vLevel),
+		"kubeadm", "init", "phase", "addon", addon, fmt.Sprintf("--config=%s", constants.KubeadmConfigPath), fmt.Sprintf("--v=%d", vLevel),
 	).RunWithEcho(); err != nil {
 		return err
 	}
@@ -194,7 +211,23 @@ func kubeadmInitWithPhases(cp1 *status.Node, copyCertsMode CopyCertsMode, vLevel
 	return nil
 }
 
-func postInit(c *status.Cluster, wait time.Duration) error {
+// verifyKubeProxyAbsent checks that the kube-proxy DaemonSet does not exist in the cluster
+func verifyKubeProxyAbsent(cp1 *status.Node) error {
+	cp1.Infof("verifying the kube-proxy addon is not installed")
+	lines, err := cp1.Command(
+		"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "get", "daemonsets", "-n=kube-system",
+		"--field-selector=metadata.name=kube-proxy", "-o=name",
+	).Silent().RunAndCapture()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the DaemonSets: %s", strings.Join(lines, "\n"))
+	}
+	if len(lines) > 0 {
+		return errors.New("the kube-proxy DaemonSet exists, but the kube-proxy addon was expected to be skipped")
+	}
+	return nil
+}
+
+func postInit(c *status.Cluster, skipKubeProxy bool, wait time.Duration) error {
 	cp1 := c.BootstrapControlPlane()
 
 	if err := copyKubeConfigToHost(c); err != nil {
@@ -202,9 +235,21 @@ func postInit(c *status.Cluster, wait time.Duration) error {
 	}
 
 	// Apply a CNI plugin using a hardcoded manifest
+	// NB. without kube-proxy the kubernetes service is not reachable, so kindnet is pointed to the control plane endpoint
+	manifest := assets.KindnetManifest054
+	if skipKubeProxy {
+		controlPlaneIP, controlPlaneIPv6, controlPlanePort, err := getControlPlaneAddress(c)
+		if err != nil {
+			return err
+		}
+		if c.Settings.IPFamily == status.IPv6Family {
+			controlPlaneIP = controlPlaneIPv6
+		}
+		manifest = kindnetManifestWithAPIServer(manifest, controlPlaneIP, controlPlanePort)
+	}
 	cmd := cp1.Command("kubectl", "apply", "--kubeconfig=/etc/kubernetes/admin.conf", "-f", "-")
 	cp1.Infof("applying kindnet version 0.5.4")
-	cmd.Stdin(strings.NewReader(assets.KindnetManifest054))
+	cmd.Stdin(strings.NewReader(manifest))
 	if err := cmd.RunWithEcho(); err != nil {
 		return err
 	}
@@ -241,6 +286,22 @@ func postInit(c *status.Cluster, wait time.Duration) error {
 	return nil
 }
 
+// kindnetManifestWithAPIServer returns the kindnet manifest with the KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT
+// env variables pointing to the given API server address, so kindnet does not depend on the kubernetes service
+func kindnetManifestWithAPIServer(manifest, host string, port int) string {
+	return strings.Replace(manifest, `
+            - name: POD_SUBNET
+              value: "192.168.0.0/16"
+`, fmt.Sprintf(`
+            - name: POD_SUBNET
+              value: "192.168.0.0/16"
+            - name: KUBERNETES_SERVICE_HOST
+              value: "%s"
+            - name: KUBERNETES_SERVICE_PORT
+              value: "%d"
+`, host, port), 1)
+}
+
 // copyKubeConfigToHost copies the admin.conf file to the host in order to make the cluster
 // usable with kubectl.
 // the kubeconfig file created by kubeadm internally to the node must be modified in order to use
@@ -347,3 +408,42 @@ func copyPatchesToNode(n *status.Node, dir string) error {
 
 	return nil
 }
+
+// verifyAPIServerCertSANs checks that the given Subject Alternative Names are included in the
+// API server serving certificate of a control plane node, if any
+func verifyAPIServerCertSANs(cp *status.Node, certSANs []string) error {
+	if len(certSANs) == 0 {
+		return nil
+	}
+
+	sans, err := cp.APIServerCertSANs()
+	if err != nil {
+		return err
+	}
+	if missing := missingCertSANs(sans, certSANs); len(missing) > 0 {
+		return errors.Errorf("the API server serving certificate on node %s does not include the SANs %v", cp.Name(), missing)
+	}
+
+	log.Printf("API server serving certificate on node %s includes the SANs %v\n", cp.Name(), certSANs)
+	return nil
+}
+
+// missingCertSANs returns the expected Subject Alternative Names not included in sans;
+// IP addresses are compared by value, while DNS names are compared case insensitively
+func missingCertSANs(sans, expected []string) []string {
+	var missing []string
+	for _, e := range expected {
+		found := false
+		for _, san := range sans {
+			ip, expectedIP := net.ParseIP(san), net.ParseIP(e)
+			if (ip != nil && ip.Equal(expectedIP)) || (expectedIP == nil && strings.EqualFold(san, e)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, e)
+		}
+	}
+	return missing
+}
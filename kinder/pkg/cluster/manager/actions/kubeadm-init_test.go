@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/manager/actions/assets"
+)
+
+func TestKindnetManifestWithAPIServer(t *testing.T) {
+	manifest := kindnetManifestWithAPIServer(assets.KindnetManifest054, "172.17.0.2", 6443)
+
+	expected := `
+            - name: KUBERNETES_SERVICE_HOST
+              value: "172.17.0.2"
+            - name: KUBERNETES_SERVICE_PORT
+              value: "6443"
+`
+	if !strings.Contains(manifest, expected) {
+		t.Errorf("expected the kindnet manifest to contain %q, got:\n%s", expected, manifest)
+	}
+}
+
+func TestMissingCertSANs(t *testing.T) {
+	sans := []string{"kinder-control-plane", "kubernetes.default", "10.96.0.1", "fd00::1"}
+
+	tests := []struct {
+		name            string
+		expected        []string
+		expectedMissing []string
+	}{
+		{
+			name:     "all the SANs are included",
+			expected: []string{"kubernetes.default", "10.96.0.1"},
+		},
+		{
+			name:     "DNS names are compared case insensitively",
+			expected: []string{"Kinder-Control-Plane"},
+		},
+		{
+			name:     "IP addresses are compared by value",
+			expected: []string{"fd00:0:0:0:0:0:0:1"},
+		},
+		{
+			name:            "missing SANs are returned",
+			expected:        []string{"kubernetes.default", "api.example.com", "10.96.0.2"},
+			expectedMissing: []string{"api.example.com", "10.96.0.2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			missing := missingCertSANs(sans, test.expected)
+			if strings.Join(missing, ",") != strings.Join(test.expectedMissing, ",") {
+				t.Errorf("expected missing SANs %v, got %v", test.expectedMissing, missing)
+			}
+		})
+	}
+}
@@ -25,19 +25,21 @@ import (
 )
 
 // KubeadmJoin executes the kubeadm join workflow both for control-plane nodes and
-// worker nodes
-func KubeadmJoin(c *status.Cluster, usePhases bool, copyCertsMode CopyCertsMode, discoveryMode DiscoveryMode, kubeadmConfigVersion, patchesDir, ignorePreflightErrors string, wait time.Duration, vLevel int) (err error) {
-	if err := joinControlPlanes(c, usePhases, copyCertsMode, discoveryMode, kubeadmConfigVersion, patchesDir, ignorePreflightErrors, wait, vLevel); err != nil {
+// worker nodes; if certSANs is not empty, the given Subject Alternative Names are verified in the
+// API server serving certificate of the joining control-plane nodes. Please note that kubeadm join
+// generates the certificate using the certSANs in the ClusterConfiguration of the cluster, set by init.
+func KubeadmJoin(c *status.Cluster, usePhases bool, copyCertsMode CopyCertsMode, discoveryMode DiscoveryMode, kubeadmConfigVersion, patchesDir, criSocket, ignorePreflightErrors string, certSANs []string, wait time.Duration, vLevel int) (err error) {
+	if err := joinControlPlanes(c, usePhases, copyCertsMode, discoveryMode, kubeadmConfigVersion, patchesDir, criSocket, ignorePreflightErrors, certSANs, wait, vLevel); err != nil {
 		return err
 	}
 
-	if err := joinWorkers(c, usePhases, discoveryMode, wait, kubeadmConfigVersion, patchesDir, ignorePreflightErrors, vLevel); err != nil {
+	if err := joinWorkers(c, usePhases, discoveryMode, wait, kubeadmConfigVersion, patchesDir, criSocket, ignorePreflightErrors, vLevel); err != nil {
 		return err
 	}
 	return nil
 }
 
-func joinControlPlanes(c *status.Cluster, usePhases bool, copyCertsMode CopyCertsMode, discoveryMode DiscoveryMode, kubeadmConfigVersion, patchesDir, ignorePreflightErrors string, wait time.Duration, vLevel int) (err error) {
+func joinControlPlanes(c *status.Cluster, usePhases bool, copyCertsMode CopyCertsMode, discoveryMode DiscoveryMode, kubeadmConfigVersion, patchesDir, criSocket, ignorePreflightErrors string, certSANs []string, wait time.Duration, vLevel int) (err error) {
 	cpX := []*status.Node{c.BootstrapControlPlane()}
 
 	for _, cp2 := range c.SecondaryControlPlanes().EligibleForActions() {
@@ -63,7 +65,7 @@ func joinControlPlanes(c *status.Cluster, usePhases bool, copyCertsMode CopyCert
 		}
 
 		// prepares the kubeadm config on this node
-		if err := KubeadmJoinConfig(c, kubeadmConfigVersion, copyCertsMode, discoveryMode, ignorePreflightErrors, cp2); err != nil {
+		if err := KubeadmJoinConfig(c, kubeadmConfigVersion, copyCertsMode, discoveryMode, criSocket, ignorePreflightErrors, cp2); err != nil {
 			return err
 		}
 
@@ -86,6 +88,10 @@ func joinControlPlanes(c *status.Cluster, usePhases bool, copyCertsMode CopyCert
 		if err := waitNewControlPlaneNodeReady(c, cp2, wait); err != nil {
 			return err
 		}
+
+		if err := verifyAPIServerCertSANs(cp2, certSANs); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -158,7 +164,7 @@ func kubeadmJoinControlPlaneWithPhases(cp *status.Node, vLevel int) (err error)
 	return nil
 }
 
-func joinWorkers(c *status.Cluster, usePhases bool, discoveryMode DiscoveryMode, wait time.Duration, kubeadmConfigVersion, patchesDir, ignorePreflightErrors string, vLevel int) (err error) {
+func joinWorkers(c *status.Cluster, usePhases bool, discoveryMode DiscoveryMode, wait time.Duration, kubeadmConfigVersion, patchesDir, criSocket, ignorePreflightErrors string, vLevel int) (err error) {
 	for _, w := range c.Workers().EligibleForActions() {
 		// checks pre-loaded images available on the node (this will report missing images, if any)
 		kubeVersion, err := w.KubeVersion()
@@ -175,7 +181,7 @@ func joinWorkers(c *status.Cluster, usePhases bool, discoveryMode DiscoveryMode,
 		}
 
 		// prepares the kubeadm config on this node
-		if err := KubeadmJoinConfig(c, kubeadmConfigVersion, CopyCertsModeNone, discoveryMode, ignorePreflightErrors, w); err != nil {
+		if err := KubeadmJoinConfig(c, kubeadmConfigVersion, CopyCertsModeNone, discoveryMode, criSocket, ignorePreflightErrors, w); err != nil {
 			return err
 		}
 
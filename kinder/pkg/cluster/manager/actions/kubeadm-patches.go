@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/util/version"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
+)
+
+// KubeadmPatches action stages the patch files in the given host directory into the patches directory
+// of the given nodes, so they are used by kubeadm init, join and upgrade.
+// Patch files are Go templates, expanded with the same settings used for generating the kubeadm config
+// of each node, e.g. {{ .NodeAddress }}; file names must follow the naming convention of kubeadm --patches,
+// e.g. kube-apiserver+merge.yaml.
+// Please note that patch files are staged with the same name, so kubeadm init, join and upgrade should be
+// executed without the --patches flag, otherwise the raw patch files will override the staged ones.
+func KubeadmPatches(c *status.Cluster, patchesDir string, upgradeVersion *version.Version, nodes ...*status.Node) error {
+	if patchesDir == "" {
+		return errors.New("kubeadm-patches action requires the --patches parameter to be set")
+	}
+
+	files, err := os.ReadDir(patchesDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the patches directory %s", patchesDir)
+	}
+
+	// read and validate all the patch files before changing the nodes
+	patches := map[string]string{}
+	names := []string{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if err := kubeadm.ValidatePatchFileName(file.Name()); err != nil {
+			return err
+		}
+		content, err := os.ReadFile(filepath.Join(patchesDir, file.Name()))
+		if err != nil {
+			return errors.Wrapf(err, "failed to read patch file %s", file.Name())
+		}
+		patches[file.Name()] = string(content)
+		names = append(names, file.Name())
+	}
+
+	data, err := kubeadmConfigData(c, "", "", "", "", "", upgradeVersion)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		n.Infof("Staging patches from %s into %s", patchesDir, constants.PatchesDir)
+
+		nodeData, err := nodeKubeadmConfigData(c, n, data)
+		if err != nil {
+			return err
+		}
+
+		if err := n.Command("mkdir", "-p", constants.PatchesDir).Silent().Run(); err != nil {
+			return errors.Wrapf(err, "failed to create %s folder on node %s", constants.PatchesDir, n.Name())
+		}
+
+		for _, name := range names {
+			patch, err := kubeadm.RenderPatchFile(name, patches[name], nodeData)
+			if err != nil {
+				return errors.Wrapf(err, "failed to render patch file for node %s", n.Name())
+			}
+
+			nodePath := filepath.Join(constants.PatchesDir, name)
+			if err := n.WriteFile(nodePath, []byte(patch)); err != nil {
+				return errors.Wrapf(err, "failed to write patch file %s on node %s", nodePath, n.Name())
+			}
+		}
+	}
+
+	return nil
+}
+
+// nodeHasPatches returns true if there are patch files in the patches directory of the node,
+// e.g. staged by the kubeadm-patches action or copied from the --patches dir
+func nodeHasPatches(n *status.Node) (bool, error) {
+	lines, err := n.Command("ls", "-A", constants.PatchesDir).Silent().RunAndCapture()
+	if err != nil {
+		if n.Command("test", "-d", constants.PatchesDir).Silent().Run() != nil {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to list the patches in %s on node %s", constants.PatchesDir, n.Name())
+	}
+	return len(lines) > 0, nil
+}
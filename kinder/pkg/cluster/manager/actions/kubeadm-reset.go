@@ -26,26 +26,34 @@ import (
 	"k8s.io/kubeadm/kinder/pkg/kubeadm"
 )
 
-// KubeadmReset executes the kubeadm reset workflow
-func KubeadmReset(c *status.Cluster, vLevel int) error {
+// KubeadmReset executes the kubeadm reset workflow; reset allows to customize the ResetConfiguration,
+// and it is supported only when using kubeadm config version v1beta4
+func KubeadmReset(c *status.Cluster, kubeadmConfigVersion string, reset kubeadm.ResetConfigData, vLevel int) error {
 	//TODO: implements kubeadm reset with phases
 	for _, n := range c.K8sNodes().EligibleForActions() {
 		flags := []string{"reset", fmt.Sprintf("--v=%d", vLevel)}
 
 		// After upgrade, the 'kubeadm version' should return the version of the kubeadm used
-		// to perform the upgrade. Use this version to determine if v1beta4 is enabled. If yes,
+		// to perform the upgrade. Use this version to determine if v1beta4 is enabled, unless
+		// a kubeadm config version is explicitly requested. If v1beta4 is enabled,
 		// use ResetConfiguration with a 'force: true', else just use the '--force' flag.
-		v, err := n.KubeadmVersion()
+		nodeConfigVersion, _, err := kubeadm.ResolveConfigVersion(n, kubeadmConfigVersion)
 		if err != nil {
-			return errors.Wrap(err, "could not obtain the kubeadm version before calling 'kubeadm reset'")
+			return errors.Wrap(err, "could not resolve the kubeadm config version before calling 'kubeadm reset'")
 		}
-		if kubeadm.GetKubeadmConfigVersion(v) == "v1beta4" {
-			if err := KubeadmResetConfig(c, "", n); err != nil {
+		if reset.IsSet() && nodeConfigVersion != "v1beta4" {
+			return errors.Errorf("customizing the ResetConfiguration requires kubeadm config version v1beta4, node %s uses %s", n.Name(), nodeConfigVersion)
+		}
+		if nodeConfigVersion == "v1beta4" {
+			if err := KubeadmResetConfig(c, nodeConfigVersion, "", reset, n); err != nil {
 				return errors.Wrap(err, "could not write kubeadm config before calling 'kubeadm reset'")
 			}
 			flags = append(flags, "--config", constants.KubeadmConfigPath)
 		} else {
 			flags = append(flags, "--force")
+			if reset.CRISocket != "" {
+				flags = append(flags, "--cri-socket", reset.CRISocket)
+			}
 		}
 
 		if err := n.Command("kubeadm", flags...).RunWithEcho(); err != nil {
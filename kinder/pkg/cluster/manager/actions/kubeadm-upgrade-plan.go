@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
+)
+
+// minKubeadmVersionForUpgradePlanOutput defines the minimum kubeadm version supporting
+// the output.kubeadm.k8s.io/v1alpha3 UpgradePlan output for kubeadm upgrade plan
+var minKubeadmVersionForUpgradePlanOutput = version.MustParseSemantic("v1.30.0-0")
+
+// UpgradePlan defines the upgrades offered by kubeadm upgrade plan
+type UpgradePlan struct {
+	AvailableUpgrades []AvailableUpgrade `json:"availableUpgrades"`
+}
+
+// AvailableUpgrade defines an upgrade offered by kubeadm upgrade plan
+type AvailableUpgrade struct {
+	Description string                 `json:"description"`
+	Components  []UpgradePlanComponent `json:"components"`
+}
+
+// UpgradePlanComponent defines the current and the target version of a component in an AvailableUpgrade
+type UpgradePlanComponent struct {
+	Name           string `json:"name"`
+	NodeName       string `json:"nodeName,omitempty"`
+	CurrentVersion string `json:"currentVersion"`
+	NewVersion     string `json:"newVersion"`
+}
+
+// HasTarget returns true if at least one of the AvailableUpgrades targets the given version
+func (p *UpgradePlan) HasTarget(target *version.Version) bool {
+	for _, u := range p.AvailableUpgrades {
+		for _, c := range u.Components {
+			v, err := version.ParseSemantic(c.NewVersion)
+			if err != nil {
+				continue
+			}
+			if v.String() == target.String() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// KubeadmUpgradePlan executes kubeadm upgrade plan on the bootstrap control plane and returns
+// the upgrades offered by kubeadm, so it is possible to check the upgrade target before upgrading.
+// When supported by kubeadm, the structured output of kubeadm upgrade plan is used; otherwise
+// the human readable output is parsed.
+func KubeadmUpgradePlan(c *status.Cluster, kubeadmConfigVersion string, upgradeVersion *version.Version, vLevel int) (*UpgradePlan, error) {
+	if upgradeVersion == nil {
+		return nil, errors.New("kubeadm-upgrade-plan actions requires the --upgrade-version parameter to be set")
+	}
+
+	cp1 := c.BootstrapControlPlane()
+
+	kubeadmConfigVersion, kubeadmVersion, err := kubeadm.ResolveConfigVersion(cp1, kubeadmConfigVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not resolve the kubeadm config version before calling kubeadm upgrade plan")
+	}
+
+	// prepares the kubeadm config on this node
+	if err := KubeadmUpgradeConfig(c, kubeadmConfigVersion, "", false, upgradeVersion, cp1); err != nil {
+		return nil, err
+	}
+
+	planArgs := kubeadmUpgradePlanArgs(kubeadmConfigVersion, upgradeVersion, vLevel)
+
+	structuredOutput := kubeadmVersion.AtLeast(minKubeadmVersionForUpgradePlanOutput)
+	if structuredOutput {
+		planArgs = append(planArgs, "--output=yaml")
+	}
+
+	lines, err := cp1.Command(
+		"kubeadm", planArgs...,
+	).RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to execute kubeadm upgrade plan on %s", cp1.Name())
+	}
+
+	if structuredOutput {
+		return parseUpgradePlanOutput(lines)
+	}
+	return parseUpgradePlanTable(lines)
+}
+
+// kubeadmUpgradePlanArgs returns the args for kubeadm upgrade plan
+func kubeadmUpgradePlanArgs(configVersion string, upgradeVersion *version.Version, vLevel int) []string {
+	planArgs := []string{
+		"upgrade", "plan", fmt.Sprintf("--v=%d", vLevel),
+	}
+
+	if configVersion == "v1beta4" {
+		planArgs = append(planArgs, "--config", constants.KubeadmConfigPath)
+	} else {
+		planArgs = append(planArgs, "--allow-experimental-upgrades", "--allow-release-candidate-upgrades",
+			fmt.Sprintf("v%s", upgradeVersion))
+	}
+
+	return planArgs
+}
+
+// parseUpgradePlanOutput parses the output.kubeadm.k8s.io UpgradePlan object returned
+// by kubeadm upgrade plan --output=yaml
+func parseUpgradePlanOutput(lines []string) (*UpgradePlan, error) {
+	// skip everything before the UpgradePlan object, e.g. log lines
+	start := -1
+	for i, l := range lines {
+		if strings.HasPrefix(l, "apiVersion: output.kubeadm.k8s.io/") {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return nil, errors.New("failed to find the UpgradePlan object in the kubeadm upgrade plan output")
+	}
+
+	plan := &UpgradePlan{}
+	if err := yaml.Unmarshal([]byte(strings.Join(lines[start:], "\n")), plan); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the UpgradePlan object in the kubeadm upgrade plan output")
+	}
+	return plan, nil
+}
+
+// parseUpgradePlanTable parses the human readable output of kubeadm upgrade plan, e.g.
+//
+//	Components that must be upgraded manually after you have upgraded the control plane with 'kubeadm upgrade apply':
+//	COMPONENT   NODE                     CURRENT   TARGET
+//	kubelet     kinder-control-plane-1   v1.29.0   v1.29.1
+//
+//	Upgrade to the latest version in the v1.29 series:
+//
+//	COMPONENT                 NODE                     CURRENT    TARGET
+//	kube-apiserver            kinder-control-plane-1   v1.29.0    v1.29.1
+//	...
+//
+//	You can now apply the upgrade by executing the following command:
+//
+//		kubeadm upgrade apply v1.29.1
+//
+// Older kubeadm versions do not have the NODE column.
+func parseUpgradePlanTable(lines []string) (*UpgradePlan, error) {
+	plan := &UpgradePlan{}
+
+	var current *AvailableUpgrade
+	closed := true
+	next := func() *AvailableUpgrade {
+		if closed {
+			plan.AvailableUpgrades = append(plan.AvailableUpgrades, AvailableUpgrade{})
+			closed = false
+		}
+		return &plan.AvailableUpgrades[len(plan.AvailableUpgrades)-1]
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case strings.HasPrefix(line, "Upgrade to"):
+			current = next()
+			if current.Description != "" {
+				closed = true
+				current = next()
+			}
+			current.Description = strings.TrimSuffix(line, ":")
+		case strings.HasPrefix(line, "COMPONENT"):
+			current = next()
+			hasNodeColumn := strings.Contains(line, "NODE")
+			// read table rows until an empty line
+			for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+				i++
+				fields := strings.Fields(lines[i])
+				if len(fields) < 3 {
+					return nil, errors.Errorf("failed to parse row %q in the kubeadm upgrade plan output", lines[i])
+				}
+				component := UpgradePlanComponent{
+					Name:           fields[0],
+					CurrentVersion: fields[len(fields)-2],
+					NewVersion:     fields[len(fields)-1],
+				}
+				if hasNodeColumn && len(fields) == 4 {
+					component.NodeName = fields[1]
+				}
+				current.Components = append(current.Components, component)
+			}
+		case strings.HasPrefix(line, "kubeadm upgrade apply"):
+			closed = true
+		}
+	}
+
+	if len(plan.AvailableUpgrades) == 0 {
+		return nil, errors.New("failed to find available upgrades in the kubeadm upgrade plan output")
+	}
+	return plan, nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestParseUpgradePlan(t *testing.T) {
+	tests := []struct {
+		name             string
+		output           string
+		structuredOutput bool
+		expectedPlan     *UpgradePlan
+		expectedError    bool
+	}{
+		{
+			name: "valid: table with node column",
+			output: `[upgrade/versions] Target version: v1.29.1
+Components that must be upgraded manually after you have upgraded the control plane with 'kubeadm upgrade apply':
+COMPONENT   NODE                     CURRENT   TARGET
+kubelet     kinder-control-plane-1   v1.29.0   v1.29.1
+
+Upgrade to the latest version in the v1.29 series:
+
+COMPONENT                 NODE                     CURRENT    TARGET
+kube-apiserver            kinder-control-plane-1   v1.29.0    v1.29.1
+etcd                      kinder-control-plane-1   3.5.10-0   3.5.10-0
+
+You can now apply the upgrade by executing the following command:
+
+	kubeadm upgrade apply v1.29.1
+`,
+			expectedPlan: &UpgradePlan{
+				AvailableUpgrades: []AvailableUpgrade{
+					{
+						Description: "Upgrade to the latest version in the v1.29 series",
+						Components: []UpgradePlanComponent{
+							{Name: "kubelet", NodeName: "kinder-control-plane-1", CurrentVersion: "v1.29.0", NewVersion: "v1.29.1"},
+							{Name: "kube-apiserver", NodeName: "kinder-control-plane-1", CurrentVersion: "v1.29.0", NewVersion: "v1.29.1"},
+							{Name: "etcd", NodeName: "kinder-control-plane-1", CurrentVersion: "3.5.10-0", NewVersion: "3.5.10-0"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "valid: table without node column and multiple upgrades",
+			output: `Components that must be upgraded manually after you have upgraded the control plane with 'kubeadm upgrade apply':
+COMPONENT   CURRENT       TARGET
+kubelet     2 x v1.27.0   v1.27.3
+
+Upgrade to the latest version in the v1.27 series:
+
+COMPONENT                 CURRENT   TARGET
+kube-apiserver            v1.27.0   v1.27.3
+
+You can now apply the upgrade by executing the following command:
+
+	kubeadm upgrade apply v1.27.3
+
+Upgrade to the latest stable version:
+
+COMPONENT                 CURRENT   TARGET
+kube-apiserver            v1.27.0   v1.28.0
+
+You can now apply the upgrade by executing the following command:
+
+	kubeadm upgrade apply v1.28.0
+`,
+			expectedPlan: &UpgradePlan{
+				AvailableUpgrades: []AvailableUpgrade{
+					{
+						Description: "Upgrade to the latest version in the v1.27 series",
+						Components: []UpgradePlanComponent{
+							{Name: "kubelet", CurrentVersion: "v1.27.0", NewVersion: "v1.27.3"},
+							{Name: "kube-apiserver", CurrentVersion: "v1.27.0", NewVersion: "v1.27.3"},
+						},
+					},
+					{
+						Description: "Upgrade to the latest stable version",
+						Components: []UpgradePlanComponent{
+							{Name: "kube-apiserver", CurrentVersion: "v1.27.0", NewVersion: "v1.28.0"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:          "invalid: table without upgrades",
+			output:        "Awesome, you're up-to-date! Enjoy!",
+			expectedError: true,
+		},
+		{
+			name: "valid: structured output",
+			output: `[upgrade/config] Reading configuration from the cluster...
+apiVersion: output.kubeadm.k8s.io/v1alpha3
+kind: UpgradePlan
+availableUpgrades:
+- description: remote version
+  components:
+  - name: kube-apiserver
+    currentVersion: v1.30.0
+    newVersion: v1.31.0
+    nodeName: kinder-control-plane-1
+configVersions:
+- componentName: kubeproxy.config.k8s.io
+  currentVersion: v1alpha1
+  preferredVersion: v1alpha1
+  manualUpgradeRequired: false
+`,
+			structuredOutput: true,
+			expectedPlan: &UpgradePlan{
+				AvailableUpgrades: []AvailableUpgrade{
+					{
+						Description: "remote version",
+						Components: []UpgradePlanComponent{
+							{Name: "kube-apiserver", NodeName: "kinder-control-plane-1", CurrentVersion: "v1.30.0", NewVersion: "v1.31.0"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:             "invalid: structured output without UpgradePlan",
+			output:           "error execution phase",
+			structuredOutput: true,
+			expectedError:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := strings.Split(test.output, "\n")
+
+			var plan *UpgradePlan
+			var err error
+			if test.structuredOutput {
+				plan, err = parseUpgradePlanOutput(lines)
+			} else {
+				plan, err = parseUpgradePlanTable(lines)
+			}
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if !reflect.DeepEqual(plan, test.expectedPlan) {
+				t.Fatalf("expected plan:\n%+v\ngot:\n%+v", test.expectedPlan, plan)
+			}
+		})
+	}
+}
+
+func TestUpgradePlanHasTarget(t *testing.T) {
+	plan := &UpgradePlan{
+		AvailableUpgrades: []AvailableUpgrade{
+			{
+				Components: []UpgradePlanComponent{
+					{Name: "etcd", CurrentVersion: "3.5.10-0", NewVersion: "3.5.12-0"},
+					{Name: "kube-apiserver", CurrentVersion: "v1.30.0", NewVersion: "v1.31.0-rc.1"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		expected bool
+	}{
+		{
+			name:     "target is offered",
+			target:   "v1.31.0-rc.1",
+			expected: true,
+		},
+		{
+			name:     "target is not offered",
+			target:   "v1.31.0",
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := plan.HasTarget(version.MustParseSemantic(test.target)); got != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
@@ -37,7 +37,7 @@ import (
 //
 // The implementation assumes that the kubeadm/kubelet/kubectl binaries and all the necessary images
 // for the new kubernetes version are available in the /kinder/upgrade/{version} folder.
-func KubeadmUpgrade(c *status.Cluster, upgradeVersion *version.Version, patchesDir, ignorePreflightErrors string, wait time.Duration, vLevel int) (err error) {
+func KubeadmUpgrade(c *status.Cluster, kubeadmConfigVersion string, upgradeVersion *version.Version, patchesDir, ignorePreflightErrors string, skipKubeProxy bool, wait time.Duration, vLevel int) (err error) {
 	if upgradeVersion == nil {
 		return errors.New("kubeadm-upgrade actions requires the --upgrade-version parameter to be set")
 	}
@@ -78,27 +78,40 @@ func KubeadmUpgrade(c *status.Cluster, upgradeVersion *version.Version, patchesD
 			return err
 		}
 
+		// use the kubeadm config version explicitly requested, if any, otherwise
+		// the kubeadm config version corresponding to the new kubeadm binary
+		nodeConfigVersion, _, err := kubeadm.ResolveConfigVersion(n, kubeadmConfigVersion)
+		if err != nil {
+			return errors.Wrap(err, "could not resolve the kubeadm config version before calling kubeadm upgrade")
+		}
+
+		// the kube-proxy addon can be skipped only via the UpgradeConfiguration, otherwise kubeadm upgrade installs it
+		if skipKubeProxy && nodeConfigVersion != "v1beta4" {
+			return errors.Errorf("skipping the kube-proxy addon during upgrades requires kubeadm config version v1beta4, node %s uses %s", n.Name(), nodeConfigVersion)
+		}
+
 		// prepares the kubeadm config on this node
-		if err := KubeadmUpgradeConfig(c, ignorePreflightErrors, upgradeVersion, n); err != nil {
+		if err := KubeadmUpgradeConfig(c, kubeadmConfigVersion, ignorePreflightErrors, skipKubeProxy, upgradeVersion, n); err != nil {
 			return err
 		}
 
-		v, err := n.KubeadmVersion()
+		// patches staged on the node, e.g. by the kubeadm-patches action, are used
+		// even if the patches dir is not passed to the kubeadm-upgrade action
+		patches, err := nodeHasPatches(n)
 		if err != nil {
-			errors.Wrap(err, "could not obtain the kubeadm version before calling kubeadm reset")
+			return err
 		}
-		kubeadmConfigVersion := kubeadm.GetKubeadmConfigVersion(v)
 
 		if n.Name() == c.BootstrapControlPlane().Name() {
-			if err := kubeadmUpgradePlan(c, n, kubeadmConfigVersion, upgradeVersion, vLevel); err != nil {
+			if err := kubeadmUpgradePlan(c, n, nodeConfigVersion, upgradeVersion, vLevel); err != nil {
 				return err
 			}
-			if err := kubeadmUpgradeDiff(c, n, kubeadmConfigVersion, upgradeVersion, vLevel); err != nil {
+			if err := kubeadmUpgradeDiff(c, n, nodeConfigVersion, upgradeVersion, vLevel); err != nil {
 				return err
 			}
-			err = kubeadmUpgradeApply(c, n, kubeadmConfigVersion, upgradeVersion, patchesDir, wait, vLevel)
+			err = kubeadmUpgradeApply(c, n, nodeConfigVersion, upgradeVersion, patches, wait, vLevel)
 		} else {
-			err = kubeadmUpgradeNode(c, n, kubeadmConfigVersion, upgradeVersion, patchesDir, wait, vLevel)
+			err = kubeadmUpgradeNode(c, n, nodeConfigVersion, upgradeVersion, patches, wait, vLevel)
 		}
 		if err != nil {
 			return err
@@ -181,26 +194,35 @@ func kubeadmUpgradeDiff(c *status.Cluster, cp1 *status.Node, configVersion strin
 }
 
 func kubeadmUpgradePlan(c *status.Cluster, cp1 *status.Node, configVersion string, upgradeVersion *version.Version, vLevel int) error {
-	planArgs := []string{
-		"upgrade", "plan", fmt.Sprintf("--v=%d", vLevel),
-	}
+	planArgs := kubeadmUpgradePlanArgs(configVersion, upgradeVersion, vLevel)
 
-	if configVersion == "v1beta4" {
-		planArgs = append(planArgs, "--config", constants.KubeadmConfigPath)
-	} else {
-		planArgs = append(planArgs, "--allow-experimental-upgrades", "--allow-release-candidate-upgrades",
-			fmt.Sprintf("v%s", upgradeVersion))
+	if err := cp1.Command(
+		"kubeadm", planArgs...,
+	).RunWithEcho(); err != nil {
+		return err
 	}
+	return nil
+}
+
+func kubeadmUpgradeApply(c *status.Cluster, cp1 *status.Node, configVersion string, upgradeVersion *version.Version, patches bool, wait time.Duration, vLevel int) error {
+	applyArgs := kubeadmUpgradeApplyArgs(configVersion, upgradeVersion, patches, vLevel)
 
 	if err := cp1.Command(
-		"kubeadm", planArgs...,
+		"kubeadm", applyArgs...,
 	).RunWithEcho(); err != nil {
 		return err
 	}
+
+	if err := waitControlPlaneUpgraded(c, cp1, upgradeVersion, wait); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func kubeadmUpgradeApply(c *status.Cluster, cp1 *status.Node, configVersion string, upgradeVersion *version.Version, patchesDir string, wait time.Duration, vLevel int) error {
+// kubeadmUpgradeApplyArgs returns the args for kubeadm upgrade apply; with v1beta4 the patches dir is
+// defined in the UpgradeConfiguration, otherwise the --patches flag is added if there are patches on the node
+func kubeadmUpgradeApplyArgs(configVersion string, upgradeVersion *version.Version, patches bool, vLevel int) []string {
 	applyArgs := []string{
 		"upgrade", "apply", fmt.Sprintf("--v=%d", vLevel),
 	}
@@ -208,26 +230,16 @@ func kubeadmUpgradeApply(c *status.Cluster, cp1 *status.Node, configVersion stri
 	if configVersion == "v1beta4" {
 		applyArgs = append(applyArgs, "--config", constants.KubeadmConfigPath)
 	} else {
-		if patchesDir != "" {
+		if patches {
 			applyArgs = append(applyArgs, fmt.Sprintf("--patches=%s", constants.PatchesDir))
 		}
 		applyArgs = append(applyArgs, "-f", fmt.Sprintf("v%s", upgradeVersion.String()))
 	}
 
-	if err := cp1.Command(
-		"kubeadm", applyArgs...,
-	).RunWithEcho(); err != nil {
-		return err
-	}
-
-	if err := waitControlPlaneUpgraded(c, cp1, upgradeVersion, wait); err != nil {
-		return err
-	}
-
-	return nil
+	return applyArgs
 }
 
-func kubeadmUpgradeNode(c *status.Cluster, n *status.Node, configVersion string, upgradeVersion *version.Version, patchesDir string, wait time.Duration, vLevel int) error {
+func kubeadmUpgradeNode(c *status.Cluster, n *status.Node, configVersion string, upgradeVersion *version.Version, patches bool, wait time.Duration, vLevel int) error {
 	// waitKubeletHasRBAC waits for the kubelet to have access to the expected config map
 	// please note that this is a temporary workaround for a problem we are observing on upgrades while
 	// executing node upgrades immediately after control-plane upgrade.
@@ -236,17 +248,7 @@ func kubeadmUpgradeNode(c *status.Cluster, n *status.Node, configVersion string,
 	}
 
 	// kubeadm upgrade node
-	nodeArgs := []string{
-		"upgrade", "node", fmt.Sprintf("--v=%d", vLevel),
-	}
-
-	if configVersion == "v1beta4" {
-		nodeArgs = append(nodeArgs, "--config", constants.KubeadmConfigPath)
-	} else {
-		if patchesDir != "" {
-			nodeArgs = append(nodeArgs, fmt.Sprintf("--patches=%s", constants.PatchesDir))
-		}
-	}
+	nodeArgs := kubeadmUpgradeNodeArgs(configVersion, patches, vLevel)
 
 	if err := n.Command(
 		"kubeadm", nodeArgs...,
@@ -263,6 +265,22 @@ func kubeadmUpgradeNode(c *status.Cluster, n *status.Node, configVersion string,
 	return nil
 }
 
+// kubeadmUpgradeNodeArgs returns the args for kubeadm upgrade node; with v1beta4 the patches dir is
+// defined in the UpgradeConfiguration, otherwise the --patches flag is added if there are patches on the node
+func kubeadmUpgradeNodeArgs(configVersion string, patches bool, vLevel int) []string {
+	nodeArgs := []string{
+		"upgrade", "node", fmt.Sprintf("--v=%d", vLevel),
+	}
+
+	if configVersion == "v1beta4" {
+		nodeArgs = append(nodeArgs, "--config", constants.KubeadmConfigPath)
+	} else if patches {
+		nodeArgs = append(nodeArgs, fmt.Sprintf("--patches=%s", constants.PatchesDir))
+	}
+
+	return nodeArgs
+}
+
 func upgradeKubeletKubectl(c *status.Cluster, n *status.Node, upgradeVersion *version.Version, wait time.Duration) error {
 	n.Infof("upgrade kubelet and kubectl binaries")
 
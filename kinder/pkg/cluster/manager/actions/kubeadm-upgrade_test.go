@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestKubeadmUpgradeArgs(t *testing.T) {
+	upgradeVersion := version.MustParseSemantic("v1.31.0")
+
+	tests := []struct {
+		name          string
+		configVersion string
+		patches       bool
+		expectedApply []string
+		expectedNode  []string
+	}{
+		{
+			name:          "v1beta3 without patches",
+			configVersion: "v1beta3",
+			expectedApply: []string{"upgrade", "apply", "--v=2", "-f", "v1.31.0"},
+			expectedNode:  []string{"upgrade", "node", "--v=2"},
+		},
+		{
+			name:          "v1beta3 with patches",
+			configVersion: "v1beta3",
+			patches:       true,
+			expectedApply: []string{"upgrade", "apply", "--v=2", "--patches=/kinder/patches", "-f", "v1.31.0"},
+			expectedNode:  []string{"upgrade", "node", "--v=2", "--patches=/kinder/patches"},
+		},
+		{
+			name:          "v1beta4 with patches uses the patches dir in the config",
+			configVersion: "v1beta4",
+			patches:       true,
+			expectedApply: []string{"upgrade", "apply", "--v=2", "--config", "/kind/kubeadm.conf"},
+			expectedNode:  []string{"upgrade", "node", "--v=2", "--config", "/kind/kubeadm.conf"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := kubeadmUpgradeApplyArgs(test.configVersion, upgradeVersion, test.patches, 2); !reflect.DeepEqual(got, test.expectedApply) {
+				t.Errorf("expected apply args %v, got %v", test.expectedApply, got)
+			}
+			if got := kubeadmUpgradeNodeArgs(test.configVersion, test.patches, 2); !reflect.DeepEqual(got, test.expectedNode) {
+				t.Errorf("expected node args %v, got %v", test.expectedNode, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+)
+
+const (
+	// networkChaosInterface defines the network interface of the node containers used for injecting network chaos
+	networkChaosInterface = "eth0"
+
+	// networkChaosChain defines the iptables chain used for dropping the traffic to and from the peers
+	networkChaosChain = "KINDER-CHAOS"
+
+	// defaultNetworkLatency defines the latency added to the traffic to the peers when no latency is provided
+	defaultNetworkLatency = 200 * time.Millisecond
+)
+
+// NetworkChaosMode defines the type of network chaos injected by the network-chaos action
+type NetworkChaosMode string
+
+const (
+	// NetworkLatency adds latency to the traffic from the nodes to the peers
+	NetworkLatency = NetworkChaosMode("latency")
+
+	// NetworkPartition drops the traffic between the nodes and the peers
+	NetworkPartition = NetworkChaosMode("partition")
+)
+
+// KnownNetworkChaosMode returns the list of known NetworkChaosMode
+func KnownNetworkChaosMode() []string {
+	return []string{
+		string(NetworkLatency),
+		string(NetworkPartition),
+	}
+}
+
+// ValidateNetworkChaosMode validates a NetworkChaosMode
+func ValidateNetworkChaosMode(t NetworkChaosMode) error {
+	switch t {
+	case NetworkLatency:
+	case NetworkPartition:
+	default:
+		return errors.Errorf("invalid network chaos mode. Use one of %s", KnownNetworkChaosMode())
+	}
+	return nil
+}
+
+// NetworkChaos action injects network chaos between the nodes and the peers, e.g. for simulating a control-plane
+// network partition during an upgrade. In latency mode, tc netem delays the traffic from the nodes to the peers;
+// in partition mode, iptables drops the traffic to and from the peers, so the partition is symmetric even if
+// the peers are not changed. If no peers are provided, all the other K8s nodes in the cluster are used as peers.
+// The returned closer removes the network chaos from the nodes, and it should be deferred in order to ensure
+// teardown always runs; in case of errors, the network chaos already injected is removed before returning.
+func NetworkChaos(c *status.Cluster, mode NetworkChaosMode, latency time.Duration, peers status.NodeList, nodes ...*status.Node) (func() error, error) {
+	if err := ValidateNetworkChaosMode(mode); err != nil {
+		return nil, err
+	}
+	if latency == 0 {
+		latency = defaultNetworkLatency
+	}
+
+	closer := func() error {
+		return ClearNetworkChaos(c, nodes...)
+	}
+
+	for _, n := range nodes {
+		n.Infof("Injecting network %s", mode)
+
+		peerIPs, err := networkChaosPeerIPs(c, n, peers)
+		if err != nil {
+			_ = closer()
+			return nil, err
+		}
+
+		switch mode {
+		case NetworkLatency:
+			err = addNetworkLatency(n, latency, peerIPs)
+		case NetworkPartition:
+			err = addNetworkPartition(n, peerIPs)
+		}
+		if err != nil {
+			_ = closer()
+			return nil, errors.Wrapf(err, "failed to inject network %s on node %s", mode, n.Name())
+		}
+	}
+
+	return closer, nil
+}
+
+// ClearNetworkChaos action removes the network chaos injected by NetworkChaos from the nodes;
+// it is safe to call ClearNetworkChaos on nodes without network chaos.
+func ClearNetworkChaos(c *status.Cluster, nodes ...*status.Node) error {
+	var lastErr error
+	for _, n := range nodes {
+		n.Infof("Removing network chaos")
+
+		// removes the tc qdisc used for adding latency, if any
+		// NB. the command fails if the default qdisc is in place, so errors are ignored
+		_ = n.Command("tc", "qdisc", "del", "dev", networkChaosInterface, "root").Silent().Run()
+
+		// removes the iptables chain used for dropping traffic, if any
+		for _, iptables := range []string{"iptables", "ip6tables"} {
+			if err := n.Command(iptables, "-n", "-L", networkChaosChain).Silent().Run(); err != nil {
+				continue
+			}
+			for _, args := range [][]string{
+				{"-D", "INPUT", "-j", networkChaosChain},
+				{"-D", "OUTPUT", "-j", networkChaosChain},
+				{"-F", networkChaosChain},
+				{"-X", networkChaosChain},
+			} {
+				if err := n.Command(iptables, args...).Silent().Run(); err != nil {
+					lastErr = errors.Wrapf(err, "failed to remove the %s chain on node %s", networkChaosChain, n.Name())
+				}
+			}
+		}
+	}
+	return lastErr
+}
+
+// networkChaosPeerIPs returns the IPs of the peers of a node, using all the other K8s nodes if no peers are provided
+func networkChaosPeerIPs(c *status.Cluster, n *status.Node, peers status.NodeList) ([]string, error) {
+	if len(peers) == 0 {
+		peers = c.K8sNodes()
+	}
+
+	ips := []string{}
+	for _, p := range peers {
+		if p.Name() == n.Name() {
+			continue
+		}
+		ipv4, ipv6, err := p.IP()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the IP of node %s", p.Name())
+		}
+		if c.Settings.IPFamily == status.IPv6Family {
+			ips = append(ips, ipv6)
+			continue
+		}
+		ips = append(ips, ipv4)
+	}
+	if len(ips) == 0 {
+		return nil, errors.Errorf("there are no peers for node %s", n.Name())
+	}
+	return ips, nil
+}
+
+// addNetworkLatency delays the traffic from a node to the peers using a prio qdisc, where
+// the traffic to the peers is routed to a band with a netem qdisc, while other traffic is not affected
+func addNetworkLatency(n *status.Node, latency time.Duration, peerIPs []string) error {
+	commands := [][]string{
+		{"qdisc", "replace", "dev", networkChaosInterface, "root", "handle", "1:", "prio"},
+		{"qdisc", "replace", "dev", networkChaosInterface, "parent", "1:3", "handle", "30:", "netem", "delay", fmt.Sprintf("%dms", latency.Milliseconds())},
+	}
+	for _, ip := range peerIPs {
+		protocol, match, prefix := "ip", "ip", 32
+		if isIPv6(ip) {
+			protocol, match, prefix = "ipv6", "ip6", 128
+		}
+		commands = append(commands, []string{
+			"filter", "add", "dev", networkChaosInterface, "protocol", protocol, "parent", "1:0", "prio", "3",
+			"u32", "match", match, "dst", fmt.Sprintf("%s/%d", ip, prefix), "flowid", "1:3",
+		})
+	}
+
+	for _, args := range commands {
+		if lines, err := n.Command("tc", args...).Silent().RunAndCapture(); err != nil {
+			return errors.Wrapf(err, "tc %v failed: %v", args, lines)
+		}
+	}
+	return nil
+}
+
+// addNetworkPartition drops the traffic to and from the peers using a dedicated iptables chain
+func addNetworkPartition(n *status.Node, peerIPs []string) error {
+	for _, iptables := range []string{"iptables", "ip6tables"} {
+		ips := []string{}
+		for _, ip := range peerIPs {
+			if isIPv6(ip) == (iptables == "ip6tables") {
+				ips = append(ips, ip)
+			}
+		}
+		if len(ips) == 0 {
+			continue
+		}
+
+		commands := [][]string{
+			{"-N", networkChaosChain},
+			{"-I", "INPUT", "-j", networkChaosChain},
+			{"-I", "OUTPUT", "-j", networkChaosChain},
+		}
+		for _, ip := range ips {
+			commands = append(commands,
+				[]string{"-A", networkChaosChain, "-s", ip, "-j", "DROP"},
+				[]string{"-A", networkChaosChain, "-d", ip, "-j", "DROP"},
+			)
+		}
+
+		for _, args := range commands {
+			if lines, err := n.Command(iptables, args...).Silent().RunAndCapture(); err != nil {
+				return errors.Wrapf(err, "%s %v failed: %v", iptables, args, lines)
+			}
+		}
+	}
+	return nil
+}
+
+// isIPv6 returns true if the IP is an IPv6 address
+func isIPv6(ip string) bool {
+	return strings.Contains(ip, ":")
+}
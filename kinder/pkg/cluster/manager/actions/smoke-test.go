@@ -24,6 +24,7 @@ import (
 	"github.com/pkg/errors"
 
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
 )
 
 // SmokeTest actions execute a set of simple test checking proper functioning of
@@ -103,7 +104,8 @@ func SmokeTest(c *status.Cluster, wait time.Duration) error {
 	// Test DNS resolution
 	cp1.Infof("test DNS resolution")
 
-	if len(lines) < 3 || !strings.Contains(lines[3], "kubernetes.default.svc.cluster.local") {
+	dnsDomain := clusterDNSDomain(cp1)
+	if len(lines) < 3 || !strings.Contains(lines[3], fmt.Sprintf("kubernetes.default.svc.%s", dnsDomain)) {
 		return errors.Wrapf(err, "dns resolution error")
 	}
 	fmt.Printf("kubernetes service answers to %s\n", lines[3])
@@ -115,6 +117,16 @@ func SmokeTest(c *status.Cluster, wait time.Duration) error {
 	return nil
 }
 
+// clusterDNSDomain returns the DNS domain used by services in the cluster, as configured by kubeadm
+// in the kubelet config of the node; if the kubelet config can't be read, the default DNS domain is returned
+func clusterDNSDomain(n *status.Node) string {
+	config, err := n.EffectiveKubeletConfig()
+	if err != nil || config.ClusterDomain == "" {
+		return kubeadm.DefaultDNSDomain
+	}
+	return config.ClusterDomain
+}
+
 func cleanupSmokeTest(cp1 *status.Node) {
 	cp1.Command(
 		"kubectl",
@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+)
+
+const (
+	// uploadCertsConfigPath defines the path of the kubeadm config used for uploading certs;
+	// a separated file is used in order to preserve the kubeadm config used by kubeadm init
+	uploadCertsConfigPath = "/kind/kubeadm-upload-certs.conf"
+
+	// kubeadmCertsSecret defines the name of the Secret where kubeadm uploads the encrypted certs
+	kubeadmCertsSecret = "kubeadm-certs"
+
+	// kubeletKubeconfigPath defines the path of the kubeconfig file created by kubeadm init/join for the kubelet
+	kubeletKubeconfigPath = "/etc/kubernetes/kubelet.conf"
+)
+
+// certificateKeyRegexp defines the format of the certificate keys generated by kubeadm
+var certificateKeyRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// UploadCerts action generates a new certificate key and re-uploads the control-plane certificates
+// into the kubeadm-certs Secret using the new key; the new certificate key is returned.
+// Please note that the kubeadm-certs Secret expires together with the bootstrap token owning it,
+// by default after two hours; the action can be executed again to refresh the Secret.
+// If updateJoinConfigs is set, the kubeadm config of the secondary control-plane nodes
+// not joined yet is regenerated with the new certificate key.
+func UploadCerts(c *status.Cluster, kubeadmConfigVersion, criSocket, ignorePreflightErrors string, updateJoinConfigs bool, vLevel int) (string, error) {
+	cp1 := c.BootstrapControlPlane()
+
+	cp1.Infof("Generating a new certificate key")
+	lines, err := cp1.Command("kubeadm", "certs", "certificate-key").Silent().RunAndCapture()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to generate a new certificate key on %s", cp1.Name())
+	}
+	if len(lines) != 1 || !certificateKeyRegexp.MatchString(strings.TrimSpace(lines[0])) {
+		return "", errors.Errorf("failed to parse the certificate key generated on %s: %q", cp1.Name(), lines)
+	}
+	certificateKey := strings.TrimSpace(lines[0])
+
+	data, err := kubeadmConfigData(c, "", "", criSocket, "", ignorePreflightErrors, nil)
+	if err != nil {
+		return "", err
+	}
+	options := kubeadmConfigOptions{
+		configVersion:  kubeadmConfigVersion,
+		copyCertsMode:  CopyCertsModeAuto,
+		discoveryMode:  TokenDiscovery,
+		certificateKey: certificateKey,
+	}
+
+	// NB. kubeadm does not allow to mix --config and --certificate-key, so the certificate key
+	// is passed to kubeadm using a kubeadm config
+	cp1Data, err := nodeKubeadmConfigData(c, cp1, data)
+	if err != nil {
+		return "", err
+	}
+	kubeadmConfig, err := getKubeadmConfig(c, cp1, cp1Data, options)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate kubeadm config content")
+	}
+	if err := cp1.WriteFile(uploadCertsConfigPath, []byte(kubeadmConfig)); err != nil {
+		return "", errors.Wrapf(err, "failed to write the kubeadm config to node %s", cp1.Name())
+	}
+
+	cp1.Infof("Uploading certs into the %s Secret", kubeadmCertsSecret)
+	if err := cp1.Command(
+		"kubeadm", "init", "phase", "upload-certs", "--upload-certs",
+		fmt.Sprintf("--config=%s", uploadCertsConfigPath),
+		fmt.Sprintf("--v=%d", vLevel),
+	).RunWithEcho(); err != nil {
+		return "", errors.Wrapf(err, "failed to upload certs on %s", cp1.Name())
+	}
+
+	expiration, err := kubeadmCertsExpiration(cp1)
+	if err != nil {
+		return "", err
+	}
+	if time.Until(expiration) <= 0 {
+		return "", errors.Errorf("the %s Secret expired at %s", kubeadmCertsSecret, expiration.Format(time.RFC3339))
+	}
+	log.Infof("The %s Secret expires at %s; execute the upload-certs action again for refreshing it", kubeadmCertsSecret, expiration.Format(time.RFC3339))
+
+	if updateJoinConfigs {
+		for _, n := range c.SecondaryControlPlanes().EligibleForActions() {
+			// skip nodes already joined, that do not use the certificate key anymore
+			if err := n.Command("test", "-f", kubeletKubeconfigPath).Silent().Run(); err == nil {
+				log.Debugf("node %s already joined the cluster, skipping update of the kubeadm config", n.Name())
+				continue
+			}
+			if err := writeKubeadmConfig(c, n, data, options); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	fmt.Printf("certificate key: %s\n", certificateKey)
+	return certificateKey, nil
+}
+
+// kubeadmCertsExpiration returns the expiration of the kubeadm-certs Secret, that is the
+// expiration of the bootstrap token owning it
+func kubeadmCertsExpiration(n *status.Node) (time.Time, error) {
+	tokenSecret := kubectlOutput(n,
+		"--kubeconfig=/etc/kubernetes/admin.conf", "-n", "kube-system",
+		"get", "secret", kubeadmCertsSecret,
+		"-o", "jsonpath={.metadata.ownerReferences[0].name}",
+	)
+	if tokenSecret == "" {
+		return time.Time{}, errors.Errorf("failed to get the owner of the %s Secret", kubeadmCertsSecret)
+	}
+
+	encoded := kubectlOutput(n,
+		"--kubeconfig=/etc/kubernetes/admin.conf", "-n", "kube-system",
+		"get", "secret", tokenSecret,
+		"-o", "jsonpath={.data.expiration}",
+	)
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) == 0 {
+		return time.Time{}, errors.Errorf("failed to get the expiration of the %s Secret", tokenSecret)
+	}
+
+	expiration, err := time.Parse(time.RFC3339, string(raw))
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to parse the expiration of the %s Secret", tokenSecret)
+	}
+	return expiration, nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+)
+
+// ControlPlaneComponentHealth defines the health of a control-plane component running on a node
+type ControlPlaneComponentHealth struct {
+	// Node is the name of the control-plane node
+	Node string
+	// Component is the name of the control-plane component, e.g. kube-apiserver
+	Component string
+	// Healthy is true if the component container is running and the static pod is ready
+	Healthy bool
+	// Reason explains why the component is not healthy
+	Reason string
+}
+
+// ControlPlaneHealth defines the health of all the control-plane components in a cluster
+type ControlPlaneHealth struct {
+	Components []ControlPlaneComponentHealth
+}
+
+// Healthy returns true if all the control-plane components are healthy
+func (h *ControlPlaneHealth) Healthy() bool {
+	for _, c := range h.Components {
+		if !c.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Print prints the health of the control-plane components as a table
+func (h *ControlPlaneHealth) Print() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NODE\tCOMPONENT\tHEALTHY\tREASON")
+	for _, c := range h.Components {
+		fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", c.Node, c.Component, c.Healthy, c.Reason)
+	}
+	w.Flush()
+}
+
+// VerifyControlPlane checks that each control-plane node has healthy control-plane static pods,
+// that are kube-apiserver, kube-controller-manager, kube-scheduler and, in case of stacked etcd, etcd.
+// A component is healthy if its container is running, according to crictl on the node,
+// and the corresponding static pod is reported as ready by the API server.
+func VerifyControlPlane(c *status.Cluster) (*ControlPlaneHealth, error) {
+	components := []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler"}
+	if c.ExternalEtcd() == nil {
+		components = append(components, "etcd")
+	}
+
+	health := &ControlPlaneHealth{}
+	for _, n := range c.ControlPlanes() {
+		for _, component := range components {
+			h := ControlPlaneComponentHealth{
+				Node:      n.Name(),
+				Component: component,
+			}
+
+			running, err := staticPodContainerIsRunning(n, component)
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case !running:
+				h.Reason = "container is not running"
+			case !staticPodIsReady(component)(c, n):
+				h.Reason = "static pod is not ready"
+			default:
+				h.Healthy = true
+			}
+			health.Components = append(health.Components, h)
+		}
+	}
+
+	return health, nil
+}
+
+// verifyControlPlane checks the health of the control-plane components until all of them
+// are healthy or the timeout is reached, and then prints the result
+func verifyControlPlane(c *status.Cluster, wait time.Duration) error {
+	c.BootstrapControlPlane().Infof("verifying control-plane health (timeout %s)", wait)
+
+	deadline := time.Now().Add(wait)
+	for {
+		health, err := VerifyControlPlane(c)
+		if err != nil {
+			return err
+		}
+		if health.Healthy() || time.Now().After(deadline) {
+			fmt.Println()
+			health.Print()
+			if !health.Healthy() {
+				return errors.New("control-plane is not healthy")
+			}
+			return nil
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// staticPodContainerIsRunning returns true if the container of a static pod is running on the node
+func staticPodContainerIsRunning(n *status.Node, name string) (bool, error) {
+	lines, err := n.Command(
+		"crictl", "ps", "--quiet", "--state=running", fmt.Sprintf("--name=^%s$", name),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list the running containers on %s: %s", n.Name(), strings.Join(lines, "\n"))
+	}
+	return len(lines) > 0, nil
+}
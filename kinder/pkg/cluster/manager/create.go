@@ -18,6 +18,7 @@ package manager
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -42,6 +43,7 @@ type CreateOptions struct {
 	externalEtcd         bool
 	retain               bool
 	volumes              []string
+	nodeCRIs             map[string]string
 }
 
 // CreateOption is a configuration option supplied to Create
@@ -98,6 +100,16 @@ func Volumes(volumes []string) CreateOption {
 	}
 }
 
+// NodeCRI option instructs create cluster to use the given container runtime on the given K8s nodes, e.g. for
+// creating clusters with nodes using different container runtimes; nodes can be specified with or without the
+// cluster name prefix, and the container runtime must be installed in the node image. On other nodes, the container
+// runtime detected in the node image is used.
+func NodeCRI(nodeCRIs map[string]string) CreateOption {
+	return func(c *CreateOptions) {
+		c.nodeCRIs = nodeCRIs
+	}
+}
+
 // CreateCluster creates a new kinder cluster
 func CreateCluster(clusterName string, options ...CreateOption) error {
 	flags := &CreateOptions{}
@@ -167,6 +179,12 @@ func createNodes(clusterName string, flags *CreateOptions) error {
 	}
 	fmt.Printf("Preparing nodes %s\n", strings.Repeat("📦", numberOfNodes))
 
+	// validate the container runtimes selected for the nodes, if any
+	nodeCRIs, err := resolveNodeCRIs(clusterName, desiredNodes, flags.nodeCRIs)
+	if err != nil {
+		return err
+	}
+
 	// detect CRI runtime installed into images before actually creating nodes
 	runtime, err := status.InspectCRIinImage(flags.image)
 	if err != nil {
@@ -175,11 +193,30 @@ func createNodes(clusterName string, flags *CreateOptions) error {
 	}
 	log.Infof("Detected %s container runtime for image %s", runtime, flags.image)
 
-	createHelper, err := nodes.NewCreateHelper(runtime)
-	if err != nil {
-		log.Errorf("Error creating NewCreateHelper for CRI %s! %v", flags.image, err)
-		return err
+	// check the container runtimes selected for the nodes are installed in the image before creating nodes,
+	// and get a create helper for each container runtime, so each node is created for its own container runtime
+	createHelpers := map[status.ContainerRuntime]*nodes.CreateHelper{}
+	for _, cri := range append([]status.ContainerRuntime{runtime}, criValues(nodeCRIs)...) {
+		if _, ok := createHelpers[cri]; ok {
+			continue
+		}
+		if cri != runtime {
+			installed, err := status.IsCRIInstalledInImage(flags.image, cri)
+			if err != nil {
+				return err
+			}
+			if !installed {
+				return errors.Errorf("container runtime %s selected for nodes %s is not installed in the node image %s",
+					cri, strings.Join(nodesWithCRI(nodeCRIs, cri), ", "), flags.image)
+			}
+		}
+		createHelpers[cri], err = nodes.NewCreateHelper(cri)
+		if err != nil {
+			log.Errorf("Error creating NewCreateHelper for CRI %s! %v", cri, err)
+			return err
+		}
 	}
+	createHelper := createHelpers[runtime]
 
 	// create all of the node containers
 	log.Info("Creating nodes...")
@@ -189,7 +226,11 @@ func createNodes(clusterName string, flags *CreateOptions) error {
 		case constants.ExternalLoadBalancerNodeRoleValue:
 			err = createHelper.CreateExternalLoadBalancer(clusterName, desiredNode.Name)
 		case constants.ControlPlaneNodeRoleValue, constants.WorkerNodeRoleValue:
-			err = createHelper.CreateNode(clusterName, desiredNode.Name, flags.image, desiredNode.Role, flags.volumes)
+			nodeCreateHelper := createHelper
+			if cri, ok := nodeCRIs[desiredNode.Name]; ok {
+				nodeCreateHelper = createHelpers[cri]
+			}
+			err = nodeCreateHelper.CreateNode(clusterName, desiredNode.Name, flags.image, desiredNode.Role, flags.volumes)
 		}
 		if err != nil {
 			return errors.Wrapf(err, "error creating node %v", desiredNode)
@@ -255,24 +296,79 @@ func createNodes(clusterName string, flags *CreateOptions) error {
 		IPFamily: status.IPv4Family, // only IPv4 is tested with kinder
 	}
 
-	// TODO: the cluster and node settings are currently unused by kinder
-	// Enable these writes if settings have to stored on the nodes
-	//
-	// // write to the nodes the cluster settings that will be re-used by kinder during the cluster lifecycle.
-	//
-	// if err := c.WriteSettings(); err != nil {
-	// 	return err
-	// }
-	//
-	// for _, n := range c.K8sNodes() {
-	// 	if err := n.WriteNodeSettings(&status.NodeSettings{}); err != nil {
-	// 		return err
-	// 	}
-	// }
+	// NB. the cluster settings are not written to the nodes at create time; settings are written only when
+	// required, e.g. by SetBootstrapControlPlane, while the IP family is detected from the kubeadm config
+	// by ReadSettings when not set.
+
+	// write to the nodes the container runtime selected at create time, if any
+	for _, n := range c.K8sNodes() {
+		cri, ok := nodeCRIs[n.Name()]
+		if !ok {
+			continue
+		}
+		log.Infof("Using %s container runtime for node %s", cri, n.Name())
+		if err := n.WriteNodeSettings(&status.NodeSettings{CRI: cri}); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// resolveNodeCRIs returns the container runtimes selected for the K8s nodes, indexed by the node name;
+// nodes can be specified with or without the cluster name prefix
+func resolveNodeCRIs(clusterName string, desiredNodes []nodeSpec, nodeCRIs map[string]string) (map[string]status.ContainerRuntime, error) {
+	resolved := map[string]status.ContainerRuntime{}
+	for name, value := range nodeCRIs {
+		cri := status.ContainerRuntime(strings.ToLower(value))
+		if err := status.ValidateContainerRuntime(cri); err != nil {
+			return nil, errors.Wrapf(err, "invalid container runtime for node %s", name)
+		}
+
+		if !strings.HasPrefix(name, clusterName+"-") {
+			name = fmt.Sprintf("%s-%s", clusterName, name)
+		}
+		found := false
+		for _, n := range desiredNodes {
+			if n.Name == name && (n.Role == constants.ControlPlaneNodeRoleValue || n.Role == constants.WorkerNodeRoleValue) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("the container runtime can be selected only for K8s nodes, %s is not a K8s node of the cluster", name)
+		}
+		resolved[name] = cri
+	}
+	return resolved, nil
+}
+
+// criValues returns the container runtimes selected for the nodes, sorted and without duplicates
+func criValues(nodeCRIs map[string]status.ContainerRuntime) []status.ContainerRuntime {
+	seen := map[status.ContainerRuntime]bool{}
+	values := []status.ContainerRuntime{}
+	for _, cri := range nodeCRIs {
+		if !seen[cri] {
+			seen[cri] = true
+			values = append(values, cri)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return values
+}
+
+// nodesWithCRI returns the sorted names of the nodes the given container runtime is selected for
+func nodesWithCRI(nodeCRIs map[string]status.ContainerRuntime, cri status.ContainerRuntime) []string {
+	names := []string{}
+	for name, c := range nodeCRIs {
+		if c == cri {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // nodeSpec describes a node to create purely from the container aspect
 // this does not include eg starting kubernetes (see actions for that)
 type nodeSpec struct {
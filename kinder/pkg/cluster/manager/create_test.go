@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+)
+
+func TestResolveNodeCRIs(t *testing.T) {
+	desiredNodes := nodesToCreate("kinder", &CreateOptions{controlPlanes: 2, workers: 1})
+
+	tests := []struct {
+		name          string
+		nodeCRIs      map[string]string
+		expected      map[string]status.ContainerRuntime
+		expectedError bool
+	}{
+		{
+			name:     "no container runtimes selected",
+			expected: map[string]status.ContainerRuntime{},
+		},
+		{
+			name:     "nodes with and without the cluster name prefix",
+			nodeCRIs: map[string]string{"worker-1": "containerd", "kinder-control-plane-2": "Docker"},
+			expected: map[string]status.ContainerRuntime{
+				"kinder-worker-1":        status.ContainerdRuntime,
+				"kinder-control-plane-2": status.DockerRuntime,
+			},
+		},
+		{
+			name:          "unknown container runtime",
+			nodeCRIs:      map[string]string{"worker-1": "cri-o"},
+			expectedError: true,
+		},
+		{
+			name:          "unknown node",
+			nodeCRIs:      map[string]string{"worker-2": "containerd"},
+			expectedError: true,
+		},
+		{
+			name:          "not a K8s node",
+			nodeCRIs:      map[string]string{"lb": "containerd"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolved, err := resolveNodeCRIs("kinder", desiredNodes, test.nodeCRIs)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(resolved, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestCRIValues(t *testing.T) {
+	nodeCRIs := map[string]status.ContainerRuntime{
+		"kinder-worker-1":        status.DockerRuntime,
+		"kinder-worker-2":        status.ContainerdRuntime,
+		"kinder-control-plane-1": status.DockerRuntime,
+	}
+
+	expectedValues := []status.ContainerRuntime{status.ContainerdRuntime, status.DockerRuntime}
+	if values := criValues(nodeCRIs); !reflect.DeepEqual(values, expectedValues) {
+		t.Errorf("expected %v, got %v", expectedValues, values)
+	}
+
+	expectedNodes := []string{"kinder-control-plane-1", "kinder-worker-1"}
+	if names := nodesWithCRI(nodeCRIs, status.DockerRuntime); !reflect.DeepEqual(names, expectedNodes) {
+		t.Errorf("expected %v, got %v", expectedNodes, names)
+	}
+}
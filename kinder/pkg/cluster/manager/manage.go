@@ -72,19 +72,29 @@ func (c *ClusterManager) DryRun() {
 	}
 }
 
-// OnlyNode instruct the cluster manager to run only commands on one node
-func (c *ClusterManager) OnlyNode(node string) error {
-	found := false
+// RecordCommandHistory instruct the cluster manager to record the history of the commands run on the nodes
+func (c *ClusterManager) RecordCommandHistory() {
 	for _, n := range c.Cluster.AllNodes() {
-		if n.Name() == node {
-			log.Infof("Found matching node for --only-node: %s", node)
-			found = true
-			continue
-		}
-		n.SkipActions()
+		n.RecordCommandHistory()
+	}
+}
+
+// OnlyNodes instruct the cluster manager to run only commands on the nodes with the given names
+func (c *ClusterManager) OnlyNodes(names ...string) error {
+	nodes, err := c.Cluster.SelectNodesByName(names...)
+	if err != nil {
+		return errors.Wrap(err, "did not find a matching node for --only-node")
 	}
-	if !found {
-		return errors.Errorf("did not find a matching node for --only-node: %s", node)
+
+	selected := map[string]bool{}
+	for _, n := range nodes {
+		log.Infof("Found matching node for --only-node: %s", n.Name())
+		selected[n.Name()] = true
+	}
+	for _, n := range c.Cluster.AllNodes() {
+		if !selected[n.Name()] {
+			n.SkipActions()
+		}
 	}
 	return nil
 }
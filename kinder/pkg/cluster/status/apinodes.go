@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// apiNodesJSONPath defines the kubectl jsonpath expression used for listing the nodes registered in the API,
+// with their Ready condition
+const apiNodesJSONPath = `{range .items[*]}{.metadata.name}{" "}{.status.conditions[?(@.type=="Ready")].status}{"\n"}{end}`
+
+// APINode defines a node registered in the API
+type APINode struct {
+	// Name is the name of the node
+	Name string
+	// Ready is true if the node has the Ready condition set to True
+	Ready bool
+}
+
+// WaitForNodesRegistered waits for the expected number of nodes to be registered in the API and Ready, e.g. after
+// scaling a cluster, and returns the nodes registered in the API. Nodes are listed using kubectl and the admin.conf
+// file on the bootstrap control plane.
+func (c *Cluster) WaitForNodesRegistered(expected int, timeout time.Duration) ([]APINode, error) {
+	cp1 := c.BootstrapControlPlane()
+	if cp1 == nil {
+		return nil, errors.New("the cluster does not have a control plane node")
+	}
+
+	var nodes []APINode
+	var lastErr error
+	err := wait.PollImmediate(time.Second*1, timeout, func() (bool, error) {
+		lines, err := cp1.Command(
+			"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "get", "nodes", "-o", "jsonpath="+apiNodesJSONPath,
+		).Silent().RunAndCapture()
+		if err != nil {
+			lastErr = errors.Wrapf(err, "failed to list the nodes from node %s: %s", cp1.Name(), strings.Join(lines, "\n"))
+			return false, nil
+		}
+
+		nodes = parseAPINodes(lines)
+		ready := 0
+		for _, n := range nodes {
+			if n.Ready {
+				ready++
+			}
+		}
+		log.Debugf("%d of %d expected nodes are Ready", ready, expected)
+		if ready < expected {
+			lastErr = errors.Errorf("%d of %d expected nodes are Ready", ready, expected)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nodes, errors.Wrapf(lastErr, "timeout waiting for %d nodes to be registered", expected)
+	}
+	return nodes, nil
+}
+
+// parseAPINodes parses the output of kubectl get nodes using apiNodesJSONPath
+func parseAPINodes(lines []string) []APINode {
+	nodes := []APINode{}
+	for _, l := range lines {
+		fields := strings.Fields(l)
+		if len(fields) == 0 {
+			continue
+		}
+		nodes = append(nodes, APINode{
+			Name:  fields[0],
+			Ready: len(fields) > 1 && fields[1] == "True",
+		})
+	}
+	return nodes
+}
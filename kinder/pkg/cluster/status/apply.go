@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// applyManifestPath defines the path where manifests are staged on the node before applying them
+const applyManifestPath = "/kinder/apply-manifest.yaml"
+
+// ApplyResult defines the result of applying an object with kubectl apply
+type ApplyResult struct {
+	// Object is the kind and name of the applied object, e.g. deployment.apps/nginx
+	Object string
+	// Action is the action executed by kubectl apply, e.g. created, configured, unchanged or serverside-applied
+	Action string
+}
+
+// ApplyOption is a configuration option supplied to ApplyManifest
+type ApplyOption func(*applyOptions)
+
+type applyOptions struct {
+	serverSide     bool
+	forceConflicts bool
+}
+
+// ServerSideApply option instructs ApplyManifest to use server-side apply instead of client-side apply;
+// if forceConflicts is true, conflicts with other field managers are forced
+func ServerSideApply(forceConflicts bool) ApplyOption {
+	return func(o *applyOptions) {
+		o.serverSide = true
+		o.forceConflicts = forceConflicts
+	}
+}
+
+// ApplyManifest applies the given YAML manifest to the cluster using the kubectl binary and the admin.conf
+// kubeconfig available on the node, that must be a control-plane node where kubeadm init or join is completed.
+func (n *Node) ApplyManifest(manifest []byte, options ...ApplyOption) ([]ApplyResult, error) {
+	if !n.IsControlPlane() {
+		return nil, errors.Errorf("manifests can be applied only from control-plane nodes, %s is a %s node", n.Name(), n.Role())
+	}
+
+	o := &applyOptions{}
+	for _, option := range options {
+		option(o)
+	}
+
+	if err := n.WriteFile(applyManifestPath, manifest); err != nil {
+		return nil, err
+	}
+	defer n.Command("rm", "-f", applyManifestPath).Silent().Run()
+
+	args := []string{"--kubeconfig=/etc/kubernetes/admin.conf", "apply", "-f", applyManifestPath}
+	if o.serverSide {
+		args = append(args, "--server-side")
+		if o.forceConflicts {
+			args = append(args, "--force-conflicts")
+		}
+	}
+
+	lines, err := n.Command("kubectl", args...).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to apply the manifest on node %s: %s", n.Name(), strings.Join(lines, "\n"))
+	}
+
+	return parseApplyResults(lines), nil
+}
+
+// parseApplyResults parses the output of kubectl apply, that is in the form "deployment.apps/nginx created";
+// lines not matching this format, e.g. warnings, are ignored
+func parseApplyResults(lines []string) []ApplyResult {
+	results := []ApplyResult{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.Contains(fields[0], "/") {
+			continue
+		}
+		results = append(results, ApplyResult{Object: fields[0], Action: fields[1]})
+	}
+	return results
+}
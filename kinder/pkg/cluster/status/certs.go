@@ -0,0 +1,47 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
+)
+
+// apiServerCertPath defines the path of the API server serving certificate generated by kubeadm
+const apiServerCertPath = "/etc/kubernetes/pki/apiserver.crt"
+
+// APIServerCertSANs returns the DNS names and the IP addresses included in the Subject Alternative Names of the
+// API server serving certificate on a control plane node, e.g. for asserting that a certSANs entry configured
+// in the kubeadm config actually landed in the serving certificate
+func (n *Node) APIServerCertSANs() ([]string, error) {
+	if !n.IsControlPlane() {
+		return nil, errors.Errorf("node %s is not a control plane node", n.Name())
+	}
+
+	lines, err := n.Command("cat", apiServerCertPath).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s from node %s", apiServerCertPath, n.Name())
+	}
+	sans, err := kubeadm.CertSANs([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid certificate %s on node %s", apiServerCertPath, n.Name())
+	}
+	return sans, nil
+}
@@ -18,16 +18,19 @@ package status
 
 import (
 	"fmt"
+	"net"
 	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/homedir"
 
 	"k8s.io/kubeadm/kinder/pkg/constants"
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
 )
 
 // Cluster represents an existing kind(er) clusters
@@ -40,6 +43,10 @@ type Cluster struct {
 	workers              NodeList
 	externalEtcd         *Node
 	externalLoadBalancer *Node
+
+	// bootstrapControlPlane is the node set as the bootstrap control plane, if any;
+	// if nil, the first node with control-plane role is used
+	bootstrapControlPlane *Node
 }
 
 // ClusterSettings defines a set of settings that will be stored in the cluster and re-used
@@ -52,6 +59,10 @@ type ClusterSettings struct {
 	// kind configuration settings that are used to configure the cluster when
 	// generating the kubeadm config file.
 	IPFamily ClusterIPFamily `json:"ipFamily,omitempty"`
+
+	// BootstrapControlPlane is the name of the node set as the bootstrap control plane
+	// using SetBootstrapControlPlane, if any
+	BootstrapControlPlane string `json:"bootstrapControlPlane,omitempty"`
 }
 
 // ClusterIPFamily defines cluster network IP family
@@ -62,6 +73,8 @@ const (
 	IPv4Family ClusterIPFamily = "ipv4"
 	// IPv6Family sets ClusterIPFamily to ipv6
 	IPv6Family ClusterIPFamily = "ipv6"
+	// DualStackFamily sets ClusterIPFamily to dual-stack
+	DualStackFamily ClusterIPFamily = "dual"
 )
 
 // ListClusters is part of the providers.Provider interface
@@ -189,23 +202,141 @@ func (c *Cluster) Validate() error {
 	return nil
 }
 
-// ReadSettings read cluster settings from a control plane node
+// ReadSettings read cluster settings from the first control plane node where settings can be read, e.g. skipping
+// control plane nodes that are stopped; if the bootstrap control plane is defined in the settings, it is used as
+// the BootstrapControlPlane, and if the IP family is not defined in the settings, it is detected from the kubeadm config
 func (c *Cluster) ReadSettings() (err error) {
+	c.readSettings((*Node).ReadClusterSettings)
+
+	if c.Settings.IPFamily == "" {
+		c.Settings.IPFamily, err = c.DetectIPFamily()
+		if err != nil {
+			return err
+		}
+		log.Debugf("Detected IP family %s", c.Settings.IPFamily)
+	}
+	return nil
+}
+
+// readSettings read cluster settings using the given read function, and applies the bootstrap control plane
+// defined in the settings, if any; if settings can't be read from any control plane node, defaults are used
+func (c *Cluster) readSettings(read func(*Node) (*ClusterSettings, error)) {
 	log.Debug("Reading cluster settings...")
-	c.Settings, err = c.BootstrapControlPlane().ReadClusterSettings()
+	c.Settings = nil
+	for _, n := range c.controlPlanes {
+		settings, err := read(n)
+		if err != nil {
+			log.Debugf("failed to read cluster settings from node %s: %v", n.Name(), err)
+			continue
+		}
+		c.Settings = settings
+		break
+	}
+	if c.Settings == nil {
+		log.Warn("Failed to read cluster settings from the control plane nodes, using defaults")
+		c.Settings = &ClusterSettings{}
+	}
+
+	c.bootstrapControlPlane = nil
+	if name := c.Settings.BootstrapControlPlane; name != "" {
+		c.bootstrapControlPlane = nil
+		for _, n := range c.controlPlanes {
+			if n.Name() == name {
+				c.bootstrapControlPlane = n
+				break
+			}
+		}
+		// NB. the bootstrap control plane could have been removed from the cluster after being set
+		if c.bootstrapControlPlane == nil {
+			log.Warnf("Bootstrap control plane %s does not exist anymore, using %s", name, c.BootstrapControlPlane().Name())
+		}
+		log.Debugf("Using bootstrap control plane %s", c.BootstrapControlPlane().Name())
+	}
+}
+
+// DetectIPFamily detects the IP family of the cluster from the pod and service subnets in the ClusterConfiguration
+// of the kubeadm config on the bootstrap control plane: IPv4 only, IPv6 only or dual-stack.
+// NB. the node addresses are not used, because nodes can get IPv6 addresses from the docker network also
+// in IPv4 clusters; if the kubeadm config or the subnets are not defined yet, IPv4, the kind default, is returned
+func (c *Cluster) DetectIPFamily() (ClusterIPFamily, error) {
+	cp1 := c.BootstrapControlPlane()
+	if cp1 == nil {
+		return "", errors.New("failed to detect the IP family: the cluster does not have a control-plane node")
+	}
+
+	lines, err := cp1.Command("cat", constants.KubeadmConfigPath).Silent().RunAndCapture()
 	if err != nil {
-		return errors.Wrapf(err, "failed to read cluster settings from node %s", c.BootstrapControlPlane().name)
+		log.Debugf("kubeadm config not found on node %s, assuming IPv4: %v", cp1.Name(), err)
+		return IPv4Family, nil
 	}
-	return nil
+	config, err := kubeadm.ParseConfig(strings.Join(lines, "\n"))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to detect the IP family from the kubeadm config on node %s", cp1.Name())
+	}
+	if config.ClusterConfiguration == nil {
+		return IPv4Family, nil
+	}
+
+	networking := config.ClusterConfiguration.Networking
+	return ipFamilyFromSubnets(networking.PodSubnet, networking.ServiceSubnet)
+}
+
+// ipFamilyFromSubnets returns the IP family of the given subnets, e.g. the pod subnet and the service subnet
+// of the kubeadm config; each subnet can be a comma separated list of CIDRs, like in dual-stack clusters.
+// Empty subnets are ignored, and if all the subnets are empty, IPv4 is returned.
+func ipFamilyFromSubnets(subnets ...string) (ClusterIPFamily, error) {
+	var ipv4, ipv6 bool
+	for _, subnet := range subnets {
+		if subnet == "" {
+			continue
+		}
+		for _, cidr := range strings.Split(subnet, ",") {
+			ip, _, err := net.ParseCIDR(strings.TrimSpace(cidr))
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to detect the IP family: invalid subnet %q", subnet)
+			}
+			if ip.To4() != nil {
+				ipv4 = true
+			} else {
+				ipv6 = true
+			}
+		}
+	}
+
+	switch {
+	case ipv4 && ipv6:
+		return DualStackFamily, nil
+	case ipv6:
+		return IPv6Family, nil
+	}
+	return IPv4Family, nil
 }
 
-// WriteSettings writes cluster settings nodes
+// WriteSettings writes cluster settings to the K8s nodes; nodes where settings can't be written, e.g. because
+// they are stopped, are skipped, but settings must be written at least on one control plane node
 func (c *Cluster) WriteSettings() error {
+	return c.writeSettings((*Node).WriteClusterSettings)
+}
+
+// writeSettings writes cluster settings to the K8s nodes using the given write function
+func (c *Cluster) writeSettings(write func(*Node, *ClusterSettings) error) error {
 	log.Debug("Writings cluster settings...")
+	var errs []error
+	written := false
 	for _, n := range c.K8sNodes() {
-		if err := n.WriteClusterSettings(c.Settings); err != nil {
-			return errors.Wrapf(err, "failed to write cluster settings to node %s", n.name)
+		if err := write(n, c.Settings); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to write cluster settings to node %s", n.name))
+			continue
 		}
+		if n.IsControlPlane() {
+			written = true
+		}
+	}
+	if !written {
+		return errors.Wrap(utilerrors.NewAggregate(errs), "failed to write cluster settings to the control plane nodes")
+	}
+	for _, err := range errs {
+		log.Warnf("%v, skipping", err)
 	}
 	return nil
 }
@@ -258,22 +389,80 @@ func (c *Cluster) ControlPlanes() NodeList {
 	return c.controlPlanes
 }
 
-// BootstrapControlPlane returns the first node with control-plane role.
-// This is the node where kubeadm init will be executed.
+// BootstrapControlPlane returns the first node with control-plane role, unless another node
+// was set using SetBootstrapControlPlane.
+// This is the node where kubeadm init will be executed, and it is used as a reference by the actions
+// after init, e.g. for generating the kubeadm config or the discovery files.
 func (c *Cluster) BootstrapControlPlane() *Node {
+	if c.bootstrapControlPlane != nil {
+		return c.bootstrapControlPlane
+	}
 	if len(c.controlPlanes) == 0 {
 		return nil
 	}
 	return c.controlPlanes[0]
 }
 
+// SetBootstrapControlPlane sets the node to be returned by BootstrapControlPlane, e.g. for testing control-plane
+// failover scenarios where the original bootstrap control plane is removed; the node must be a healthy control-plane
+// node where kubeadm init or join is completed. The new bootstrap control plane is stored in the cluster settings
+// on the nodes, and it is read back by ReadSettings, so it is preserved across kinder invocations.
+func (c *Cluster) SetBootstrapControlPlane(n *Node) error {
+	found := false
+	for _, cp := range c.controlPlanes {
+		if cp == n {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.New("the bootstrap control plane must be a control-plane node of the cluster")
+	}
+
+	if err := n.Command("test", "-f", "/etc/kubernetes/admin.conf").Silent().Run(); err != nil {
+		return errors.Errorf("node %s can't be the bootstrap control plane: /etc/kubernetes/admin.conf does not exist", n.Name())
+	}
+
+	lines, err := n.Command(
+		"curl", "-k", "-s", "-o", "/dev/null", "-w", "%{http_code}",
+		fmt.Sprintf("https://localhost:%d/healthz", constants.APIServerPort),
+	).Silent().RunAndCapture()
+	if err != nil || len(lines) != 1 || strings.TrimSpace(lines[0]) != "200" {
+		return errors.Errorf("node %s can't be the bootstrap control plane: the API server is not healthy", n.Name())
+	}
+
+	if c.Settings == nil {
+		if err := c.ReadSettings(); err != nil {
+			return err
+		}
+	}
+
+	return c.setBootstrapControlPlane(n, (*Node).WriteClusterSettings)
+}
+
+// setBootstrapControlPlane sets the bootstrap control plane and writes it in the cluster settings
+// using the given write function
+func (c *Cluster) setBootstrapControlPlane(n *Node, write func(*Node, *ClusterSettings) error) error {
+	c.bootstrapControlPlane = n
+	c.Settings.BootstrapControlPlane = n.Name()
+	return c.writeSettings(write)
+}
+
 // SecondaryControlPlanes returns all the nodes with control-plane role
 // except the BootstrapControlPlane node, if any,
 func (c *Cluster) SecondaryControlPlanes() NodeList {
 	if len(c.controlPlanes) <= 1 {
 		return nil
 	}
-	return c.controlPlanes[1:]
+
+	cp1 := c.BootstrapControlPlane()
+	nodes := NodeList{}
+	for _, n := range c.controlPlanes {
+		if n != cp1 {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
 }
 
 // Workers returns all the nodes with Worker role, if any
@@ -348,6 +537,33 @@ func (c *Cluster) SelectNodes(nodeSelector string) (nodes NodeList, err error) {
 	return nil, nil
 }
 
+// SelectNodesByName returns the nodes with the given names, sorted by provisioning order.
+// Names can be the full node names, e.g. kind-control-plane-1, or the node names without the cluster
+// name prefix, e.g. control-plane-1; an error is returned if any name does not match a node in the cluster.
+func (c *Cluster) SelectNodesByName(names ...string) (NodeList, error) {
+	selected := map[string]*Node{}
+	for _, name := range names {
+		var match *Node
+		for _, n := range c.AllNodes() {
+			if strings.EqualFold(name, n.Name()) || strings.EqualFold(fmt.Sprintf("%s-%s", c.name, name), n.Name()) {
+				match = n
+				break
+			}
+		}
+		if match == nil {
+			return nil, errors.Errorf("node %q does not exist in cluster %s", name, c.name)
+		}
+		selected[match.Name()] = match
+	}
+
+	nodes := NodeList{}
+	for _, n := range selected {
+		nodes = append(nodes, n)
+	}
+	nodes.Sort()
+	return nodes, nil
+}
+
 func toNodeList(node *Node) NodeList {
 	if node != nil {
 		return NodeList{node}
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+func TestIPFamilyFromSubnets(t *testing.T) {
+	tests := []struct {
+		name           string
+		podSubnet      string
+		serviceSubnet  string
+		expectedFamily ClusterIPFamily
+		expectedError  bool
+	}{
+		{
+			name:           "ipv4",
+			podSubnet:      "192.168.0.0/16",
+			serviceSubnet:  "10.96.0.0/12",
+			expectedFamily: IPv4Family,
+		},
+		{
+			name:           "ipv6",
+			podSubnet:      "fd00:10:244::/56",
+			serviceSubnet:  "fd00:10:96::/112",
+			expectedFamily: IPv6Family,
+		},
+		{
+			name:           "dual-stack",
+			podSubnet:      "192.168.0.0/16,fd00:10:244::/56",
+			serviceSubnet:  "10.96.0.0/12, fd00:10:96::/112",
+			expectedFamily: DualStackFamily,
+		},
+		{
+			name:           "only the pod subnet is set",
+			podSubnet:      "fd00:10:244::/56",
+			expectedFamily: IPv6Family,
+		},
+		{
+			name:           "no subnets defaults to ipv4",
+			expectedFamily: IPv4Family,
+		},
+		{
+			name:          "invalid subnet",
+			podSubnet:     "192.168.0.0",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			family, err := ipFamilyFromSubnets(test.podSubnet, test.serviceSubnet)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if family != test.expectedFamily {
+				t.Errorf("expected IP family %q, got %q", test.expectedFamily, family)
+			}
+		})
+	}
+}
+
+func TestBootstrapControlPlaneSettings(t *testing.T) {
+	// settings are stored by node name, like the cluster settings file on the nodes;
+	// stopped nodes can't be read or written
+	store := map[string]ClusterSettings{}
+	stopped := map[string]bool{}
+	read := func(n *Node) (*ClusterSettings, error) {
+		if stopped[n.Name()] {
+			return nil, errors.Errorf("node %s is stopped", n.Name())
+		}
+		settings := store[n.Name()]
+		return &settings, nil
+	}
+	write := func(n *Node, settings *ClusterSettings) error {
+		if stopped[n.Name()] {
+			return errors.Errorf("node %s is stopped", n.Name())
+		}
+		store[n.Name()] = *settings
+		return nil
+	}
+
+	newCluster := func(controlPlanes ...string) *Cluster {
+		c := &Cluster{name: "kinder"}
+		for _, name := range controlPlanes {
+			if err := c.add(&Node{name: name, role: constants.ControlPlaneNodeRoleValue}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := c.add(&Node{name: "kinder-worker", role: constants.WorkerNodeRoleValue}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		c.readSettings(read)
+		return c
+	}
+
+	assertControlPlanes := func(c *Cluster, expectedBootstrap string, expectedSecondaries ...string) {
+		t.Helper()
+		if name := c.BootstrapControlPlane().Name(); name != expectedBootstrap {
+			t.Errorf("expected bootstrap control plane %s, got %s", expectedBootstrap, name)
+		}
+		var secondaries []string
+		for _, n := range c.SecondaryControlPlanes() {
+			secondaries = append(secondaries, n.Name())
+		}
+		if !reflect.DeepEqual(secondaries, expectedSecondaries) {
+			t.Errorf("expected secondary control planes %v, got %v", expectedSecondaries, secondaries)
+		}
+	}
+
+	// without settings, the first control plane is the bootstrap control plane
+	c := newCluster("kinder-control-plane-1", "kinder-control-plane-2", "kinder-control-plane-3")
+	assertControlPlanes(c, "kinder-control-plane-1", "kinder-control-plane-2", "kinder-control-plane-3")
+
+	// the original bootstrap control plane is stopped, and another control plane is set as the bootstrap control plane
+	stopped["kinder-control-plane-1"] = true
+	if err := c.setBootstrapControlPlane(c.ControlPlanes()[1], write); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertControlPlanes(c, "kinder-control-plane-2", "kinder-control-plane-1", "kinder-control-plane-3")
+	if _, ok := store["kinder-worker"]; !ok {
+		t.Error("expected cluster settings to be written on the worker nodes")
+	}
+
+	// the bootstrap control plane survives a new Cluster, e.g. a new kinder invocation,
+	// with settings read from a node that is not stopped
+	c = newCluster("kinder-control-plane-1", "kinder-control-plane-2", "kinder-control-plane-3")
+	assertControlPlanes(c, "kinder-control-plane-2", "kinder-control-plane-1", "kinder-control-plane-3")
+
+	// once the original bootstrap control plane is removed, the bootstrap control plane is still preserved
+	c = newCluster("kinder-control-plane-2", "kinder-control-plane-3")
+	assertControlPlanes(c, "kinder-control-plane-2", "kinder-control-plane-3")
+
+	// if the bootstrap control plane is removed, the first control plane is used
+	c = newCluster("kinder-control-plane-3")
+	assertControlPlanes(c, "kinder-control-plane-3")
+
+	// settings must be written at least on one control plane
+	stopped["kinder-control-plane-3"] = true
+	if err := c.setBootstrapControlPlane(c.ControlPlanes()[0], write); err == nil {
+		t.Error("expected an error when settings can't be written on any control plane, got nil")
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// componentRestartTimeout defines how long to wait for the kubelet to restart a control-plane component
+// after its static pod manifest is changed
+const componentRestartTimeout = 2 * time.Minute
+
+// verbosityFlagRegexp matches the --v flag in the command of a static pod manifest
+var verbosityFlagRegexp = regexp.MustCompile(`^(\s*- )--v=\d+$`)
+
+// isVerbosityComponent returns true if the component is a control-plane component supporting the --v flag
+func isVerbosityComponent(component string) bool {
+	switch component {
+	case "kube-apiserver", "kube-controller-manager", "kube-scheduler":
+		return true
+	}
+	return false
+}
+
+// SetComponentVerbosity sets the --v flag of a control-plane component, e.g. kube-apiserver, patching its static pod
+// manifest on the node, and waits for the kubelet to restart the component.
+// The returned function restores the original static pod manifest and waits for the component to restart again;
+// it is meant to be called on teardown.
+func (n *Node) SetComponentVerbosity(component string, level int) (func() error, error) {
+	if !n.IsControlPlane() {
+		return nil, errors.Errorf("node %s is not a control-plane node", n.Name())
+	}
+	if !isVerbosityComponent(component) {
+		return nil, errors.Errorf("unsupported component %q, it must be one of kube-apiserver, kube-controller-manager or kube-scheduler", component)
+	}
+
+	manifestPath := fmt.Sprintf("%s/%s.yaml", staticPodManifestsDir, component)
+	original, err := n.Command("cat", manifestPath).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the static pod manifest %s on node %s", manifestPath, n.Name())
+	}
+
+	patched, err := setVerbosityFlag(original, component, level)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to set the verbosity in the static pod manifest %s", manifestPath)
+	}
+
+	n.Infof("Setting %s verbosity to %d", component, level)
+	if err := n.writeStaticPodManifest(component, manifestPath, patched); err != nil {
+		return nil, err
+	}
+
+	restore := func() error {
+		n.Infof("Restoring %s verbosity", component)
+		return n.writeStaticPodManifest(component, manifestPath, original)
+	}
+	return restore, nil
+}
+
+// writeStaticPodManifest writes a static pod manifest, and waits for the kubelet to restart the component.
+// The manifest is written atomically, so the kubelet never reads a partially written manifest; the temporary file
+// is written outside of the static pod manifests folder, otherwise the kubelet could start it as a static pod.
+func (n *Node) writeStaticPodManifest(component, manifestPath string, lines []string) error {
+	hash, _ := n.componentConfigHash(component)
+
+	tmpPath := path.Join(path.Dir(staticPodManifestsDir), fmt.Sprintf(".%s.yaml.tmp", component))
+	if err := n.WriteFile(tmpPath, []byte(strings.Join(lines, "\n")+"\n")); err != nil {
+		return errors.Wrapf(err, "failed to write the static pod manifest %s on node %s", tmpPath, n.Name())
+	}
+	if err := n.Command("mv", "-f", tmpPath, manifestPath).Silent().Run(); err != nil {
+		return errors.Wrapf(err, "failed to replace the static pod manifest %s on node %s", manifestPath, n.Name())
+	}
+
+	// the kubelet restarts the component as soon as the manifest changes, and then updates
+	// the config hash annotation of the mirror pod
+	err := wait.PollImmediate(time.Second, componentRestartTimeout, func() (bool, error) {
+		newHash, ready := n.componentConfigHash(component)
+		return newHash != "" && newHash != hash && ready, nil
+	})
+	if err != nil {
+		return errors.Errorf("timeout: %s was not restarted on node %s", component, n.Name())
+	}
+	return nil
+}
+
+// componentConfigHash returns the config hash of a control-plane component mirror pod, and true if the pod is ready;
+// errors, e.g. the API server is not reachable during restarts, are ignored
+func (n *Node) componentConfigHash(component string) (string, bool) {
+	lines, err := n.Command(
+		"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "-n", "kube-system",
+		"get", "pod", fmt.Sprintf("%s-%s", component, n.Name()),
+		"-o", `jsonpath={.metadata.annotations.kubernetes\.io/config\.hash} {.status.conditions[?(@.type=="Ready")].status}`,
+	).Silent().RunAndCapture()
+	if err != nil || len(lines) != 1 {
+		log.Debugf("failed to get the %s pod on node %s: %v", component, n.Name(), err)
+		return "", false
+	}
+	fields := strings.Fields(lines[0])
+	if len(fields) != 2 {
+		return "", false
+	}
+	return fields[0], fields[1] == "True"
+}
+
+// setVerbosityFlag returns the lines of a static pod manifest with the --v flag of the component set to level;
+// if the flag does not exist, it is added after the component binary in the container command
+func setVerbosityFlag(lines []string, component string, level int) ([]string, error) {
+	patched := make([]string, 0, len(lines)+1)
+	commandRegexp := regexp.MustCompile(fmt.Sprintf(`^(\s*- )%s$`, regexp.QuoteMeta(component)))
+	found := false
+	for _, l := range lines {
+		if m := verbosityFlagRegexp.FindStringSubmatch(l); m != nil {
+			patched = append(patched, fmt.Sprintf("%s--v=%d", m[1], level))
+			found = true
+			continue
+		}
+		patched = append(patched, l)
+	}
+	if found {
+		return patched, nil
+	}
+
+	patched = patched[:0]
+	for _, l := range lines {
+		patched = append(patched, l)
+		if m := commandRegexp.FindStringSubmatch(l); m != nil && !found {
+			patched = append(patched, fmt.Sprintf("%s--v=%d", m[1], level))
+			found = true
+		}
+	}
+	if !found {
+		return nil, errors.Errorf("the %s command does not exist", component)
+	}
+	return patched, nil
+}
+
+// ComponentLogs returns the logs of a control-plane component, e.g. kube-apiserver, running on the node,
+// limited to the last sinceSeconds seconds; if sinceSeconds is 0, all the logs are returned
+func (n *Node) ComponentLogs(component string, sinceSeconds int) ([]string, error) {
+	if !n.IsControlPlane() {
+		return nil, errors.Errorf("node %s is not a control-plane node", n.Name())
+	}
+
+	args := []string{
+		"--kubeconfig=/etc/kubernetes/admin.conf", "-n", "kube-system",
+		"logs", fmt.Sprintf("%s-%s", component, n.Name()),
+	}
+	if sinceSeconds > 0 {
+		args = append(args, fmt.Sprintf("--since=%ds", sinceSeconds))
+	}
+
+	lines, err := n.Command("kubectl", args...).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the %s logs on node %s", component, n.Name())
+	}
+	return lines, nil
+}
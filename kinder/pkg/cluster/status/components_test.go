@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetVerbosityFlag(t *testing.T) {
+	tests := []struct {
+		name          string
+		component     string
+		lines         []string
+		level         int
+		expected      []string
+		expectedError bool
+	}{
+		{
+			name:      "existing flag is replaced",
+			component: "kube-apiserver",
+			lines: []string{
+				"    command:",
+				"    - kube-apiserver",
+				"    - --advertise-address=172.17.0.2",
+				"    - --v=2",
+			},
+			level: 5,
+			expected: []string{
+				"    command:",
+				"    - kube-apiserver",
+				"    - --advertise-address=172.17.0.2",
+				"    - --v=5",
+			},
+		},
+		{
+			name:      "missing flag is added after the component command",
+			component: "kube-scheduler",
+			lines: []string{
+				"    command:",
+				"    - kube-scheduler",
+				"    - --bind-address=127.0.0.1",
+			},
+			level: 4,
+			expected: []string{
+				"    command:",
+				"    - kube-scheduler",
+				"    - --v=4",
+				"    - --bind-address=127.0.0.1",
+			},
+		},
+		{
+			name:      "flags with a similar name are not changed",
+			component: "kube-controller-manager",
+			lines: []string{
+				"    command:",
+				"    - kube-controller-manager",
+				"    - --vmodule=foo=4",
+			},
+			level: 3,
+			expected: []string{
+				"    command:",
+				"    - kube-controller-manager",
+				"    - --v=3",
+				"    - --vmodule=foo=4",
+			},
+		},
+		{
+			name:      "component command not found",
+			component: "kube-apiserver",
+			lines: []string{
+				"    command:",
+				"    - kube-scheduler",
+			},
+			level:         5,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patched, err := setVerbosityFlag(test.lines, test.component, test.level)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err == nil && !reflect.DeepEqual(patched, test.expected) {
+				t.Errorf("expected:\n%v\ngot:\n%v", test.expected, patched)
+			}
+		})
+	}
+}
@@ -17,6 +17,10 @@ limitations under the License.
 package status
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
@@ -37,8 +41,40 @@ const (
 	ContainerdRuntime ContainerRuntime = "containerd"
 )
 
+const (
+	// SystemdCgroupDriver refers to the systemd cgroup driver
+	SystemdCgroupDriver = "systemd"
+	// CgroupfsCgroupDriver refers to the cgroupfs cgroup driver
+	CgroupfsCgroupDriver = "cgroupfs"
+)
+
+// containerdSystemdCgroupRegexp matches the runc option enabling the systemd cgroup driver in the containerd config
+var containerdSystemdCgroupRegexp = regexp.MustCompile(`^\s*SystemdCgroup\s*=\s*true\s*$`)
+
+// kubeletConfigPath defines the path of the kubelet config written by kubeadm
+const kubeletConfigPath = "/var/lib/kubelet/config.yaml"
+
 // InspectCRIinImage inspect an image and detects the installed container runtime
-func InspectCRIinImage(image string) (ContainerRuntime, error) {
+func InspectCRIinImage(image string) (cri ContainerRuntime, err error) {
+	err = runInImageContainer(image, func(id string) error {
+		cri, err = InspectCRIinContainer(id)
+		return err
+	})
+	return cri, err
+}
+
+// IsCRIInstalledInImage checks if the given container runtime is installed in an image
+func IsCRIInstalledInImage(image string, cri ContainerRuntime) (installed bool, err error) {
+	err = runInImageContainer(image, func(id string) error {
+		installed, err = IsCRIInstalledInContainer(id, cri)
+		return err
+	})
+	return installed, err
+}
+
+// runInImageContainer creates a temporary container from an image, and runs f against it;
+// the container is deleted when f returns
+func runInImageContainer(image string, f func(id string) error) error {
 	// define docker default args
 	id := "kind-detect-" + uuid.New().String()
 	runArgs := []string{
@@ -49,13 +85,13 @@ func InspectCRIinImage(image string) (ContainerRuntime, error) {
 	contatinerArgs := []string{"infinity"} // sleep infinitely to keep the container around
 
 	if err := host.Run(image, runArgs, contatinerArgs); err != nil {
-		return "", errors.Wrap(err, "error creating a temporary container for CRI detection")
+		return errors.Wrap(err, "error creating a temporary container for CRI detection")
 	}
 	defer func() {
 		exec.NewHostCmd("docker", "rm", "-f", id).Run()
 	}()
 
-	return InspectCRIinContainer(id)
+	return f(id)
 }
 
 // InspectCRIinContainer inspect a running container and detects the installed container runtime
@@ -74,3 +110,97 @@ func InspectCRIinContainer(id string) (ContainerRuntime, error) {
 
 	return ContainerdRuntime, nil
 }
+
+// KnownContainerRuntimes returns the list of known ContainerRuntime
+func KnownContainerRuntimes() []string {
+	return []string{
+		string(ContainerdRuntime),
+		string(DockerRuntime),
+	}
+}
+
+// ValidateContainerRuntime validates a ContainerRuntime
+func ValidateContainerRuntime(cri ContainerRuntime) error {
+	switch cri {
+	case ContainerdRuntime:
+	case DockerRuntime:
+	default:
+		return errors.Errorf("invalid container runtime %q. Use one of %s", cri, KnownContainerRuntimes())
+	}
+	return nil
+}
+
+// IsCRIInstalledInContainer checks if the given container runtime is installed in a running container
+// NB. this method use raw kinddocker/kindexec commands because it is used also during "create"
+// (before an actual Cluster status exist)
+func IsCRIInstalledInContainer(id string, cri ContainerRuntime) (bool, error) {
+	// NB. the name of the container runtime binary matches the name of the container runtime
+	lines, err := exec.NewNodeCmd(id, "/bin/sh", "-c", fmt.Sprintf("which %s || true", cri)).Silent().RunAndCapture()
+	if err != nil {
+		return false, errors.Wrapf(err, "error detecting %s", cri)
+	}
+	return len(lines) > 0, nil
+}
+
+// CgroupDriver returns the cgroup driver used by the container runtime installed on the node,
+// that is systemd or cgroupfs; the value is cached, so the container runtime config is read only once per node
+func (n *Node) CgroupDriver() (driver string, err error) {
+	if n.cgroupDriver != "" {
+		return n.cgroupDriver, nil
+	}
+
+	n.cgroupDriver, err = n.detectCgroupDriver()
+	if err != nil {
+		return "", err
+	}
+	return n.cgroupDriver, nil
+}
+
+// detectCgroupDriver reads the cgroup driver from the config of the container runtime installed on the node
+func (n *Node) detectCgroupDriver() (string, error) {
+	cri, err := n.CRI()
+	if err != nil {
+		return "", err
+	}
+
+	switch cri {
+	case ContainerdRuntime:
+		lines, err := n.Command("containerd", "config", "dump").Silent().RunAndCapture()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read the containerd config on node %s", n.Name())
+		}
+		if len(lines) == 0 {
+			return "", errors.Errorf("empty containerd config on node %s", n.Name())
+		}
+		for _, line := range lines {
+			if containerdSystemdCgroupRegexp.MatchString(line) {
+				return SystemdCgroupDriver, nil
+			}
+		}
+		return CgroupfsCgroupDriver, nil
+	case DockerRuntime:
+		lines, err := n.Command("docker", "info", "--format", "{{.CgroupDriver}}").Silent().RunAndCapture()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read the docker info on node %s", n.Name())
+		}
+		if len(lines) != 1 {
+			return "", errors.Errorf("failed to detect the docker cgroup driver on node %s", n.Name())
+		}
+		return strings.TrimSpace(lines[0]), nil
+	}
+	return "", errors.Errorf("unknown cri: %s", cri)
+}
+
+// KubeletCgroupDriver returns the cgroup driver used by the kubelet running on the node,
+// as defined in the kubelet config written by kubeadm; this requires kubeadm init or join to be completed
+func (n *Node) KubeletCgroupDriver() (string, error) {
+	lines, err := n.Command("grep", "cgroupDriver:", kubeletConfigPath).Silent().RunAndCapture()
+	if err != nil || len(lines) == 0 {
+		// NB. cgroupfs is the kubelet default when cgroupDriver is not set
+		if n.Command("test", "-f", kubeletConfigPath).Silent().Run() == nil {
+			return CgroupfsCgroupDriver, nil
+		}
+		return "", errors.Errorf("kubelet config %s does not exist on node %s", kubeletConfigPath, n.Name())
+	}
+	return strings.Trim(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[0]), "cgroupDriver:")), `"`), nil
+}
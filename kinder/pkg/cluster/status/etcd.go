@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// stackedEtcdCertArgs defines the etcdctl flags for connecting to a stacked etcd member using the certificates
+// generated by kubeadm; NB. the flags are supported by etcdctl v3.4 or greater, that is used by all the
+// Kubernetes versions supported by kinder
+var stackedEtcdCertArgs = []string{
+	"--cacert=/etc/kubernetes/pki/etcd/ca.crt",
+	"--cert=/etc/kubernetes/pki/etcd/peer.crt",
+	"--key=/etc/kubernetes/pki/etcd/peer.key",
+}
+
+// EtcdMember defines an etcd cluster member
+type EtcdMember struct {
+	// ID is the member ID
+	ID uint64
+	// Name is the member name, that for stacked etcd is the name of the control-plane node
+	Name string
+	// PeerURLs are the URLs used by the member for communicating with the other members
+	PeerURLs []string
+	// ClientURLs are the URLs where the member serves client requests
+	ClientURLs []string
+	// IsLearner is true if the member is a non-voting learner
+	IsLearner bool
+	// Healthy is true if all the client URLs of the member are healthy
+	Healthy bool
+}
+
+// etcdMemberList defines the subset of the output of etcdctl member list -w json used by kinder
+type etcdMemberList struct {
+	Members []struct {
+		ID         uint64   `json:"ID"`
+		Name       string   `json:"name"`
+		PeerURLs   []string `json:"peerURLs"`
+		ClientURLs []string `json:"clientURLs"`
+		IsLearner  bool     `json:"isLearner"`
+	} `json:"members"`
+}
+
+// etcdEndpointHealth defines the subset of the output of etcdctl endpoint health -w json used by kinder
+type etcdEndpointHealth struct {
+	Endpoint string `json:"endpoint"`
+	Health   bool   `json:"health"`
+}
+
+// EtcdMembers returns the members of the etcd cluster together with their health.
+// In case of stacked etcd, etcdctl is executed inside the etcd static pod running on the bootstrap control-plane,
+// using the etcd certificates generated by kubeadm; in case of external etcd, etcdctl is executed on the external
+// etcd node, that is insecure.
+func (c *Cluster) EtcdMembers() ([]EtcdMember, error) {
+	var n *Node
+	var etcdctl []string
+	if etcd := c.ExternalEtcd(); etcd != nil {
+		n = etcd
+		etcdctl = []string{"etcdctl", "--endpoints=http://127.0.0.1:2379"}
+	} else {
+		n = c.BootstrapControlPlane()
+		etcdctl = append([]string{
+			"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "exec", "-n=kube-system", fmt.Sprintf("etcd-%s", n.Name()),
+			"--", "etcdctl", "--endpoints=https://127.0.0.1:2379",
+		}, stackedEtcdCertArgs...)
+	}
+
+	lines, err := n.Command(etcdctl[0], append(etcdctl[1:], "member", "list", "-w", "json")...).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the etcd members from node %s: %s", n.Name(), strings.Join(lines, "\n"))
+	}
+	members, err := parseEtcdMemberList(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	// NB. etcdctl endpoint health exits with an error when any endpoint is unhealthy, but it still prints the health
+	// of each endpoint, so the error is ignored and members are considered unhealthy if their health is not reported
+	lines, _ = n.Command(etcdctl[0], append(etcdctl[1:], "endpoint", "health", "--cluster", "-w", "json")...).Silent().RunAndCapture()
+	health := parseEtcdEndpointHealth(lines)
+	for i := range members {
+		members[i].Healthy = len(members[i].ClientURLs) > 0
+		for _, u := range members[i].ClientURLs {
+			if !health[u] {
+				members[i].Healthy = false
+			}
+		}
+	}
+
+	return members, nil
+}
+
+// parseEtcdMemberList parses the output of etcdctl member list -w json
+func parseEtcdMemberList(lines []string) ([]EtcdMember, error) {
+	list := etcdMemberList{}
+	if err := json.Unmarshal([]byte(jsonLine(lines, "{")), &list); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the etcd member list: %s", strings.Join(lines, "\n"))
+	}
+
+	members := []EtcdMember{}
+	for _, m := range list.Members {
+		members = append(members, EtcdMember{
+			ID:         m.ID,
+			Name:       m.Name,
+			PeerURLs:   m.PeerURLs,
+			ClientURLs: m.ClientURLs,
+			IsLearner:  m.IsLearner,
+		})
+	}
+	return members, nil
+}
+
+// parseEtcdEndpointHealth parses the output of etcdctl endpoint health -w json, and returns the health of each endpoint
+func parseEtcdEndpointHealth(lines []string) map[string]bool {
+	endpoints := []etcdEndpointHealth{}
+	health := map[string]bool{}
+	if err := json.Unmarshal([]byte(jsonLine(lines, "[")), &endpoints); err != nil {
+		return health
+	}
+	for _, e := range endpoints {
+		health[e.Endpoint] = e.Health
+	}
+	return health
+}
+
+// jsonLine returns the first line starting with the given prefix; this allows to skip warnings
+// mixed with the JSON output of etcdctl
+func jsonLine(lines []string, prefix string) string {
+	for _, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), prefix) {
+			return l
+		}
+	}
+	return ""
+}
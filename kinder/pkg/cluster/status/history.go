@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/exec"
+)
+
+// redactedStdin is the placeholder used in the command history for data streamed in input to the commands
+// that was not recorded
+const redactedStdin = "<REDACTED>"
+
+// shellSafe matches the words that can be used in a shell script without quoting
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// commandHistory defines the history of the commands run on a node
+type commandHistory struct {
+	sync.Mutex
+	commands []exec.RecordedCommand
+}
+
+// RecordCommandHistory instructs the node to record all the commands run on the node,
+// including silent and dry run commands, into the node's command history.
+func (n *Node) RecordCommandHistory() {
+	if n.history != nil {
+		return
+	}
+	n.history = &commandHistory{}
+
+	n.commandMutators = append(n.commandMutators,
+		func(c *exec.NodeCmd) *exec.NodeCmd {
+			return c.Record(func(r exec.RecordedCommand) {
+				n.history.Lock()
+				defer n.history.Unlock()
+				n.history.commands = append(n.history.commands, r)
+			})
+		},
+	)
+}
+
+// recordFileWrite adds a file written on the node by WriteFile to the node's command history, if recording;
+// the file content is never recorded, because it might contain secrets, e.g. certificates or kubeconfig files.
+func (n *Node) recordFileWrite(containerPath string, size int) {
+	if n.history == nil {
+		return
+	}
+	n.history.Lock()
+	defer n.history.Unlock()
+	n.history.commands = append(n.history.commands, exec.RecordedCommand{
+		Time:          time.Now(),
+		Node:          n.name,
+		Command:       "sh",
+		Args:          []string{"-c", "cat > " + shellQuote(containerPath)},
+		StdinRedacted: true,
+		StdinSize:     size,
+	})
+}
+
+// CommandHistory returns the commands recorded on the node, in execution order;
+// the history is empty if RecordCommandHistory was not called.
+func (n *Node) CommandHistory() []exec.RecordedCommand {
+	if n.history == nil {
+		return nil
+	}
+	n.history.Lock()
+	defer n.history.Unlock()
+	return append([]exec.RecordedCommand{}, n.history.commands...)
+}
+
+// DumpCommandHistory writes the commands recorded on all the nodes into w as a runnable shell script,
+// with commands from different nodes merged in execution order; this allows to turn a failed kinder run
+// into a manual reproduction. Silent and dry run commands are preceded by a comment, and data streamed
+// in input to the commands is piped from printf; redacted input is replaced by a placeholder, to be
+// replaced manually before running the script.
+func (c *Cluster) DumpCommandHistory(w io.Writer) error {
+	commands := []exec.RecordedCommand{}
+	for _, n := range c.AllNodes() {
+		commands = append(commands, n.CommandHistory()...)
+	}
+	sort.SliceStable(commands, func(i, j int) bool {
+		return commands[i].Time.Before(commands[j].Time)
+	})
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "#!/usr/bin/env bash")
+	fmt.Fprintf(&b, "# history of the commands run by kinder on the nodes of cluster %s\n", c.Name())
+	for _, r := range commands {
+		fmt.Fprintln(&b)
+
+		var notes []string
+		if r.Silent {
+			notes = append(notes, "silent")
+		}
+		if r.DryRun {
+			notes = append(notes, "dry run")
+		}
+		if r.StdinRedacted {
+			notes = append(notes, fmt.Sprintf("input of %d bytes redacted", r.StdinSize))
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, "# %s\n", strings.Join(notes, ", "))
+		}
+
+		args := []string{"docker", "exec"}
+		switch {
+		case r.StdinRedacted:
+			fmt.Fprintf(&b, "printf '%%s' %s | ", shellQuote(redactedStdin))
+			args = append(args, "-i")
+		case r.Stdin != nil:
+			fmt.Fprintf(&b, "printf '%%s' %s | ", shellQuote(string(r.Stdin)))
+			args = append(args, "-i")
+		}
+		args = append(args, r.Node, r.Command)
+		args = append(args, r.Args...)
+		for i := range args {
+			args[i] = shellQuote(args[i])
+		}
+		fmt.Fprintln(&b, strings.Join(args, " "))
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return errors.Wrap(err, "failed to write the command history")
+	}
+	return nil
+}
+
+// shellQuote returns s quoted for use as a single word in a shell script
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
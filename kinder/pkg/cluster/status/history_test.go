@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/exec"
+)
+
+func TestDumpCommandHistory(t *testing.T) {
+	n := &Node{name: "kind-control-plane"}
+	n.RecordCommandHistory()
+
+	largeInput := strings.Repeat("x", exec.MaxRecordedStdinSize+1)
+	for _, cmd := range []*exec.NodeCmd{
+		n.Command("kubectl", "apply", "-f", "-").Stdin(strings.NewReader("small input")),
+		n.Command("kubectl", "apply", "-f", "-").Stdin(strings.NewReader(largeInput)),
+	} {
+		if err := cmd.Silent().DryRun().Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n.recordFileWrite("/etc/kubernetes/pki/ca.key", 42)
+
+	c := &Cluster{name: "kind", allNodes: NodeList{n}}
+	var b bytes.Buffer
+	if err := c.DumpCommandHistory(&b); err != nil {
+		t.Fatal(err)
+	}
+	script := b.String()
+
+	for _, expected := range []string{
+		"printf '%s' 'small input' | docker exec -i kind-control-plane kubectl apply -f -\n",
+		"# silent, dry run, input of 4097 bytes redacted\nprintf '%s' '<REDACTED>' | docker exec -i kind-control-plane kubectl apply -f -\n",
+		"# input of 42 bytes redacted\nprintf '%s' '<REDACTED>' | docker exec -i kind-control-plane sh -c 'cat > /etc/kubernetes/pki/ca.key'\n",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("expected the command history to contain %q, got:\n%s", expected, script)
+		}
+	}
+	if strings.Contains(script, largeInput) {
+		t.Error("expected large input to be redacted")
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// controlPlaneContainers defines the containers of the control-plane static pods created by kubeadm;
+// etcd is not running on the control-plane nodes when using external etcd, so it is optional.
+var controlPlaneContainers = []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler", "etcd"}
+
+// crictlContainerStatus defines the subset of the crictl inspect JSON output used by kinder
+type crictlContainerStatus struct {
+	Status struct {
+		ImageRef string `json:"imageRef"`
+	} `json:"status"`
+}
+
+// crictlImageStatus defines the subset of the crictl inspecti JSON output used by kinder
+type crictlImageStatus struct {
+	Status struct {
+		ID          string   `json:"id"`
+		RepoDigests []string `json:"repoDigests"`
+	} `json:"status"`
+}
+
+// ControlPlaneImageDigests returns the digests of the images used by the running control-plane containers
+// on the node, by container name, e.g. kube-apiserver; etcd is included only if running on the node.
+// The digest is the registry digest of the image, e.g. sha256:..., so it can be compared with the digests
+// of a digest-pinned extract; for images without a registry digest, e.g. images loaded from a tarball,
+// the image ID is returned instead.
+// Containers are inspected using crictl, so this works with all the CRIs supported by kinder.
+func (n *Node) ControlPlaneImageDigests() (map[string]string, error) {
+	if !n.IsControlPlane() {
+		return nil, errors.Errorf("node %s is not a control-plane node", n.Name())
+	}
+
+	digests := map[string]string{}
+	for _, name := range controlPlaneContainers {
+		digest, err := n.crictlImageDigest(name)
+		if err != nil {
+			return nil, err
+		}
+		if digest == "" {
+			if name == "etcd" {
+				continue
+			}
+			return nil, errors.Errorf("container %s is not running on node %s", name, n.Name())
+		}
+		digests[name] = digest
+	}
+	return digests, nil
+}
+
+// crictlImageDigest returns the image digest of the running container with the given name using crictl;
+// if the container is not running, an empty digest is returned
+func (n *Node) crictlImageDigest(name string) (string, error) {
+	ids, err := n.Command(
+		"crictl", "ps", "--state=running", "--quiet", fmt.Sprintf("--name=^%s$", name),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the %s container on node %s", name, n.Name())
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+
+	lines, err := n.Command("crictl", "inspect", "--output=json", ids[0]).Silent().RunAndCapture()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to inspect the %s container on node %s", name, n.Name())
+	}
+	container := crictlContainerStatus{}
+	if err := json.Unmarshal([]byte(strings.Join(lines, "\n")), &container); err != nil {
+		return "", errors.Wrapf(err, "failed to parse the status of the %s container on node %s", name, n.Name())
+	}
+
+	ref, digest := parseImageRef(container.Status.ImageRef)
+	if digest != "" {
+		return digest, nil
+	}
+
+	lines, err = n.Command("crictl", "inspecti", "--output=json", ref).Silent().RunAndCapture()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to inspect the image of the %s container on node %s", name, n.Name())
+	}
+	image := crictlImageStatus{}
+	if err := json.Unmarshal([]byte(strings.Join(lines, "\n")), &image); err != nil {
+		return "", errors.Wrapf(err, "failed to parse the image status of the %s container on node %s", name, n.Name())
+	}
+	return imageDigest(image.Status.RepoDigests, image.Status.ID), nil
+}
+
+// parseImageRef parses the image reference of a container as reported by crictl, returning the image reference
+// without the scheme, if any, and the digest of the image reference if it is digest-pinned; e.g. with dockershim
+// docker-pullable://registry.k8s.io/kube-apiserver@sha256:... for images pulled from a registry,
+// or docker://sha256:... for images loaded from a tarball.
+func parseImageRef(imageRef string) (ref, digest string) {
+	ref = imageRef
+	if i := strings.Index(ref, "://"); i >= 0 {
+		ref = ref[i+len("://"):]
+	}
+	// NB. the image reference is already digest-pinned when the image was pulled from a registry
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		return ref, ref[i+1:]
+	}
+	return ref, ""
+}
+
+// imageDigest returns the digest of the first repo digest, e.g. registry.k8s.io/kube-apiserver@sha256:...,
+// or the image ID if there are no repo digests
+func imageDigest(repoDigests []string, id string) string {
+	for _, d := range repoDigests {
+		if i := strings.LastIndex(d, "@"); i >= 0 {
+			return d[i+1:]
+		}
+	}
+	return id
+}
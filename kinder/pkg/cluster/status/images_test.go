@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+)
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		name           string
+		imageRef       string
+		expectedRef    string
+		expectedDigest string
+	}{
+		{
+			name:           "containerd, image pulled from a registry",
+			imageRef:       "registry.k8s.io/kube-apiserver@sha256:0123",
+			expectedRef:    "registry.k8s.io/kube-apiserver@sha256:0123",
+			expectedDigest: "sha256:0123",
+		},
+		{
+			name:        "containerd, image loaded from a tarball",
+			imageRef:    "sha256:4567",
+			expectedRef: "sha256:4567",
+		},
+		{
+			name:           "dockershim, image pulled from a registry",
+			imageRef:       "docker-pullable://registry.k8s.io/kube-apiserver@sha256:0123",
+			expectedRef:    "registry.k8s.io/kube-apiserver@sha256:0123",
+			expectedDigest: "sha256:0123",
+		},
+		{
+			name:        "dockershim, image loaded from a tarball",
+			imageRef:    "docker://sha256:4567",
+			expectedRef: "sha256:4567",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ref, digest := parseImageRef(test.imageRef)
+			if ref != test.expectedRef || digest != test.expectedDigest {
+				t.Errorf("expected ref %q and digest %q, got %q and %q", test.expectedRef, test.expectedDigest, ref, digest)
+			}
+		})
+	}
+}
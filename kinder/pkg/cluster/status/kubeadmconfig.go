@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+// DumpKubeadmConfigs writes the kubeadm config of all the K8s nodes into w, as a single multi-document YAML
+// where each kubeadm config is preceded by a comment identifying the node and its role; this gives a single
+// reproducible artifact of the kubeadm configs used across the cluster.
+// Nodes without a kubeadm config, e.g. because kubeadm-config was not executed yet, are reported with a comment.
+func (c *Cluster) DumpKubeadmConfigs(w io.Writer) error {
+	for i, n := range c.K8sNodes() {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w, "---"); err != nil {
+				return errors.Wrap(err, "failed to write the kubeadm configs")
+			}
+		}
+
+		header := fmt.Sprintf("# node: %s, role: %s\n", n.Name(), n.Role())
+		lines, err := n.Command("cat", constants.KubeadmConfigPath).Silent().RunAndCapture()
+		if err != nil {
+			header += fmt.Sprintf("# %s not found\n", constants.KubeadmConfigPath)
+			lines = nil
+		}
+
+		// NB. the leading document separator, if any, is removed so the header is attached to the first document
+		config := strings.TrimPrefix(strings.Join(lines, "\n"), "---\n")
+		if _, err := fmt.Fprintf(w, "%s%s\n", header, config); err != nil {
+			return errors.Wrap(err, "failed to write the kubeadm configs")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
+)
+
+// KubeletConfiguration defines the subset of the kubelet config that is relevant for asserting on
+// the KubeletConfiguration generated by kubeadm, including patches.
+// NB. kinder does not depend on k8s.io/kubelet, so the KubeletConfiguration type is not used here.
+type KubeletConfiguration = kubeadm.KubeletConfiguration
+
+// EffectiveKubeletConfig reads and parses the kubelet config written by kubeadm on the node,
+// e.g. for verifying that a KubeletConfiguration patch took effect after kubeadm init or join
+func (n *Node) EffectiveKubeletConfig() (*KubeletConfiguration, error) {
+	lines, err := n.Command("cat", kubeletConfigPath).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the kubelet config %s on node %s", kubeletConfigPath, n.Name())
+	}
+
+	config := &KubeletConfiguration{}
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), config); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the kubelet config %s on node %s", kubeletConfigPath, n.Name())
+	}
+	if config.Kind != "KubeletConfiguration" {
+		return nil, errors.Errorf("%s on node %s is not a KubeletConfiguration, kind is %q", kubeletConfigPath, n.Name(), config.Kind)
+	}
+	return config, nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// staticPodManifestsDir defines the folder where kubeadm writes static pod manifests
+const staticPodManifestsDir = "/etc/kubernetes/manifests"
+
+// StaticPod defines the subset of a static pod manifest that is relevant for asserting on
+// the control-plane components generated by kubeadm.
+// NB. kinder does not depend on k8s.io/api, so the Pod type is not used here.
+type StaticPod struct {
+	Metadata StaticPodMetadata `json:"metadata"`
+	Spec     StaticPodSpec     `json:"spec"`
+}
+
+// StaticPodMetadata defines the metadata of a static pod
+type StaticPodMetadata struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// StaticPodSpec defines the spec of a static pod
+type StaticPodSpec struct {
+	Containers  []StaticPodContainer `json:"containers"`
+	Volumes     []StaticPodVolume    `json:"volumes,omitempty"`
+	HostNetwork bool                 `json:"hostNetwork,omitempty"`
+}
+
+// StaticPodContainer defines a container in a static pod
+type StaticPodContainer struct {
+	Name         string                 `json:"name"`
+	Image        string                 `json:"image"`
+	Command      []string               `json:"command,omitempty"`
+	Args         []string               `json:"args,omitempty"`
+	VolumeMounts []StaticPodVolumeMount `json:"volumeMounts,omitempty"`
+}
+
+// StaticPodVolumeMount defines a volume mount in a static pod container
+type StaticPodVolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+// StaticPodVolume defines a volume in a static pod; only hostPath volumes are used by kubeadm
+type StaticPodVolume struct {
+	Name     string                   `json:"name"`
+	HostPath *StaticPodHostPathVolume `json:"hostPath,omitempty"`
+}
+
+// StaticPodHostPathVolume defines a hostPath volume in a static pod
+type StaticPodHostPathVolume struct {
+	Path string `json:"path"`
+	Type string `json:"type,omitempty"`
+}
+
+// Container returns the container with the given name, if any
+func (p *StaticPod) Container(name string) *StaticPodContainer {
+	for i := range p.Spec.Containers {
+		if p.Spec.Containers[i].Name == name {
+			return &p.Spec.Containers[i]
+		}
+	}
+	return nil
+}
+
+// Flag returns the value of a --flag=value flag passed to the container command, if any
+func (c *StaticPodContainer) Flag(name string) (string, bool) {
+	prefix := fmt.Sprintf("--%s=", name)
+	for _, arg := range append(c.Command, c.Args...) {
+		if strings.HasPrefix(arg, prefix) {
+			return strings.TrimPrefix(arg, prefix), true
+		}
+	}
+	return "", false
+}
+
+// ReadStaticPodManifest reads and parses the static pod manifest with the given name,
+// e.g. kube-apiserver, from the /etc/kubernetes/manifests folder on the node
+func (n *Node) ReadStaticPodManifest(name string) (*StaticPod, error) {
+	path := fmt.Sprintf("%s/%s.yaml", staticPodManifestsDir, name)
+
+	// check the manifest exists, so it is possible to return a clear error
+	if err := n.Command("test", "-f", path).Silent().Run(); err != nil {
+		return nil, errors.Errorf("static pod manifest %s does not exist on node %s", path, n.Name())
+	}
+
+	lines, err := n.Command("cat", path).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the static pod manifest %s on node %s", path, n.Name())
+	}
+
+	return parseStaticPodManifest(path, lines)
+}
+
+// parseStaticPodManifest parses the lines of a static pod manifest
+func parseStaticPodManifest(path string, lines []string) (*StaticPod, error) {
+	pod := &StaticPod{}
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), pod); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the static pod manifest %s", path)
+	}
+	if pod.Metadata.Name == "" {
+		return nil, errors.Errorf("static pod manifest %s does not define a pod name", path)
+	}
+	return pod, nil
+}
@@ -32,9 +32,13 @@ import (
 	"k8s.io/kubeadm/kinder/pkg/cri/host"
 	"k8s.io/kubeadm/kinder/pkg/exec"
 	"k8s.io/kubeadm/kinder/pkg/exec/colors"
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
 	ksigsyaml "sigs.k8s.io/yaml"
 )
 
+// caCertPath defines the path of the cluster CA certificate generated by kubeadm
+const caCertPath = "/etc/kubernetes/pki/ca.crt"
+
 // commandMutator define a function that can mutate commands on a node.
 // It is used to inject behaviours that should apply to all the command
 // executed on a node, like e.g. DryRun
@@ -49,9 +53,11 @@ type Node struct {
 	ipv4            string
 	ipv6            string
 	cri             ContainerRuntime
+	cgroupDriver    string
 	etcdImage       string
 	skip            bool
 	commandMutators []commandMutator
+	history         *commandHistory
 }
 
 // NodeSettings defines a set of settings that will be stored in the node and re-used
@@ -61,7 +67,9 @@ type Node struct {
 // and actions for setting up a working cluster can happen at different time
 // (while in kind everything happen within an atomic operation).
 type NodeSettings struct {
-	// NB Currently there are no persistent node settings used by kind, but we are preserving this feature for future changes
+	// CRI is the container runtime selected for the node at create time, e.g. for creating clusters with nodes
+	// using different container runtimes; if empty, the container runtime is detected from the node
+	CRI ContainerRuntime `json:"cri,omitempty"`
 }
 
 // NewNode returns a new kinder.Node wrapper
@@ -253,29 +261,25 @@ func (n *Node) WriteClusterSettings(settings *ClusterSettings) error {
 }
 
 // ReadClusterSettings reads from the node a set of cluster-wide settings that
-// are going to be re-used by kinder during the cluster lifecycle (after create)
+// are going to be re-used by kinder during the cluster lifecycle (after create);
+// if the settings were never written, e.g. because they are not written at create time, empty settings are returned
 func (n *Node) ReadClusterSettings() (*ClusterSettings, error) {
-	/*
-		Temporarily disabled because we are observing flakes related to this operation
-
-			lines, err := n.Command(
-				"cat", clusterSettingsPath,
-			).Silent().RunAndCapture()
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to read %s", clusterSettingsPath)
-			}
-
-			var settings ClusterSettings
-			err = ksigsyaml.Unmarshal([]byte(strings.Join(lines, "\n")), &settings)
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to decode %s", clusterSettingsPath)
-			}
-
-			return &settings, nil
-	*/
-	return &ClusterSettings{
-		IPFamily: IPv4Family,
-	}, nil
+	// NB. a missing settings file is not an error, because kinder writes settings only when required
+	lines, err := n.Command(
+		"sh", "-c", fmt.Sprintf("if [ -f %[1]s ]; then cat %[1]s; fi", clusterSettingsPath),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", clusterSettingsPath)
+	}
+
+	var settings ClusterSettings
+	err = ksigsyaml.Unmarshal([]byte(strings.Join(lines, "\n")), &settings)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s", clusterSettingsPath)
+	}
+
+	// NB. if the IP family is not set, it is detected from the kubeadm config by Cluster.ReadSettings
+	return &settings, nil
 }
 
 const nodeSettingsPath = "/kinder/node-settings.yaml"
@@ -323,12 +327,19 @@ func (n *Node) ReadNodeSettings() (*NodeSettings, error) {
 }
 
 // CRI returns the ContainerRuntime installed on the node and that
-// should be used by kubeadm for creating the K8s cluster
+// should be used by kubeadm for creating the K8s cluster; the container runtime selected
+// for the node at create time, if any, takes precedence over the detected one.
 func (n *Node) CRI() (cri ContainerRuntime, err error) {
 	if n.cri != "" {
 		return n.cri, nil
 	}
 
+	// NB. node settings exist only if written at create time, so errors are ignored
+	if settings, err := n.ReadNodeSettings(); err == nil && settings.CRI != "" {
+		n.cri = settings.CRI
+		return n.cri, nil
+	}
+
 	n.cri, err = InspectCRIinContainer(n.Name())
 	if err != nil {
 		return "", err
@@ -430,6 +441,7 @@ func (n *Node) WriteFile(containerPath string, contents []byte) error {
 	}
 
 	// Copy the temporary file to the container
+	n.recordFileWrite(containerPath, len(contents))
 	if err := n.CopyTo(tmpPath, containerPath); err != nil {
 		return errors.Wrapf(err, "failed to write %s", containerPath)
 	}
@@ -464,3 +476,17 @@ func (n *Node) MustKubeVersion() *K8sVersion.Version {
 
 	return sv
 }
+
+// CACertHash returns the hash of the cluster CA certificate on the node, in the format used by kubeadm for
+// --discovery-token-ca-cert-hash, e.g. sha256:...
+func (n *Node) CACertHash() (string, error) {
+	lines, err := n.Command("cat", caCertPath).Silent().RunAndCapture()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %s from node %s", caCertPath, n.Name())
+	}
+	hash, err := kubeadm.CACertHash([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid CA certificate %s on node %s", caCertPath, n.Name())
+	}
+	return hash, nil
+}
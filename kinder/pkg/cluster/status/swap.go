@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// swapFilePath defines the path of the swap file created by kinder on the node.
+// NB. the swap file is created in /var, because /var is a volume in kind(er) nodes, while swap files
+// are not supported on the overlay filesystem of the node container
+const swapFilePath = "/var/swapfile"
+
+// SwapDevice defines a swap device or swap file active on a node, as listed in /proc/swaps
+type SwapDevice struct {
+	// Filename is the path of the swap device or swap file
+	Filename string
+	// Type is the type of the swap, e.g. file or partition
+	Type string
+	// SizeKB is the size of the swap in KB
+	SizeKB int64
+	// UsedKB is the swap in use in KB
+	UsedKB int64
+}
+
+// SwapStatus defines the swap status of a node, including the swap settings of the kubelet
+type SwapStatus struct {
+	// Devices are the swap devices and swap files active on the node.
+	// NB. swap is a kernel setting, so the swap devices are shared by all the nodes running on the same host
+	Devices []SwapDevice
+	// KubeletFailSwapOn is the failSwapOn setting of the kubelet; it is nil if the kubelet config
+	// was not written yet or if the setting is not set
+	KubeletFailSwapOn *bool
+	// KubeletSwapBehavior is the memorySwap.swapBehavior setting of the kubelet; it is empty if the kubelet
+	// config was not written yet or if the kubelet default is used
+	KubeletSwapBehavior string
+}
+
+// Enabled returns true if at least one swap device or swap file is active
+func (s *SwapStatus) Enabled() bool {
+	return len(s.Devices) > 0
+}
+
+// EnableSwap creates a swap file of the given size on the node and activates it, e.g. for testing NodeSwap scenarios.
+// Please note that swap is a kernel setting, so the swap file is used by all the nodes running on the same host,
+// and it should be deactivated with DisableSwap before deleting the node.
+func (n *Node) EnableSwap(sizeMB int) error {
+	if sizeMB <= 0 {
+		return errors.Errorf("invalid swap size %dMB, it must be greater than 0", sizeMB)
+	}
+
+	swap, err := n.SwapStatus()
+	if err != nil {
+		return err
+	}
+	for _, d := range swap.Devices {
+		if d.Filename == swapFilePath {
+			return errors.Errorf("swap file %s is already active on node %s", swapFilePath, n.Name())
+		}
+	}
+
+	n.Infof("Enabling %dMB of swap", sizeMB)
+	for _, args := range [][]string{
+		{"dd", "if=/dev/zero", fmt.Sprintf("of=%s", swapFilePath), "bs=1M", fmt.Sprintf("count=%d", sizeMB)},
+		{"chmod", "0600", swapFilePath},
+		{"mkswap", swapFilePath},
+		{"swapon", swapFilePath},
+	} {
+		if lines, err := n.Command(args[0], args[1:]...).Silent().RunAndCapture(); err != nil {
+			return errors.Wrapf(err, "failed to enable swap on node %s: %s", n.Name(), strings.Join(lines, "\n"))
+		}
+	}
+	return nil
+}
+
+// DisableSwap deactivates and removes the swap file created by EnableSwap on the node;
+// it is safe to call DisableSwap on nodes without the swap file.
+func (n *Node) DisableSwap() error {
+	swap, err := n.SwapStatus()
+	if err != nil {
+		return err
+	}
+	for _, d := range swap.Devices {
+		if d.Filename != swapFilePath {
+			continue
+		}
+		n.Infof("Disabling swap")
+		if lines, err := n.Command("swapoff", swapFilePath).Silent().RunAndCapture(); err != nil {
+			return errors.Wrapf(err, "failed to disable swap on node %s: %s", n.Name(), strings.Join(lines, "\n"))
+		}
+	}
+
+	if lines, err := n.Command("rm", "-f", swapFilePath).Silent().RunAndCapture(); err != nil {
+		return errors.Wrapf(err, "failed to remove %s on node %s: %s", swapFilePath, n.Name(), strings.Join(lines, "\n"))
+	}
+	return nil
+}
+
+// SwapStatus returns the swap devices active on the node and the swap settings of the kubelet,
+// e.g. for asserting on the kubelet swap behavior after enabling swap
+func (n *Node) SwapStatus() (*SwapStatus, error) {
+	lines, err := n.Command("cat", "/proc/swaps").Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read /proc/swaps on node %s: %s", n.Name(), strings.Join(lines, "\n"))
+	}
+
+	devices, err := parseProcSwaps(lines)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse /proc/swaps on node %s", n.Name())
+	}
+	swap := &SwapStatus{Devices: devices}
+
+	// NB. the kubelet config is written by kubeadm init or join, so it could not exist yet
+	config, err := n.EffectiveKubeletConfig()
+	if err != nil {
+		log.Debugf("kubelet swap settings on node %s are not available: %v", n.Name(), err)
+		return swap, nil
+	}
+	swap.KubeletFailSwapOn = config.FailSwapOn
+	swap.KubeletSwapBehavior = config.MemorySwap.SwapBehavior
+	return swap, nil
+}
+
+// parseProcSwaps parses the content of /proc/swaps, e.g.
+//
+//	Filename        Type   Size     Used  Priority
+//	/var/swapfile   file   1048572  0     -2
+func parseProcSwaps(lines []string) ([]SwapDevice, error) {
+	devices := []SwapDevice{}
+	for i, l := range lines {
+		fields := strings.Fields(l)
+		if i == 0 || len(fields) == 0 {
+			// skip the header and empty lines
+			continue
+		}
+		if len(fields) < 4 {
+			return nil, errors.Errorf("unexpected line %q", l)
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid size in line %q", l)
+		}
+		used, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid used size in line %q", l)
+		}
+		devices = append(devices, SwapDevice{
+			Filename: fields[0],
+			Type:     fields[1],
+			SizeKB:   size,
+			UsedKB:   used,
+		})
+	}
+	return devices, nil
+}
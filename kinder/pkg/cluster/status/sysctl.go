@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// sysctlDropInPath defines the path of the sysctl drop-in file written by kinder,
+// so sysctls are preserved if the node is restarted
+const sysctlDropInPath = "/etc/sysctl.d/99-kinder.conf"
+
+// KubeadmSysctls defines the sysctls checked by the kubeadm preflight checks
+var KubeadmSysctls = map[string]string{
+	"net.bridge.bridge-nf-call-iptables": "1",
+	"net.ipv4.ip_forward":                "1",
+}
+
+// EnsureSysctls sets the given sysctls on the node, e.g. KubeadmSysctls, and writes them into
+// a sysctl drop-in file, so they are preserved if the node is restarted;
+// each sysctl is read back after being set, and an error is returned if the value does not match
+func (n *Node) EnsureSysctls(sysctls map[string]string) error {
+	keys := make([]string, 0, len(sysctls))
+	for k := range sysctls {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var dropIn strings.Builder
+	for _, k := range keys {
+		v := sysctls[k]
+		if lines, err := n.Command("sysctl", "-w", fmt.Sprintf("%s=%s", k, v)).Silent().RunAndCapture(); err != nil {
+			return errors.Wrapf(err, "failed to set sysctl %s on node %s: %s", k, n.Name(), strings.Join(lines, "\n"))
+		}
+
+		actual, err := n.ReadSysctl(k)
+		if err != nil {
+			return err
+		}
+		if actual != v {
+			return errors.Errorf("sysctl %s on node %s is %q, expected %q", k, n.Name(), actual, v)
+		}
+
+		fmt.Fprintf(&dropIn, "%s = %s\n", k, v)
+	}
+
+	if err := n.WriteFile(sysctlDropInPath, []byte(dropIn.String())); err != nil {
+		return errors.Wrapf(err, "failed to write %s on node %s", sysctlDropInPath, n.Name())
+	}
+	return nil
+}
+
+// ReadSysctl returns the value of a sysctl on the node; multiple values, e.g. for net.ipv4.ip_local_port_range,
+// are separated by a single space
+func (n *Node) ReadSysctl(key string) (string, error) {
+	lines, err := n.Command("sysctl", "-n", key).Silent().RunAndCapture()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read sysctl %s on node %s: %s", key, n.Name(), strings.Join(lines, "\n"))
+	}
+	if len(lines) != 1 {
+		return "", errors.Errorf("failed to read sysctl %s on node %s: unexpected output %q", key, n.Name(), strings.Join(lines, "\n"))
+	}
+	return strings.Join(strings.Fields(lines[0]), " "), nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// systemdStatusLogLines defines the number of recent log lines included in UnitStatus
+const systemdStatusLogLines = 20
+
+// UnitStatus defines the status of a systemd unit running on a node
+type UnitStatus struct {
+	// Unit is the name of the systemd unit, e.g. kubelet
+	Unit string
+	// LoadState reflects whether the unit definition was properly loaded, e.g. loaded, not-found
+	LoadState string
+	// ActiveState is the high-level unit activation state, e.g. active, inactive, failed
+	ActiveState string
+	// SubState is the low-level unit activation state, e.g. running, exited, dead
+	SubState string
+	// MainPID is the PID of the main process of the unit, if running
+	MainPID int
+	// Logs contains the recent log lines of the unit
+	Logs []string
+}
+
+// IsActive returns true if the systemd unit is active
+func (s *UnitStatus) IsActive() bool {
+	return s.ActiveState == "active"
+}
+
+// IsFailed returns true if the systemd unit is failed
+func (s *UnitStatus) IsFailed() bool {
+	return s.ActiveState == "failed"
+}
+
+// SystemdStatus returns the status of a systemd unit running on the node, e.g. kubelet
+func (n *Node) SystemdStatus(unit string) (*UnitStatus, error) {
+	lines, err := n.Command(
+		"systemctl", "show", unit, "--no-pager", "--property=LoadState,ActiveState,SubState,MainPID",
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the status of the %s unit", unit)
+	}
+
+	status, err := parseSystemctlShow(unit, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := n.Command(
+		"journalctl", "--unit", unit, "--no-pager", "--lines", strconv.Itoa(systemdStatusLogLines),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the logs of the %s unit", unit)
+	}
+	status.Logs = logs
+
+	return status, nil
+}
+
+// parseSystemctlShow parses the key=value output of systemctl show
+func parseSystemctlShow(unit string, lines []string) (*UnitStatus, error) {
+	status := &UnitStatus{Unit: unit}
+	for _, l := range lines {
+		kv := strings.SplitN(strings.TrimSpace(l), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "LoadState":
+			status.LoadState = kv[1]
+		case "ActiveState":
+			status.ActiveState = kv[1]
+		case "SubState":
+			status.SubState = kv[1]
+		case "MainPID":
+			pid, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid MainPID %q for the %s unit", kv[1], unit)
+			}
+			status.MainPID = pid
+		}
+	}
+
+	if status.ActiveState == "" {
+		return nil, errors.Errorf("failed to read the ActiveState of the %s unit", unit)
+	}
+
+	return status, nil
+}
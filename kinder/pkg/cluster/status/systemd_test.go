@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSystemctlShow(t *testing.T) {
+	tests := []struct {
+		name           string
+		lines          []string
+		expectedStatus *UnitStatus
+		expectedError  bool
+	}{
+		{
+			name:  "valid: running unit",
+			lines: []string{"LoadState=loaded", "ActiveState=active", "SubState=running", "MainPID=1234"},
+			expectedStatus: &UnitStatus{
+				Unit: "kubelet", LoadState: "loaded", ActiveState: "active", SubState: "running", MainPID: 1234,
+			},
+		},
+		{
+			name:  "valid: failed unit, with blank and unknown lines",
+			lines: []string{"", "  LoadState=loaded  ", "ActiveState=failed", "SubState=failed", "MainPID=0", "Foo=bar", "garbage"},
+			expectedStatus: &UnitStatus{
+				Unit: "kubelet", LoadState: "loaded", ActiveState: "failed", SubState: "failed",
+			},
+		},
+		{
+			name:  "valid: unit not found",
+			lines: []string{"LoadState=not-found", "ActiveState=inactive", "SubState=dead", "MainPID=0"},
+			expectedStatus: &UnitStatus{
+				Unit: "kubelet", LoadState: "not-found", ActiveState: "inactive", SubState: "dead",
+			},
+		},
+		{
+			name:          "invalid: MainPID is not a number",
+			lines:         []string{"ActiveState=active", "MainPID=abc"},
+			expectedError: true,
+		},
+		{
+			name:          "invalid: ActiveState is missing",
+			lines:         []string{"LoadState=loaded", "SubState=running"},
+			expectedError: true,
+		},
+		{
+			name:          "invalid: empty output",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, err := parseSystemctlShow("kubelet", test.lines)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if !reflect.DeepEqual(status, test.expectedStatus) {
+				t.Errorf("expected status %+v, got %+v", test.expectedStatus, status)
+			}
+		})
+	}
+}
@@ -77,6 +77,47 @@ func GetArchiveTags(path string) ([]string, error) {
 	return res, nil
 }
 
+// GetArchiveConfigDigest obtains the digest of the image config, i.e. the image ID, of the image
+// with the given "repo:tag" tag from a given docker image archive (tarball) path; if the archive
+// contains a single image, the config digest of that image is returned regardless of the tag.
+// https://github.com/moby/moby/blob/master/image/spec/v1.2.md
+func GetArchiveConfigDigest(path, tag string) (string, error) {
+	// open the archive and find the manifest entry
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return "", errors.New("could not find image manifest")
+		}
+		if err != nil {
+			return "", err
+		}
+		if hdr.Name == "manifest.json" {
+			break
+		}
+	}
+	// read and parse the manifest
+	b, err := io.ReadAll(tr)
+	if err != nil {
+		return "", err
+	}
+	var entries []metadataEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if len(entries) == 1 || containsTag(entry.RepoTags, tag) {
+			return configDigest(entry.Config)
+		}
+	}
+	return "", fmt.Errorf("could not find image %s in the image manifest", tag)
+}
+
 // EditArchiveRepositories applies edit to reader's image repositories,
 // IE the repository part of repository:tag in image tags
 // This supports v1 / v1.1 / v1.2 Docker Image Archives
@@ -185,6 +226,26 @@ func editManifestRepositories(raw []byte, editRepositories func(string) string)
 	return json.Marshal(entries)
 }
 
+// returns true if tags contains the given tag
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the digest of the image config from its path in the archive,
+// e.g. <hex>.json (v1.2) or blobs/sha256/<hex> (OCI layout)
+func configDigest(config string) (string, error) {
+	hex := strings.TrimSuffix(config[strings.LastIndex(config, "/")+1:], ".json")
+	if len(hex) != 64 {
+		return "", fmt.Errorf("invalid image config %q", config)
+	}
+	return "sha256:" + hex, nil
+}
+
 // returns repository:tag:ref
 func parseRepositories(data []byte) (archiveRepositories, error) {
 	var repoTags archiveRepositories
@@ -20,6 +20,7 @@ import (
 	"github.com/pkg/errors"
 
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/containerd"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/docker"
 )
@@ -57,3 +58,15 @@ func (h *ActionHelper) GetImages(n *status.Node) ([]string, error) {
 	}
 	return nil, errors.Errorf("unknown cri: %s", h.cri)
 }
+
+// ContainerStats returns a snapshot of the resource usage of a running container in the node, e.g. kube-apiserver;
+// sampling stats before and after an operation allows to measure the resources consumed during the operation
+func (h *ActionHelper) ContainerStats(n *status.Node, name string) (*common.ContainerStats, error) {
+	switch h.cri {
+	case status.ContainerdRuntime:
+		return containerd.ContainerStats(n, name)
+	case status.DockerRuntime:
+		return docker.ContainerStats(n, name)
+	}
+	return nil, errors.Errorf("unknown cri: %s", h.cri)
+}
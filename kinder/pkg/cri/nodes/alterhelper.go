@@ -62,11 +62,15 @@ func (h *AlterHelper) StartCRI(bc *bits.BuildContext) error {
 }
 
 // SetupCRI setups the container runtime.
-func (h *AlterHelper) SetupCRI(bc *bits.BuildContext) error {
+// The containerdConfig fragment, if any, is merged into the containerd config file; it is not supported for docker.
+func (h *AlterHelper) SetupCRI(bc *bits.BuildContext, containerdConfig string) error {
 	switch h.cri {
 	case status.ContainerdRuntime:
-		return containerd.SetupRuntime(bc)
+		return containerd.SetupRuntime(bc, containerdConfig)
 	case status.DockerRuntime:
+		if containerdConfig != "" {
+			return errors.Errorf("a containerd config fragment can't be used with cri: %s", h.cri)
+		}
 		return docker.SetupRuntime(bc)
 	}
 	return errors.Errorf("unknown cri: %s", h.cri)
@@ -0,0 +1,27 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+// ContainerStats defines a snapshot of the resource usage of a container running inside a kind(er) node
+type ContainerStats struct {
+	// CPUUsageCoreNanoSeconds is the cumulative CPU time consumed by the container, in nanoseconds;
+	// the CPU usage in a time interval is the difference between two snapshots
+	CPUUsageCoreNanoSeconds uint64
+
+	// MemoryWorkingSetBytes is the memory used by the container that can't be reclaimed, in bytes
+	MemoryWorkingSetBytes uint64
+}
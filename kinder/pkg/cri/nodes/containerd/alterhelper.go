@@ -49,11 +49,41 @@ func GetAlterContainerArgs() ([]string, []string) {
 	return runArgs, runCommands
 }
 
-// SetupRuntime setups the runtime
-func SetupRuntime(bc *bits.BuildContext) error {
+// SetupRuntime setups the runtime; if configFragment is not empty, it is merged into the containerd config file
+func SetupRuntime(bc *bits.BuildContext, configFragment string) error {
 	if err := setupCRISandboxImage(bc); err != nil {
 		return err
 	}
+	if configFragment != "" {
+		if err := setupConfigFragment(bc, configFragment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setupConfigFragment merges a config fragment into the containerd config file;
+// if the containerd config file does not exist, it is created.
+func setupConfigFragment(bc *bits.BuildContext, configFragment string) error {
+	tmpConfigFileName := "containerd-config-fragment.toml"
+	tmpConfigFileInContainer := filepath.Join(bc.ContainerBasePath(), tmpConfigFileName)
+	tmpConfigFileOnHost := filepath.Join(bc.HostBasePath(), tmpConfigFileName)
+	defer os.Remove(tmpConfigFileOnHost)
+
+	cmd := fmt.Sprintf(`if [ -f %[1]s ]; then cp %[1]s %[2]s; else touch %[2]s; fi && chmod 0666 %[2]s`, config.DefaultConfigPath, tmpConfigFileInContainer)
+	out, err := bc.CombinedOutputLinesInContainer("bash", "-c", cmd)
+	if err != nil {
+		return errors.Wrapf(err, "failed to execute command %q, output %v", cmd, out)
+	}
+
+	log.Infof("merging the containerd config fragment into the config file %s", config.DefaultConfigPath)
+	if err := config.MergeConfigFragment(tmpConfigFileOnHost, configFragment); err != nil {
+		return errors.Wrap(err, "failed to merge the containerd config fragment")
+	}
+	if err := bc.RunInContainer("bash", "-c", fmt.Sprintf("mkdir -p %s && cp %s %s", filepath.Dir(config.DefaultConfigPath), tmpConfigFileInContainer, config.DefaultConfigPath)); err != nil {
+		log.Errorf("failed to copy %s into %s, error: %v", tmpConfigFileInContainer, config.DefaultConfigPath, err)
+		return err
+	}
 	return nil
 }
 
@@ -74,3 +74,59 @@ func SetCRISandboxImage(path string, sandboxImage string) error {
 
 	return nil
 }
+
+// ValidateConfigFragment checks that a containerd config fragment is valid TOML.
+func ValidateConfigFragment(fragment string) error {
+	if _, err := toml.Load(fragment); err != nil {
+		return errors.Wrap(err, "invalid containerd config fragment")
+	}
+	return nil
+}
+
+// MergeConfigFragment merges a containerd config fragment into the containerd config file.
+// Tables are merged recursively, while any other value in the fragment, including arrays,
+// replaces the corresponding value in the config file.
+func MergeConfigFragment(path string, fragment string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	tree, err := toml.LoadFile(path)
+	if err != nil {
+		return err
+	}
+
+	fragmentTree, err := toml.Load(fragment)
+	if err != nil {
+		return errors.Wrap(err, "invalid containerd config fragment")
+	}
+
+	mergeTree(tree, fragmentTree)
+
+	data, err := tree.ToTomlString()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(data), 0666); err != nil {
+		return errors.Errorf("failed to write to config file %s, error: %v", path, err)
+	}
+
+	return nil
+}
+
+// mergeTree merges src into dst.
+// NB. keys are accessed by path, because table names like "io.containerd.grpc.v1.cri" contain dots
+func mergeTree(dst, src *toml.Tree) {
+	for _, key := range src.Keys() {
+		path := []string{key}
+		srcValue := src.GetPath(path)
+		if srcTree, ok := srcValue.(*toml.Tree); ok {
+			if dstTree, ok := dst.GetPath(path).(*toml.Tree); ok {
+				mergeTree(dstTree, srcTree)
+				continue
+			}
+		}
+		dst.SetPath(path, srcValue)
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"testing"
 
 	"path/filepath"
+
+	"github.com/pelletier/go-toml"
 )
 
 func TestGetCRISandboxImage(t *testing.T) {
@@ -143,3 +145,76 @@ func TestSetCRISandboxImage(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeConfigFragment(t *testing.T) {
+	data := `version = 2
+[plugins."io.containerd.grpc.v1.cri"]
+  sandbox_image = "registry.k8s.io/pause:3.7"
+  [plugins."io.containerd.grpc.v1.cri".containerd]
+    snapshotter = "overlayfs"
+`
+	tempDir := t.TempDir()
+
+	var tests = []struct {
+		name          string
+		fragment      string
+		path          []string
+		expectedValue string
+		expectedError bool
+	}{
+		{
+			name:          "invalid fragment",
+			fragment:      `[plugins."io.containerd.grpc.v1.cri"`,
+			expectedError: true,
+		},
+		{
+			name: "fragment overrides an existing value",
+			fragment: `[plugins."io.containerd.grpc.v1.cri".containerd]
+  snapshotter = "native"
+`,
+			path:          []string{"plugins", "io.containerd.grpc.v1.cri", "containerd", "snapshotter"},
+			expectedValue: "native",
+		},
+		{
+			name: "fragment adds a new table",
+			fragment: `[plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+  endpoint = ["http://mirror.local:5000"]
+`,
+			path:          []string{"plugins", "io.containerd.grpc.v1.cri", "containerd", "snapshotter"},
+			expectedValue: "overlayfs",
+		},
+	}
+
+	for i, rt := range tests {
+		t.Run(rt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, fmt.Sprintf("config-%d.toml", i))
+			if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+				t.Fatalf("couldn't write to file %s: %v", path, err)
+			}
+
+			err := MergeConfigFragment(path, rt.fragment)
+			if (err != nil) != rt.expectedError {
+				t.Errorf("failed MergeConfigFragment:\n\texpected error: %t\n\tactual error: %v", rt.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			sandboxImage, err := GetCRISandboxImage(path)
+			if err != nil {
+				t.Fatalf("failed to get sandbox image from config file %s: %v", path, err)
+			}
+			if sandboxImage != "registry.k8s.io/pause:3.7" {
+				t.Errorf("failed MergeConfigFragment: sandbox image not preserved, got %s", sandboxImage)
+			}
+
+			tree, err := toml.LoadFile(path)
+			if err != nil {
+				t.Fatalf("failed to load config file %s: %v", path, err)
+			}
+			if value, _ := tree.GetPath(rt.path).(string); value != rt.expectedValue {
+				t.Errorf("failed MergeConfigFragment:\n\texpected value: %s\n\tactual value: %v", rt.expectedValue, tree.GetPath(rt.path))
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package containerd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
+)
+
+// crictlStats defines the subset of the crictl stats JSON output used by kinder
+type crictlStats struct {
+	Stats []struct {
+		CPU struct {
+			UsageCoreNanoSeconds crictlUInt64Value `json:"usageCoreNanoSeconds"`
+		} `json:"cpu"`
+		Memory struct {
+			WorkingSetBytes crictlUInt64Value `json:"workingSetBytes"`
+		} `json:"memory"`
+	} `json:"stats"`
+}
+
+// crictlUInt64Value defines an uint64 value in the crictl stats JSON output;
+// NB. values are encoded as strings, according to the protobuf JSON mapping for uint64
+type crictlUInt64Value struct {
+	Value json.Number `json:"value"`
+}
+
+// ContainerStats returns a snapshot of the resource usage of the running container with the given name,
+// e.g. kube-apiserver
+func ContainerStats(n *status.Node, name string) (*common.ContainerStats, error) {
+	ids, err := n.Command(
+		"crictl", "ps", "--state=running", "--quiet", fmt.Sprintf("--name=^%s$", name),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the %s container on node %s", name, n.Name())
+	}
+	if len(ids) == 0 {
+		return nil, errors.Errorf("container %s is not running on node %s", name, n.Name())
+	}
+
+	lines, err := n.Command(
+		"crictl", "stats", "--output=json", fmt.Sprintf("--id=%s", ids[0]),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the stats of the %s container on node %s", name, n.Name())
+	}
+
+	return parseCrictlStats(strings.Join(lines, "\n"))
+}
+
+// parseCrictlStats parses the output of crictl stats for a single container
+func parseCrictlStats(output string) (*common.ContainerStats, error) {
+	stats := crictlStats{}
+	if err := json.Unmarshal([]byte(output), &stats); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the crictl stats output")
+	}
+	if len(stats.Stats) != 1 {
+		return nil, errors.Errorf("expected stats for one container, got %d", len(stats.Stats))
+	}
+
+	cpu, err := strconv.ParseUint(stats.Stats[0].CPU.UsageCoreNanoSeconds.Value.String(), 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid CPU usage in the crictl stats output")
+	}
+	memory, err := strconv.ParseUint(stats.Stats[0].Memory.WorkingSetBytes.Value.String(), 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid memory working set in the crictl stats output")
+	}
+
+	return &common.ContainerStats{
+		CPUUsageCoreNanoSeconds: cpu,
+		MemoryWorkingSetBytes:   memory,
+	}, nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package containerd
+
+import (
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
+)
+
+func TestParseCrictlStats(t *testing.T) {
+	tests := []struct {
+		name          string
+		output        string
+		expected      common.ContainerStats
+		expectedError bool
+	}{
+		{
+			name: "valid: values encoded as strings",
+			output: `{
+  "stats": [
+    {
+      "attributes": {"id": "4b1e", "metadata": {"name": "kube-apiserver", "attempt": 0}},
+      "cpu": {"timestamp": "1718000000000000000", "usageCoreNanoSeconds": {"value": "123456789"}},
+      "memory": {"timestamp": "1718000000000000000", "workingSetBytes": {"value": "268435456"}}
+    }
+  ]
+}`,
+			expected: common.ContainerStats{CPUUsageCoreNanoSeconds: 123456789, MemoryWorkingSetBytes: 268435456},
+		},
+		{
+			name:     "valid: values encoded as numbers",
+			output:   `{"stats": [{"cpu": {"usageCoreNanoSeconds": {"value": 42}}, "memory": {"workingSetBytes": {"value": 1024}}}]}`,
+			expected: common.ContainerStats{CPUUsageCoreNanoSeconds: 42, MemoryWorkingSetBytes: 1024},
+		},
+		{
+			name:          "invalid: no containers",
+			output:        `{"stats": []}`,
+			expectedError: true,
+		},
+		{
+			name:          "invalid: not JSON",
+			output:        `CONTAINER CPU % MEM`,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stats, err := parseCrictlStats(test.output)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err == nil && *stats != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, *stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
+)
+
+// ContainerStats returns a snapshot of the resource usage of the running container with the given name,
+// e.g. kube-apiserver.
+// NB. docker stats reports the CPU usage as a percentage only, so the stats are read from the cgroup
+// of the container; only cgroup v2 is supported.
+func ContainerStats(n *status.Node, name string) (*common.ContainerStats, error) {
+	// containers created by the kubelet are named k8s_<container>_<pod>_<namespace>_<uid>_<attempt>
+	ids, err := n.Command(
+		"docker", "ps", "--quiet", fmt.Sprintf("--filter=name=^k8s_%s_", name),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the %s container on node %s", name, n.Name())
+	}
+	if len(ids) == 0 {
+		return nil, errors.Errorf("container %s is not running on node %s", name, n.Name())
+	}
+
+	pid, err := n.Command(
+		"docker", "inspect", "--format={{.State.Pid}}", ids[0],
+	).Silent().RunAndCapture()
+	if err != nil || len(pid) != 1 {
+		return nil, errors.Errorf("failed to get the pid of the %s container on node %s", name, n.Name())
+	}
+
+	cgroup, err := n.Command("cat", fmt.Sprintf("/proc/%s/cgroup", strings.TrimSpace(pid[0]))).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the cgroup of the %s container on node %s", name, n.Name())
+	}
+	cgroupPath, err := parseCgroupV2Path(cgroup)
+	if err != nil {
+		return nil, err
+	}
+
+	cpuStat, err := n.Command("cat", fmt.Sprintf("/sys/fs/cgroup%s/cpu.stat", cgroupPath)).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the CPU stats of the %s container on node %s", name, n.Name())
+	}
+	usageUsec, err := parseCgroupStat(cpuStat, "usage_usec")
+	if err != nil {
+		return nil, err
+	}
+
+	memoryCurrent, err := n.Command("cat", fmt.Sprintf("/sys/fs/cgroup%s/memory.current", cgroupPath)).Silent().RunAndCapture()
+	if err != nil || len(memoryCurrent) != 1 {
+		return nil, errors.Errorf("failed to read the memory usage of the %s container on node %s", name, n.Name())
+	}
+	usage, err := strconv.ParseUint(strings.TrimSpace(memoryCurrent[0]), 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid memory.current value")
+	}
+
+	memoryStat, err := n.Command("cat", fmt.Sprintf("/sys/fs/cgroup%s/memory.stat", cgroupPath)).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the memory stats of the %s container on node %s", name, n.Name())
+	}
+	inactiveFile, err := parseCgroupStat(memoryStat, "inactive_file")
+	if err != nil {
+		return nil, err
+	}
+
+	// the working set is computed like in cadvisor, excluding the inactive file cache
+	workingSet := uint64(0)
+	if usage > inactiveFile {
+		workingSet = usage - inactiveFile
+	}
+
+	return &common.ContainerStats{
+		CPUUsageCoreNanoSeconds: usageUsec * 1000,
+		MemoryWorkingSetBytes:   workingSet,
+	}, nil
+}
+
+// parseCgroupV2Path returns the path of the cgroup v2 from the content of a /proc/<pid>/cgroup file
+func parseCgroupV2Path(lines []string) (string, error) {
+	for _, l := range lines {
+		if strings.HasPrefix(l, "0::") {
+			return strings.TrimPrefix(l, "0::"), nil
+		}
+	}
+	return "", errors.New("cgroup v2 not found; only cgroup v2 is supported for reading container stats")
+}
+
+// parseCgroupStat returns the value of a key in a cgroup v2 flat keyed file, e.g. cpu.stat
+func parseCgroupStat(lines []string, key string) (uint64, error) {
+	for _, l := range lines {
+		fields := strings.Fields(l)
+		if len(fields) == 2 && fields[0] == key {
+			value, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, errors.Wrapf(err, "invalid %s value", key)
+			}
+			return value, nil
+		}
+	}
+	return 0, errors.Errorf("%s not found", key)
+}
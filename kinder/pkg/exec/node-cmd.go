@@ -24,7 +24,9 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	"k8s.io/kubeadm/kinder/pkg/exec/colors"
@@ -36,18 +38,46 @@ import (
 //	command text, that can help in debugging, please set the KINDER_COLORS environment variable to ON.
 //
 // By default, when the command is run it does not print any output generated during execution.
-// See Silent, Stdin, RunWithEcho, RunAndCapture, Skip and DryRun for possible variations to the default behavior.
+// See Silent, Stdin, RunWithEcho, RunWithStreams, RunAndCapture, Skip and DryRun for possible variations to the default behavior.
 type NodeCmd struct {
-	node    string
-	command string
-	args    []string
-	silent  bool
-	dryRun  bool
-	stdin   io.Reader
-	stdout  io.Writer
-	stderr  io.Writer
+	node     string
+	command  string
+	args     []string
+	silent   bool
+	dryRun   bool
+	stdin    io.Reader
+	stdout   io.Writer
+	stderr   io.Writer
+	recorder func(RecordedCommand)
 }
 
+// RecordedCommand defines a command run on a kind(er) node, as captured by the recorder set with NodeCmd.Record
+type RecordedCommand struct {
+	// Time is the time the command was run (or dry run)
+	Time time.Time
+	// Node is the name of the kind(er) node the command was run on
+	Node string
+	// Command is the command run on the node
+	Command string
+	// Args are the args of the command
+	Args []string
+	// Stdin is the data streamed in input to the command, if any and if not redacted
+	Stdin []byte
+	// StdinRedacted is true if data was streamed in input to the command, but it was not recorded,
+	// e.g. because it is larger than MaxRecordedStdinSize or because it might contain secrets
+	StdinRedacted bool
+	// StdinSize is the size of the data streamed in input to the command, if any
+	StdinSize int
+	// Silent is true if the command text was not printed to stdout before execution
+	Silent bool
+	// DryRun is true if the command was printed instead of being run
+	DryRun bool
+}
+
+// MaxRecordedStdinSize is the maximum size of the data streamed in input to a command that is recorded
+// by NodeCmd.Record; larger data is redacted, because it is most likely a manifest or a file content
+const MaxRecordedStdinSize = 4 * 1024
+
 // NewNodeCmd returns a new ProxyCmd to run a command on a kind(er) node
 func NewNodeCmd(node, command string, args ...string) *NodeCmd {
 	return &NodeCmd{
@@ -71,6 +101,16 @@ func (c *NodeCmd) RunWithEcho() error {
 	return c.runInnnerCommand()
 }
 
+// RunWithStreams executes the inner command on a kind(er) node and streams the command output
+// to the given writers while the command is running; this is useful for long-running commands,
+// when the output should be displayed or captured in real time, e.g. by using an io.MultiWriter.
+// Nil writers discard the corresponding output.
+func (c *NodeCmd) RunWithStreams(stdout, stderr io.Writer) error {
+	c.stdout = stdout
+	c.stderr = stderr
+	return c.runInnnerCommand()
+}
+
 // RunAndCapture executes the inner command on a kind(er) node and return the output captured during execution
 func (c *NodeCmd) RunAndCapture() (lines []string, err error) {
 	var buff bytes.Buffer
@@ -92,6 +132,14 @@ func (c *NodeCmd) Stdin(in io.Reader) *NodeCmd {
 	return c
 }
 
+// Record sets a function to be called with the command details each time the inner command is run,
+// including dry runs; this is useful e.g. for keeping the history of the commands run on a node.
+// NB. when recording, data streamed in input to the command is read in memory before execution.
+func (c *NodeCmd) Record(recorder func(RecordedCommand)) *NodeCmd {
+	c.recorder = recorder
+	return c
+}
+
 // Silent instructs the proxy command to not the command text to stdout before execution
 func (c *NodeCmd) Silent() *NodeCmd {
 	c.silent = true
@@ -105,6 +153,13 @@ func (c *NodeCmd) DryRun() *NodeCmd {
 }
 
 func (c *NodeCmd) runInnnerCommand() error {
+	// eventually record the command to be executed
+	if c.recorder != nil {
+		if err := c.record(); err != nil {
+			return err
+		}
+	}
+
 	// define the proxy command used to pass the command to the node container
 	command := "docker"
 
@@ -163,3 +218,33 @@ func (c *NodeCmd) runInnnerCommand() error {
 	log.Debugf("Running: %s", strings.Join(cmd.Args, " "))
 	return cmd.Run()
 }
+
+// record calls the recorder with the command details; data streamed in input to the command
+// is read and then replaced with an in memory reader, so it is still available to the command.
+// Data larger than MaxRecordedStdinSize is redacted.
+func (c *NodeCmd) record() error {
+	r := RecordedCommand{
+		Time:    time.Now(),
+		Node:    c.node,
+		Command: c.command,
+		Args:    append([]string{}, c.args...),
+		Silent:  c.silent,
+		DryRun:  c.dryRun,
+	}
+	if c.stdin != nil {
+		data, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return errors.Wrap(err, "failed to read the command input for recording")
+		}
+		c.stdin = bytes.NewReader(data)
+		r.StdinSize = len(data)
+		if len(data) > MaxRecordedStdinSize {
+			r.StdinRedacted = true
+		} else {
+			r.Stdin = data
+		}
+	}
+
+	c.recorder(r)
+	return nil
+}
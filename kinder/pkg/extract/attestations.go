@@ -0,0 +1,386 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/kubeadm/kinder/pkg/cri/host"
+)
+
+// attestationsFile is the name of the file listing the attestations saved for each extracted image,
+// e.g. registry.k8s.io/kube-apiserver-amd64@sha256:...  application/spdx+json  kube-apiserver.attestations/sha256-...
+const attestationsFile = "ATTESTATIONS"
+
+const (
+	// ociIndexMediaType defines the media type of the list of referrers returned by the registry referrers API
+	ociIndexMediaType = "application/vnd.oci.image.index.v1+json"
+
+	// ociManifestMediaType defines the media type of the manifest of an attestation
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// Attestation defines an attestation of an image, e.g. an SBOM, discovered using the registry referrers API
+type Attestation struct {
+	// ArtifactType is the type of the attestation, e.g. application/spdx+json
+	ArtifactType string
+	// Digest is the digest of the attestation manifest
+	Digest string
+	// Path is the path of the file where the attestation content is saved
+	Path string
+}
+
+// AttestationVerifier defines a function that verifies the attestations of an image, identified by
+// its digest-pinned reference, e.g. for failing when a required attestation is missing
+type AttestationVerifier func(image string, attestations []Attestation) error
+
+// RequireAttestations returns an AttestationVerifier that fails if an image does not have at least
+// one attestation for each of the given artifact types, e.g. application/spdx+json
+func RequireAttestations(artifactTypes ...string) AttestationVerifier {
+	return func(image string, attestations []Attestation) error {
+		found := map[string]bool{}
+		for _, a := range attestations {
+			found[a.ArtifactType] = true
+		}
+		var missing []string
+		for _, t := range artifactTypes {
+			if !found[t] {
+				missing = append(missing, t)
+			}
+		}
+		if len(missing) > 0 {
+			return errors.Errorf("image %s does not have the required attestations %v", image, missing)
+		}
+		return nil
+	}
+}
+
+// fetchedAttestation defines an attestation fetched from a registry, including its content
+type fetchedAttestation struct {
+	artifactType string
+	digest       string
+	content      []byte
+}
+
+// attestationFetcher defines a function that fetches the attestations of an image digest
+type attestationFetcher func(image, digest string) ([]fetchedAttestation, error)
+
+// configResolver defines a function that resolves an image digest to the digests of the image configs
+// referenced by the image manifest; for an image index, the configs of all the manifests in the index are returned.
+type configResolver func(image, digest string) ([]string, error)
+
+// writeAttestations fetches the attestations of all the image tarballs in paths, saving the content of each
+// attestation alongside the image tarball, e.g. kube-apiserver.attestations/sha256-..., and writes into dst
+// an attestationsFile listing the saved attestations; the attestations of each image are then checked with verify,
+// if not nil. The saved attestations and the attestationsFile are added to paths, so they are eventually included
+// in the checksums file.
+// Before fetching the attestations, the image config of each tarball is checked against the image manifest
+// in the registry, so attestations of a different image pushed with the same tag are never saved.
+func writeAttestations(dst string, paths map[string]string, resolve digestResolver, resolveConfigs configResolver, fetch attestationFetcher, verify AttestationVerifier) error {
+	dst, _ = filepath.Abs(dst)
+
+	// sort image tarballs by path, so the output is stable
+	var tarballs []string
+	for _, p := range paths {
+		if filepath.Ext(p) == ".tar" {
+			tarballs = append(tarballs, p)
+		}
+	}
+	sort.Strings(tarballs)
+
+	if len(tarballs) == 0 {
+		log.Debugf("no images extracted, skipping creation of the %s file", attestationsFile)
+		return nil
+	}
+
+	var b strings.Builder
+	for _, p := range tarballs {
+		tags, err := host.GetArchiveTags(p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the image tags from %s", p)
+		}
+		if len(tags) == 0 {
+			return errors.Errorf("image tarball %s does not define any tag", p)
+		}
+
+		image, tag := splitImageTag(tags[0])
+		digest, err := resolve(image, tag)
+		if err != nil {
+			return err
+		}
+		reference := fmt.Sprintf("%s@%s", image, digest)
+
+		config, err := host.GetArchiveConfigDigest(p, tags[0])
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the image config from %s", p)
+		}
+		configs, err := resolveConfigs(image, digest)
+		if err != nil {
+			return err
+		}
+		if !containsString(configs, config) {
+			return errors.Errorf("image tarball %s does not match %s: the image config is %s, while the registry manifest references %v", p, reference, config, configs)
+		}
+
+		fetched, err := fetch(image, digest)
+		if err != nil {
+			return err
+		}
+		log.Infof("Found %d attestations for %s", len(fetched), reference)
+
+		attestations := []Attestation{}
+		for _, f := range fetched {
+			path := filepath.Join(strings.TrimSuffix(p, ".tar")+".attestations", strings.Replace(f.digest, ":", "-", 1))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return errors.Wrapf(err, "failed to create the attestations folder for %s", p)
+			}
+			if err := os.WriteFile(path, f.content, 0644); err != nil {
+				return errors.Wrapf(err, "failed to save the attestation %s of %s", f.digest, reference)
+			}
+
+			name, err := filepath.Rel(dst, path)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get the path of %s relative to %s", path, dst)
+			}
+			paths[name] = path
+			fmt.Fprintf(&b, "%s  %s  %s\n", reference, f.artifactType, filepath.ToSlash(name))
+
+			attestations = append(attestations, Attestation{ArtifactType: f.artifactType, Digest: f.digest, Path: path})
+		}
+
+		if verify != nil {
+			if err := verify(reference, attestations); err != nil {
+				return err
+			}
+		}
+	}
+
+	file := filepath.Join(dst, attestationsFile)
+	if err := os.WriteFile(file, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	paths[attestationsFile] = file
+
+	log.Infof("%s file created", attestationsFile)
+
+	return nil
+}
+
+// ociDescriptor defines the subset of an OCI content descriptor used for reading attestations
+type ociDescriptor struct {
+	MediaType    string `json:"mediaType"`
+	Digest       string `json:"digest"`
+	ArtifactType string `json:"artifactType,omitempty"`
+}
+
+// ociIndex defines the subset of an OCI image index used for reading the referrers of an image
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// ociManifest defines the subset of an OCI image manifest used for reading an attestation
+type ociManifest struct {
+	ArtifactType string          `json:"artifactType,omitempty"`
+	Config       ociDescriptor   `json:"config"`
+	Layers       []ociDescriptor `json:"layers"`
+}
+
+// fetchAttestations fetches the attestations of an image digest using the registry referrers API;
+// the content of each attestation is the first layer of the attestation manifest.
+// If the registry does not support the referrers API, the referrers tag schema is used instead, e.g. sha256-<hex>,
+// as defined in the OCI distribution spec; if there is no referrers tag either, no attestations are returned.
+func fetchAttestations(image, digest string) ([]fetchedAttestation, error) {
+	uri, err := registryURL(image, "referrers", digest)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Fetching attestations for %s@%s", image, digest)
+	data, err := registryGet(uri, ociIndexMediaType)
+	if isNotFound(err) {
+		log.Debugf("the registry does not support the referrers API for %s, using the referrers tag schema", image)
+		uri, err = registryURL(image, "manifests", strings.Replace(digest, ":", "-", 1))
+		if err != nil {
+			return nil, err
+		}
+		data, err = registryGet(uri, ociIndexMediaType)
+		if isNotFound(err) {
+			log.Infof("No attestations found for %s@%s", image, digest)
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := &ociIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the referrers of %s@%s", image, digest)
+	}
+
+	attestations := []fetchedAttestation{}
+	for _, d := range index.Manifests {
+		uri, err := registryURL(image, "manifests", d.Digest)
+		if err != nil {
+			return nil, err
+		}
+		data, err := registryGet(uri, ociManifestMediaType)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyDigest(data, d.Digest); err != nil {
+			return nil, errors.Wrapf(err, "invalid attestation manifest %s", uri)
+		}
+
+		manifest := &ociManifest{}
+		if err := json.Unmarshal(data, manifest); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the attestation manifest %s", uri)
+		}
+		if len(manifest.Layers) == 0 {
+			log.Debugf("attestation manifest %s does not have layers, skipping", uri)
+			continue
+		}
+
+		// nb. the artifact type of the attestation defaults to the config media type, as defined in the OCI image spec
+		artifactType := d.ArtifactType
+		if artifactType == "" {
+			artifactType = manifest.ArtifactType
+		}
+		if artifactType == "" {
+			artifactType = manifest.Config.MediaType
+		}
+
+		layer := manifest.Layers[0]
+		uri, err = registryURL(image, "blobs", layer.Digest)
+		if err != nil {
+			return nil, err
+		}
+		content, err := registryGet(uri, layer.MediaType)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyDigest(content, layer.Digest); err != nil {
+			return nil, errors.Wrapf(err, "invalid attestation %s", uri)
+		}
+
+		attestations = append(attestations, fetchedAttestation{
+			artifactType: artifactType,
+			digest:       d.Digest,
+			content:      content,
+		})
+	}
+	return attestations, nil
+}
+
+// resolveImageConfigs resolves an image digest to the digests of the image configs using the registry API;
+// if the image digest is an image index, the manifests in the index are resolved too.
+func resolveImageConfigs(image, digest string) ([]string, error) {
+	uri, err := registryURL(image, "manifests", digest)
+	if err != nil {
+		return nil, err
+	}
+	data, err := registryGet(uri, strings.Join(manifestMediaTypes, ","))
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyDigest(data, digest); err != nil {
+		return nil, errors.Wrapf(err, "invalid image manifest %s", uri)
+	}
+
+	manifest := struct {
+		Config    ociDescriptor   `json:"config"`
+		Manifests []ociDescriptor `json:"manifests"`
+	}{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the image manifest %s", uri)
+	}
+	if manifest.Config.Digest != "" {
+		return []string{manifest.Config.Digest}, nil
+	}
+
+	configs := []string{}
+	for _, d := range manifest.Manifests {
+		c, err := resolveImageConfigs(image, d.Digest)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, c...)
+	}
+	return configs, nil
+}
+
+// registryGet reads the content at the given registry API URL, accepting the given media type;
+// if the registry does not find the content, an httpStatusError with status code 404 is returned
+func registryGet(uri, mediaType string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the request for %s", uri)
+	}
+	if mediaType != "" {
+		req.Header.Set("Accept", mediaType)
+	}
+
+	resp, err := registryDo(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.WithStack(&httpStatusError{uri: uri, status: resp.Status, StatusCode: resp.StatusCode})
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", uri)
+	}
+	return data, nil
+}
+
+// containsString returns true if values contains the given value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyDigest checks that the content matches the given sha256 digest
+func verifyDigest(content []byte, digest string) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return errors.Errorf("unsupported digest %q", digest)
+	}
+	sum := sha256.Sum256(content)
+	if actual := "sha256:" + hex.EncodeToString(sum[:]); actual != digest {
+		return errors.Errorf("digest mismatch, expected %s, got %s", digest, actual)
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteAttestations(t *testing.T) {
+	resolve := func(image, tag string) (string, error) {
+		return "sha256:" + tag, nil
+	}
+	resolveConfigs := func(image, digest string) ([]string, error) {
+		if strings.HasSuffix(image, "-retagged") {
+			return []string{"sha256:other"}, nil
+		}
+		return []string{imageConfigDigest(image + ":" + strings.TrimPrefix(digest, "sha256:"))}, nil
+	}
+	fetch := func(image, digest string) ([]fetchedAttestation, error) {
+		if strings.HasSuffix(image, "kube-proxy-amd64") {
+			return nil, nil
+		}
+		return []fetchedAttestation{
+			{artifactType: "application/spdx+json", digest: "sha256:0123", content: []byte(image)},
+		}, nil
+	}
+
+	tests := []struct {
+		name            string
+		images          map[string]string
+		verify          AttestationVerifier
+		expectedContent string
+		expectedError   bool
+	}{
+		{
+			name: "valid: attestations are saved alongside the image tarballs",
+			images: map[string]string{
+				"v1.31.0/kube-apiserver.tar": "registry.k8s.io/kube-apiserver-amd64:v1.31.0",
+				"v1.31.0/kube-proxy.tar":     "registry.k8s.io/kube-proxy-amd64:v1.31.0",
+			},
+			expectedContent: "registry.k8s.io/kube-apiserver-amd64@sha256:v1.31.0  application/spdx+json  v1.31.0/kube-apiserver.attestations/sha256-0123\n",
+		},
+		{
+			name: "valid: required attestations exist",
+			images: map[string]string{
+				"v1.31.0/kube-apiserver.tar": "registry.k8s.io/kube-apiserver-amd64:v1.31.0",
+			},
+			verify:          RequireAttestations("application/spdx+json"),
+			expectedContent: "registry.k8s.io/kube-apiserver-amd64@sha256:v1.31.0  application/spdx+json  v1.31.0/kube-apiserver.attestations/sha256-0123\n",
+		},
+		{
+			name: "invalid: required attestations are missing",
+			images: map[string]string{
+				"v1.31.0/kube-apiserver.tar": "registry.k8s.io/kube-apiserver-amd64:v1.31.0",
+				"v1.31.0/kube-proxy.tar":     "registry.k8s.io/kube-proxy-amd64:v1.31.0",
+			},
+			verify:        RequireAttestations("application/spdx+json"),
+			expectedError: true,
+		},
+		{
+			name: "invalid: the image tarball does not match the image in the registry",
+			images: map[string]string{
+				"v1.31.0/kube-apiserver.tar": "registry.k8s.io/kube-apiserver-retagged:v1.31.0",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := t.TempDir()
+
+			paths := map[string]string{}
+			for name, tag := range test.images {
+				p := filepath.Join(dst, name)
+				writeImageTarball(t, p, tag)
+				paths[filepath.Base(name)] = p
+			}
+
+			err := writeAttestations(dst, paths, resolve, resolveConfigs, fetch, test.verify)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			content, err := os.ReadFile(filepath.Join(dst, attestationsFile))
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", attestationsFile, err)
+			}
+			if string(content) != test.expectedContent {
+				t.Errorf("expected %s content:\n%s\ngot:\n%s", attestationsFile, test.expectedContent, content)
+			}
+			if _, ok := paths[attestationsFile]; !ok {
+				t.Errorf("expected %s to be added to paths", attestationsFile)
+			}
+
+			attestation, err := os.ReadFile(filepath.Join(dst, "v1.31.0", "kube-apiserver.attestations", "sha256-0123"))
+			if err != nil {
+				t.Fatalf("failed to read the attestation: %v", err)
+			}
+			if string(attestation) != "registry.k8s.io/kube-apiserver-amd64" {
+				t.Errorf("unexpected attestation content %q", attestation)
+			}
+		})
+	}
+}
+
+func TestFetchAttestations(t *testing.T) {
+	digest := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	sbom := `{"spdxVersion":"SPDX-2.3"}`
+	manifest := fmt.Sprintf(`{"artifactType":"application/spdx+json","config":{"mediaType":"application/vnd.oci.empty.v1+json"},`+
+		`"layers":[{"mediaType":"application/spdx+json","digest":"%s"}]}`, digest(sbom))
+	index := fmt.Sprintf(`{"manifests":[{"mediaType":"%s","digest":"%s"}]}`, ociManifestMediaType, digest(manifest))
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the registry requires an anonymous bearer token, like registry.k8s.io
+		if r.URL.Path == "/token" {
+			fmt.Fprint(w, `{"token":"anonymous"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/k8s/kube-apiserver-amd64/referrers/sha256:image":
+			fmt.Fprint(w, index)
+		case "/v2/k8s/kube-scheduler-amd64/manifests/sha256-image":
+			fmt.Fprint(w, index)
+		case "/v2/k8s/kube-controller-manager-amd64/referrers/sha256:image":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/v2/k8s/kube-scheduler-amd64/manifests/" + digest(manifest):
+			fmt.Fprint(w, manifest)
+		case "/v2/k8s/kube-scheduler-amd64/blobs/" + digest(sbom):
+			fmt.Fprint(w, sbom)
+		case "/v2/k8s/kube-apiserver-amd64/manifests/" + digest(manifest):
+			fmt.Fprint(w, manifest)
+		case "/v2/k8s/kube-apiserver-amd64/blobs/" + digest(sbom):
+			fmt.Fprint(w, sbom)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defaultClient := registryClient
+	registryClient = server.Client()
+	defer func() { registryClient = defaultClient }()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	attestations, err := fetchAttestations(host+"/k8s/kube-apiserver-amd64", "sha256:image")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(attestations) != 1 {
+		t.Fatalf("expected 1 attestation, got %d", len(attestations))
+	}
+	if a := attestations[0]; a.artifactType != "application/spdx+json" || a.digest != digest(manifest) || string(a.content) != sbom {
+		t.Errorf("unexpected attestation %s %s %q", a.artifactType, a.digest, a.content)
+	}
+
+	// registries not supporting the referrers API return attestations using the referrers tag schema
+	attestations, err = fetchAttestations(host+"/k8s/kube-scheduler-amd64", "sha256:image")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(attestations) != 1 {
+		t.Fatalf("expected 1 attestation, got %d", len(attestations))
+	}
+
+	// images without referrers do not return attestations
+	attestations, err = fetchAttestations(host+"/k8s/kube-proxy-amd64", "sha256:image")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(attestations) != 0 {
+		t.Errorf("expected no attestations, got %d", len(attestations))
+	}
+
+	// registry errors other than not found are returned
+	if _, err = fetchAttestations(host+"/k8s/kube-controller-manager-amd64", "sha256:image"); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestResolveImageConfigs(t *testing.T) {
+	digest := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	amd64 := `{"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:amd64"}}`
+	arm64 := `{"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:arm64"}}`
+	index := fmt.Sprintf(`{"manifests":[{"mediaType":"%[1]s","digest":"%[2]s"},{"mediaType":"%[1]s","digest":"%[3]s"}]}`,
+		ociManifestMediaType, digest(amd64), digest(arm64))
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, m := range []string{index, amd64, arm64} {
+			if r.URL.Path == "/v2/k8s/kube-apiserver/manifests/"+digest(m) {
+				fmt.Fprint(w, m)
+				return
+			}
+		}
+		if r.URL.Path == "/v2/k8s/kube-apiserver/manifests/sha256:tampered" {
+			fmt.Fprint(w, amd64)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	defaultClient := registryClient
+	registryClient = server.Client()
+	defer func() { registryClient = defaultClient }()
+
+	image := strings.TrimPrefix(server.URL, "https://") + "/k8s/kube-apiserver"
+
+	tests := []struct {
+		name            string
+		digest          string
+		expectedConfigs []string
+		expectedError   bool
+	}{
+		{
+			name:            "valid: image manifest",
+			digest:          digest(amd64),
+			expectedConfigs: []string{"sha256:amd64"},
+		},
+		{
+			name:            "valid: image index",
+			digest:          digest(index),
+			expectedConfigs: []string{"sha256:amd64", "sha256:arm64"},
+		},
+		{
+			name:          "invalid: the manifest does not match the digest",
+			digest:        "sha256:tampered",
+			expectedError: true,
+		},
+		{
+			name:          "invalid: the manifest does not exist",
+			digest:        digest("missing"),
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configs, err := resolveImageConfigs(image, test.digest)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(configs, test.expectedConfigs) {
+				t.Errorf("expected configs %v, got %v", test.expectedConfigs, configs)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+	kindfs "sigs.k8s.io/kind/pkg/fs"
+)
+
+// artifactCache implements an on-disk, content-addressed cache for the artifacts downloaded
+// from release or ci builds, so they can be re-used across kinder runs.
+//
+// Files are stored in the blobs/sha256 folder, named by their SHA256 digest, while the index folder
+// maps each file of a resolved Kubernetes version to the corresponding digest, e.g. index/v1.31.0/kubeadm.
+type artifactCache struct {
+	// dir is the root folder of the cache
+	dir string
+	// version is the resolved Kubernetes version the cached files belong to
+	version string
+}
+
+// newArtifactCache returns an artifactCache stored in the given folder, or nil if the folder is empty
+func newArtifactCache(dir string) *artifactCache {
+	if dir == "" {
+		return nil
+	}
+	dir, _ = filepath.Abs(dir)
+	return &artifactCache{dir: dir}
+}
+
+// forVersion returns a copy of the artifactCache for the given resolved Kubernetes version
+func (c *artifactCache) forVersion(version *K8sVersion.Version) *artifactCache {
+	if c == nil {
+		return nil
+	}
+	return &artifactCache{dir: c.dir, version: fmt.Sprintf("v%s", version)}
+}
+
+func (c *artifactCache) indexPath(file string) string {
+	return filepath.Join(c.dir, "index", c.version, file)
+}
+
+func (c *artifactCache) blobPath(digest string) string {
+	return filepath.Join(c.dir, "blobs", "sha256", digest)
+}
+
+// get copies the cached file to dst; it returns false if the file is not in the cache
+// or the cached file does not match the expected digest
+func (c *artifactCache) get(file, dst string) bool {
+	index, err := os.ReadFile(c.indexPath(file))
+	if err != nil {
+		return false
+	}
+
+	digest := strings.TrimSpace(string(index))
+	blob := c.blobPath(digest)
+	actual, err := sha256File(blob)
+	if err != nil || actual != digest {
+		log.Warnf("Ignoring cached %s for %s, the cached file is missing or corrupted", file, c.version)
+		return false
+	}
+
+	if err := kindfs.Copy(blob, dst); err != nil {
+		log.Warnf("Ignoring cached %s for %s, failed to copy the cached file: %v", file, c.version, err)
+		return false
+	}
+	return true
+}
+
+// put adds a downloaded file to the cache
+func (c *artifactCache) put(file, src string) error {
+	digest, err := sha256File(src)
+	if err != nil {
+		return err
+	}
+
+	blob := c.blobPath(digest)
+	if _, err := os.Stat(blob); os.IsNotExist(err) {
+		// nb. the blob is copied to a temporary file and then renamed, so concurrent kinder runs
+		// never read a partially written blob
+		tmp := fmt.Sprintf("%s.%d.tmp", blob, os.Getpid())
+		if err := kindfs.Copy(src, tmp); err != nil {
+			return errors.Wrapf(err, "failed to copy %s into the cache", src)
+		}
+		if err := os.Rename(tmp, blob); err != nil {
+			os.Remove(tmp)
+			return errors.Wrapf(err, "failed to copy %s into the cache", src)
+		}
+	}
+
+	index := c.indexPath(file)
+	if err := os.MkdirAll(filepath.Dir(index), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create the cache index for %s", c.version)
+	}
+	return os.WriteFile(index, []byte(digest), 0644)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestArtifactCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		putVersion  string
+		getVersion  string
+		corrupt     bool
+		expectedHit bool
+	}{
+		{
+			name:        "hit: same version",
+			putVersion:  "v1.31.0",
+			getVersion:  "v1.31.0",
+			expectedHit: true,
+		},
+		{
+			name:       "miss: different version",
+			putVersion: "v1.31.0",
+			getVersion: "v1.31.1",
+		},
+		{
+			name:       "miss: corrupted blob",
+			putVersion: "v1.31.0",
+			getVersion: "v1.31.0",
+			corrupt:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			src := filepath.Join(tmp, "kubeadm")
+			if err := os.WriteFile(src, []byte("foo"), 0644); err != nil {
+				t.Fatalf("failed to write %s: %v", src, err)
+			}
+
+			c := newArtifactCache(filepath.Join(tmp, "cache"))
+			if err := c.forVersion(K8sVersion.MustParseSemantic(test.putVersion)).put("kubeadm", src); err != nil {
+				t.Fatalf("failed to add kubeadm to the cache: %v", err)
+			}
+
+			if test.corrupt {
+				// sha256 of "foo"
+				blob := c.blobPath("2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae")
+				if err := os.WriteFile(blob, []byte("bar"), 0644); err != nil {
+					t.Fatalf("failed to corrupt %s: %v", blob, err)
+				}
+			}
+
+			dst := filepath.Join(tmp, "dst", "kubeadm")
+			hit := c.forVersion(K8sVersion.MustParseSemantic(test.getVersion)).get("kubeadm", dst)
+			if hit != test.expectedHit {
+				t.Fatalf("expected cache hit: %v, got: %v", test.expectedHit, hit)
+			}
+			if !hit {
+				return
+			}
+
+			content, err := os.ReadFile(dst)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", dst, err)
+			}
+			if string(content) != "foo" {
+				t.Errorf("expected content %q, got %q", "foo", content)
+			}
+		})
+	}
+
+	if newArtifactCache("") != nil {
+		t.Errorf("expected an empty cache dir to bypass the cache")
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// checksumsFile is the name of the file listing the SHA256 digest of the extracted files;
+// the format of this file is the same generated by the sha256sum utility.
+const checksumsFile = "SHA256SUMS"
+
+// writeChecksumsFile writes into dst a checksumsFile with the digest of all the given files, and returns
+// the digests indexed by file path; if there are no files, the checksumsFile is not created.
+func writeChecksumsFile(dst string, paths map[string]string) (map[string]string, error) {
+	if len(paths) == 0 {
+		log.Debugf("no files extracted, skipping creation of the %s file", checksumsFile)
+		return nil, nil
+	}
+
+	dst, _ = filepath.Abs(dst)
+
+	// compute the digest of each file, using the path relative to dst as a name
+	digests := map[string]string{}
+	pathDigests := map[string]string{}
+	names := []string{}
+	for _, p := range paths {
+		name, err := filepath.Rel(dst, p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the path of %s relative to %s", p, dst)
+		}
+		digest, err := sha256File(p)
+		if err != nil {
+			return nil, err
+		}
+		digests[name] = digest
+		pathDigests[p] = digest
+		names = append(names, name)
+	}
+
+	// sort files by name, so the output is stable
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", digests[name], filepath.ToSlash(name))
+	}
+
+	if err := os.WriteFile(filepath.Join(dst, checksumsFile), []byte(b.String()), 0644); err != nil {
+		return nil, err
+	}
+
+	log.Infof("%s file created", checksumsFile)
+
+	return pathDigests, nil
+}
+
+// sha256File returns the hex encoded SHA256 digest of a file
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteChecksumsFile(t *testing.T) {
+	// sha256 of "foo" and "bar"
+	const (
+		fooDigest = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+		barDigest = "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"
+	)
+
+	tests := []struct {
+		name             string
+		files            map[string]string
+		expectedContent  string
+		expectedNoOutput bool
+	}{
+		{
+			name: "valid: files are sorted by name",
+			files: map[string]string{
+				"kubelet": "bar",
+				"kubeadm": "foo",
+			},
+			expectedContent: fooDigest + "  kubeadm\n" +
+				barDigest + "  kubelet\n",
+		},
+		{
+			name: "valid: files in a version folder use relative paths",
+			files: map[string]string{
+				"v1.31.0/kubeadm": "foo",
+				"version":         "bar",
+			},
+			expectedContent: fooDigest + "  v1.31.0/kubeadm\n" +
+				barDigest + "  version\n",
+		},
+		{
+			name:             "valid: no files, no checksums file",
+			files:            map[string]string{},
+			expectedNoOutput: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := t.TempDir()
+
+			paths := map[string]string{}
+			for name, content := range test.files {
+				p := filepath.Join(dst, name)
+				if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+					t.Fatalf("failed to create folder for %s: %v", p, err)
+				}
+				if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", p, err)
+				}
+				paths[filepath.Base(name)] = p
+			}
+
+			if _, err := writeChecksumsFile(dst, paths); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dst, checksumsFile))
+			if test.expectedNoOutput {
+				if !os.IsNotExist(err) {
+					t.Fatalf("expected no %s file, got: %v", checksumsFile, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", checksumsFile, err)
+			}
+			if string(content) != test.expectedContent {
+				t.Errorf("expected %s content:\n%s\ngot:\n%s", checksumsFile, test.expectedContent, content)
+			}
+		})
+	}
+}
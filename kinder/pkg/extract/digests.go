@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/kubeadm/kinder/pkg/cri/host"
+)
+
+// imageDigestsFile is the name of the file listing the digest-pinned reference of each extracted image,
+// e.g. registry.k8s.io/kube-apiserver-amd64@sha256:...
+const imageDigestsFile = "IMAGEDIGESTS"
+
+// manifestMediaTypes defines the media types accepted when resolving image digests from a registry
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// digestResolver defines a function that resolves an image tag to the image digest
+type digestResolver func(image, tag string) (string, error)
+
+// writeImageDigestsFile resolves the tag of all the image tarballs in paths to the corresponding digest
+// and writes into dst an imageDigestsFile with the digest-pinned image references;
+// the imageDigestsFile is added to paths, so it is eventually included in the checksums file.
+// If skipNotFound is set, images not found in the registry are skipped with a warning, e.g. because
+// images of ci builds are not always pushed to the registry.
+func writeImageDigestsFile(dst string, paths map[string]string, resolve digestResolver, skipNotFound bool) error {
+	dst, _ = filepath.Abs(dst)
+
+	references := map[string]string{}
+	names := []string{}
+	for _, p := range paths {
+		if filepath.Ext(p) != ".tar" {
+			continue
+		}
+
+		tags, err := host.GetArchiveTags(p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the image tags from %s", p)
+		}
+		if len(tags) == 0 {
+			return errors.Errorf("image tarball %s does not define any tag", p)
+		}
+
+		image, tag := splitImageTag(tags[0])
+		digest, err := resolve(image, tag)
+		if err != nil {
+			if skipNotFound && isNotFound(err) {
+				log.Warnf("Image %s:%s not found in the registry, skipping it in the %s file", image, tag, imageDigestsFile)
+				continue
+			}
+			return err
+		}
+
+		name, err := filepath.Rel(dst, p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the path of %s relative to %s", p, dst)
+		}
+		references[name] = fmt.Sprintf("%s@%s", image, digest)
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		log.Debugf("no images extracted, skipping creation of the %s file", imageDigestsFile)
+		return nil
+	}
+
+	// sort files by name, so the output is stable
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", references[name], filepath.ToSlash(name))
+	}
+
+	digestsFile := filepath.Join(dst, imageDigestsFile)
+	if err := os.WriteFile(digestsFile, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	paths[imageDigestsFile] = digestsFile
+
+	log.Infof("%s file created", imageDigestsFile)
+
+	return nil
+}
+
+// splitImageTag splits an image reference like registry.k8s.io/kube-apiserver:v1.31.0
+// into the image name and the tag
+func splitImageTag(reference string) (image, tag string) {
+	i := strings.LastIndex(reference, ":")
+	if i == -1 || strings.Contains(reference[i:], "/") {
+		return reference, "latest"
+	}
+	return reference[:i], reference[i+1:]
+}
+
+// manifestURL returns the URL of the registry API for getting the manifest of an image tag
+func manifestURL(image, tag string) (string, error) {
+	return registryURL(image, "manifests", tag)
+}
+
+// registryURL returns the URL of a registry API endpoint for an image, e.g. manifests, blobs or referrers,
+// and the given reference, e.g. a tag or a digest
+func registryURL(image, endpoint, reference string) (string, error) {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) != 2 || !strings.ContainsAny(parts[0], ".:") {
+		return "", errors.Errorf("image %s does not include a registry host", image)
+	}
+	return fmt.Sprintf("https://%s/v2/%s/%s/%s", parts[0], parts[1], endpoint, reference), nil
+}
+
+// resolveImageDigest resolves an image tag to the image digest using the registry API;
+// if the image tag does not exist, an httpStatusError with status code 404 is returned
+func resolveImageDigest(image, tag string) (string, error) {
+	uri, err := manifestURL(image, tag)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodHead, uri, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create the request for %s", uri)
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ","))
+
+	log.Infof("Resolving digest for %s:%s", image, tag)
+	resp, err := registryDo(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.WithStack(&httpStatusError{method: http.MethodHead, uri: uri, status: resp.Status, StatusCode: resp.StatusCode})
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if !strings.HasPrefix(digest, "sha256:") {
+		return "", errors.Errorf("invalid digest %q for %s:%s", digest, image, tag)
+	}
+	return digest, nil
+}
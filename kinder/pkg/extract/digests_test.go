@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteImageDigestsFile(t *testing.T) {
+	resolve := func(image, tag string) (string, error) {
+		if strings.HasSuffix(image, "-missing") {
+			return "", &httpStatusError{uri: image, status: "404 Not Found", StatusCode: http.StatusNotFound}
+		}
+		if strings.HasSuffix(image, "-broken") {
+			return "", &httpStatusError{uri: image, status: "500 Internal Server Error", StatusCode: http.StatusInternalServerError}
+		}
+		return "sha256:" + tag, nil
+	}
+
+	tests := []struct {
+		name             string
+		images           map[string]string
+		skipNotFound     bool
+		expectedContent  string
+		expectedNoOutput bool
+		expectedError    bool
+	}{
+		{
+			name: "valid: images are sorted by name",
+			images: map[string]string{
+				"v1.31.0/kube-scheduler.tar": "registry.k8s.io/kube-scheduler-amd64:v1.31.0",
+				"v1.31.0/kube-apiserver.tar": "registry.k8s.io/kube-apiserver-amd64:v1.31.0",
+			},
+			expectedContent: "registry.k8s.io/kube-apiserver-amd64@sha256:v1.31.0  v1.31.0/kube-apiserver.tar\n" +
+				"registry.k8s.io/kube-scheduler-amd64@sha256:v1.31.0  v1.31.0/kube-scheduler.tar\n",
+		},
+		{
+			name:             "valid: no images, no digests file",
+			images:           map[string]string{},
+			expectedNoOutput: true,
+		},
+		{
+			name: "valid: images not found are skipped if requested",
+			images: map[string]string{
+				"v1.31.0/kube-scheduler.tar": "registry.k8s.io/kube-scheduler-missing:v1.31.0",
+				"v1.31.0/kube-apiserver.tar": "registry.k8s.io/kube-apiserver-amd64:v1.31.0",
+			},
+			skipNotFound:    true,
+			expectedContent: "registry.k8s.io/kube-apiserver-amd64@sha256:v1.31.0  v1.31.0/kube-apiserver.tar\n",
+		},
+		{
+			name: "invalid: images not found",
+			images: map[string]string{
+				"v1.31.0/kube-scheduler.tar": "registry.k8s.io/kube-scheduler-missing:v1.31.0",
+			},
+			expectedError: true,
+		},
+		{
+			name: "invalid: registry errors are not skipped",
+			images: map[string]string{
+				"v1.31.0/kube-scheduler.tar": "registry.k8s.io/kube-scheduler-broken:v1.31.0",
+			},
+			skipNotFound:  true,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := t.TempDir()
+
+			paths := map[string]string{}
+			for name, tag := range test.images {
+				p := filepath.Join(dst, name)
+				writeImageTarball(t, p, tag)
+				paths[filepath.Base(name)] = p
+			}
+
+			err := writeImageDigestsFile(dst, paths, resolve, test.skipNotFound)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			content, err := os.ReadFile(filepath.Join(dst, imageDigestsFile))
+			if test.expectedNoOutput {
+				if !os.IsNotExist(err) {
+					t.Fatalf("expected no %s file, got: %v", imageDigestsFile, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", imageDigestsFile, err)
+			}
+			if string(content) != test.expectedContent {
+				t.Errorf("expected %s content:\n%s\ngot:\n%s", imageDigestsFile, test.expectedContent, content)
+			}
+			if _, ok := paths[imageDigestsFile]; !ok {
+				t.Errorf("expected %s to be added to paths", imageDigestsFile)
+			}
+		})
+	}
+}
+
+func TestResolveImageDigest(t *testing.T) {
+	const (
+		token  = "anonymous-token"
+		digest = "sha256:0123456789abcdef"
+	)
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:k8s/kube-apiserver-amd64:pull" {
+				http.Error(w, "invalid scope", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"token":"%s"}`, token)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:k8s/kube-apiserver-amd64:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/k8s/kube-apiserver-amd64/manifests/v1.31.0":
+			w.Header().Set("Docker-Content-Digest", digest)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	defaultClient := registryClient
+	registryClient = server.Client()
+	defer func() { registryClient = defaultClient }()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	got, err := resolveImageDigest(host+"/k8s/kube-apiserver-amd64", "v1.31.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != digest {
+		t.Errorf("expected digest %s, got %s", digest, got)
+	}
+
+	_, err = resolveImageDigest(host+"/k8s/kube-apiserver-amd64", "v0.0.0")
+	if !isNotFound(err) {
+		t.Errorf("expected a not found error, got: %v", err)
+	}
+}
+
+// writeImageTarball writes a minimal image tarball with a repositories file defining the given tag
+func writeImageTarball(t *testing.T, path, tag string) {
+	image, version := splitImageTag(tag)
+	repositories := []byte(`{"` + image + `":{"` + version + `":"0123456789abcdef"}}`)
+	manifest := []byte(`[{"Config":"` + strings.TrimPrefix(imageConfigDigest(tag), "sha256:") + `.json","RepoTags":["` + tag + `"]}]`)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create folder for %s: %v", path, err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", path, err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	if err := tw.WriteHeader(&tar.Header{Name: "repositories", Mode: 0644, Size: int64(len(repositories))}); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	if _, err := tw.Write(repositories); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "manifest.json", Mode: 0644, Size: int64(len(manifest))}); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// imageConfigDigest returns the digest of the image config of the image tarballs created by writeImageTarball
+func imageConfigDigest(tag string) string {
+	sum := sha256.Sum256([]byte(tag))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -50,6 +50,10 @@ const (
 	kubeadmBinary = "kubeadm"
 	kubeletBinary = "kubelet"
 	kubectlBinary = "kubectl"
+
+	// conformanceImage defines the conformance image tarball included in a K8s release;
+	// differently from the other image tarballs, the conformance image tarball is named after the architecture
+	conformanceImage = "conformance-amd64.tar"
 )
 
 var (
@@ -60,6 +64,12 @@ var (
 
 	// AllImagesPattern defines a pattern for searching all the images in a folder
 	AllImagesPattern = []string{"*.tar"}
+
+	// conformanceImageMinVersion defines the first Kubernetes version shipping the conformance image
+	conformanceImageMinVersion = K8sVersion.MustParseSemantic("v1.13.0-alpha.2")
+
+	// conformanceImageMissingVersions defines the Kubernetes versions where the conformance image was not released
+	conformanceImageMissingVersions = []string{"v1.21.0-beta.1"}
 )
 
 // SourceType defines src types
@@ -173,6 +183,129 @@ func WithVersionFolder(versionFolder bool) Option {
 	}
 }
 
+// WithWriteChecksums option instructs the Extractor to write a SHA256SUMS file listing the digest of each extracted file
+func WithWriteChecksums(writeChecksums bool) Option {
+	return func(b *Extractor) {
+		b.writeChecksums = writeChecksums
+	}
+}
+
+// WithDigestPinning option instructs the Extractor to resolve the tag of each extracted image to the image digest,
+// and to write an IMAGEDIGESTS file listing the digest-pinned image references.
+// This option is supported only when extracting from release or ci builds.
+func WithDigestPinning(digestPinning bool) Option {
+	return func(b *Extractor) {
+		b.digestPinning = digestPinning
+	}
+}
+
+// WithCacheDir option instructs the Extractor to cache the artifacts downloaded from release or ci builds
+// in the given folder, and to re-use cached artifacts instead of downloading them again;
+// if the folder is empty, the cache is bypassed.
+func WithCacheDir(cacheDir string) Option {
+	return func(b *Extractor) {
+		b.cacheDir = cacheDir
+	}
+}
+
+// WithMirrors option instructs the Extractor to download each file from the given base URLs
+// if downloading from the source fails; mirrors are tried in order.
+// When extracting from release or ci builds, mirrors replace the release or ci build repository,
+// e.g. https://mirror.example.com/release; otherwise, mirrors replace the source URL.
+// This option is supported only when extracting from release or ci builds, or from http/https repositories.
+func WithMirrors(mirrors []string) Option {
+	return func(b *Extractor) {
+		b.mirrors = mirrors
+	}
+}
+
+// WithImageRepository option instructs the Extractor to rewrite the repository of each extracted image tarball,
+// replacing the registry and the path of each image with the given image repository,
+// e.g. registry.example.com/k8s; the image names and tags are preserved.
+// This option can't be combined with digest pinning, because the digests refer to the images in the source registry.
+func WithImageRepository(imageRepository string) Option {
+	return func(b *Extractor) {
+		b.imageRepository = imageRepository
+	}
+}
+
+// WithExcludeImages option instructs the Extractor to skip the given Kubernetes images, e.g. kube-proxy;
+// the excluded images must be Kubernetes images included in a K8s release, see AllKubernetesImages.
+func WithExcludeImages(images []string) Option {
+	return func(b *Extractor) {
+		b.excludeImages = images
+	}
+}
+
+// WithHTTPHeader option instructs the Extractor to add the given header to the HTTP requests for remote sources,
+// including mirrors, whose host matches hostPattern, e.g. for authenticating with a private repository without
+// leaking credentials to other hosts; hostPattern uses the path.Match syntax, e.g. *.example.com, and "*" matches
+// any host. The option can be repeated, and values for the same key are added in order.
+func WithHTTPHeader(hostPattern, key, value string) Option {
+	return func(b *Extractor) {
+		b.httpHeader = append(b.httpHeader, httpHeader{hostPattern: hostPattern, key: key, value: value})
+	}
+}
+
+// WithMetadata option instructs the Extractor to save the build metadata files, e.g. git-version.txt, and to write
+// a METADATA file describing the build, including the resolved Kubernetes version and the commit, so later consumers
+// know exactly which build produced the artifacts; build metadata files missing in the build are skipped.
+// This option is supported only when extracting from release or ci builds.
+func WithMetadata(metadata bool) Option {
+	return func(b *Extractor) {
+		b.metadata = metadata
+	}
+}
+
+// WithVerifyVersion option instructs the Extractor to check, after extracting, that the version file published
+// with the build matches the resolved Kubernetes version, so stale or mislabeled build folders are detected.
+// This option is supported only when extracting from release or ci builds.
+func WithVerifyVersion(verifyVersion bool) Option {
+	return func(b *Extractor) {
+		b.verifyVersion = verifyVersion
+	}
+}
+
+// WithConformanceImage option instructs the Extractor to add the conformance image tarball to the extracted files,
+// e.g. for building node images preloaded for conformance runs; the conformance image is skipped for versions that
+// don't ship it, i.e. before v1.13.0-alpha.2.
+// This option is supported only when extracting from release or ci builds.
+func WithConformanceImage(conformanceImage bool) Option {
+	return func(b *Extractor) {
+		b.conformanceImage = conformanceImage
+	}
+}
+
+// WithAttestations option instructs the Extractor to fetch the attestations of each extracted image, e.g. SBOMs,
+// using the registry referrers API, to save them alongside the image tarball, and to write an ATTESTATIONS file
+// listing the saved attestations. This option is supported only when extracting from release or ci builds,
+// and it can't be combined with rewriting the image repository.
+func WithAttestations(attestations bool) Option {
+	return func(b *Extractor) {
+		b.attestations = attestations
+	}
+}
+
+// WithAttestationVerifier option instructs the Extractor to check the attestations of each extracted image with
+// the given verifier, e.g. RequireAttestations, failing the extraction if the verifier returns an error;
+// this option implies WithAttestations.
+func WithAttestationVerifier(verifier AttestationVerifier) Option {
+	return func(b *Extractor) {
+		b.attestationVerifier = verifier
+	}
+}
+
+// WithManifest option instructs the Extractor to write a JSON manifest describing the extraction into the given
+// path, including the source, the resolved Kubernetes version, the timestamp and the path, the size and the checksum
+// of each extracted file, e.g. for archiving an auditable record of the extraction in CI; if the path is relative,
+// the manifest is written into the destination folder. Checksums are included only when WithWriteChecksums is set,
+// and the resolved version is included only when extracting from release or ci builds.
+func WithManifest(path string) Option {
+	return func(b *Extractor) {
+		b.manifestPath = path
+	}
+}
+
 // Extractor defines attributes for a Kubernetes artifact extractor
 type Extractor struct {
 	// src is the source from where to extract file
@@ -185,6 +318,32 @@ type Extractor struct {
 	dstMutator fileNameMutator
 	// add version file to dst
 	addVersionFileToDst bool
+	// write a SHA256SUMS file to dst
+	writeChecksums bool
+	// write an IMAGEDIGESTS file to dst
+	digestPinning bool
+	// cache folder for artifacts downloaded from release or ci builds
+	cacheDir string
+	// fallback base URLs for artifacts downloaded via http
+	mirrors []string
+	// image repository for rewriting extracted image tarballs
+	imageRepository string
+	// Kubernetes images to skip
+	excludeImages []string
+	// headers to be added to the HTTP requests for remote sources
+	httpHeader httpHeaders
+	// save the build metadata files to dst
+	metadata bool
+	// verify the version file published with the build
+	verifyVersion bool
+	// add the conformance image tarball to the extracted files
+	conformanceImage bool
+	// save the attestations of the extracted images to dst
+	attestations bool
+	// verifier for the attestations of the extracted images
+	attestationVerifier AttestationVerifier
+	// path of the JSON manifest describing the extraction
+	manifestPath string
 }
 
 // NewExtractor returns a new extractor configured with the given options
@@ -214,7 +373,37 @@ func (e *Extractor) SetFiles(files []string) {
 func (e *Extractor) Extract() (paths map[string]string, err error) {
 	var f extractFunc
 
-	switch GetSourceType(e.src) {
+	sourceType := GetSourceType(e.src)
+	if e.digestPinning && sourceType != ReleaseLabelOrVersionSource && sourceType != CILabelOrVersionSource {
+		return nil, errors.Errorf("digest pinning is supported only when extracting from release or ci builds, got %s", e.src)
+	}
+
+	if e.digestPinning && e.imageRepository != "" {
+		return nil, errors.New("digest pinning can't be combined with rewriting the image repository")
+	}
+
+	if e.metadata && sourceType != ReleaseLabelOrVersionSource && sourceType != CILabelOrVersionSource {
+		return nil, errors.Errorf("metadata are supported only when extracting from release or ci builds, got %s", e.src)
+	}
+
+	if e.verifyVersion && sourceType != ReleaseLabelOrVersionSource && sourceType != CILabelOrVersionSource {
+		return nil, errors.Errorf("version verification is supported only when extracting from release or ci builds, got %s", e.src)
+	}
+
+	if e.conformanceImage && sourceType != ReleaseLabelOrVersionSource && sourceType != CILabelOrVersionSource {
+		return nil, errors.Errorf("the conformance image is supported only when extracting from release or ci builds, got %s", e.src)
+	}
+
+	attestations := e.attestations || e.attestationVerifier != nil
+	if attestations && sourceType != ReleaseLabelOrVersionSource && sourceType != CILabelOrVersionSource {
+		return nil, errors.Errorf("attestations are supported only when extracting from release or ci builds, got %s", e.src)
+	}
+
+	if attestations && e.imageRepository != "" {
+		return nil, errors.New("attestations can't be combined with rewriting the image repository")
+	}
+
+	switch sourceType {
 	case ReleaseLabelOrVersionSource:
 		f = extractFromReleaseBuild
 	case CILabelOrVersionSource:
@@ -227,74 +416,338 @@ func (e *Extractor) Extract() (paths map[string]string, err error) {
 		return nil, errors.Errorf("source %s did not resolve to a valid source type", e.src)
 	}
 
-	return f(e.src, e.files, e.dst, e.dstMutator, e.addVersionFileToDst)
+	// artifacts are cached only for release or ci builds, because for other sources
+	// there is no resolved Kubernetes version to be used as a cache key
+	var cache *artifactCache
+	if sourceType == ReleaseLabelOrVersionSource || sourceType == CILabelOrVersionSource {
+		cache = newArtifactCache(e.cacheDir)
+	}
+
+	if len(e.mirrors) > 0 && sourceType == LocalRepositorySource {
+		return nil, errors.Errorf("mirrors are not supported when extracting from a local repository, got %s", e.src)
+	}
+
+	files := e.files
+	if len(e.excludeImages) > 0 {
+		excluded, err := KubernetesImageTarballs(e.excludeImages)
+		if err != nil {
+			return nil, err
+		}
+		files = excludeFiles(files, excluded)
+	}
+
+	// resolves the version of the build (if required by metadata, version verification, the conformance image or the manifest)
+	// nb. the source is pinned to the resolved version, so metadata and version verification match the extracted
+	// artifacts even if a label is updated while extracting
+	src := e.src
+	var version *K8sVersion.Version
+	var buildURLs []string
+	if e.metadata || e.verifyVersion || e.conformanceImage ||
+		(e.manifestPath != "" && (sourceType == ReleaseLabelOrVersionSource || sourceType == CILabelOrVersionSource)) {
+		prefix := "release/"
+		repository := releaseBuildURepository
+		if sourceType == CILabelOrVersionSource {
+			prefix = "ci/"
+			repository = ciBuildRepository
+		}
+		version, err = resolveVersion(strings.TrimPrefix(e.src, prefix), repository, e.mirrors, e.httpHeader)
+		if err != nil {
+			return nil, err
+		}
+		src = fmt.Sprintf("%sv%s", prefix, version)
+		for _, base := range append([]string{repository}, e.mirrors...) {
+			buildURLs = append(buildURLs, fmt.Sprintf("%s/v%s", base, version))
+		}
+	}
+
+	// adds the conformance image tarball (if requested, and if shipped with the version)
+	if e.conformanceImage {
+		if shipsConformanceImage(version) {
+			files = append(append([]string{}, files...), conformanceImage)
+		} else {
+			log.Warnf("Kubernetes v%s does not ship the conformance image, skipping", version)
+		}
+	}
+
+	paths, err = f(src, extractOptions{
+		files:               files,
+		dst:                 e.dst,
+		mutator:             e.dstMutator,
+		addVersionFileToDst: e.addVersionFileToDst,
+		cache:               cache,
+		mirrors:             e.mirrors,
+		header:              e.httpHeader,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// verifies the version file published with the build (if requested)
+	if e.verifyVersion {
+		if err := verifyVersionFile(buildURLs, version, e.httpHeader); err != nil {
+			return nil, err
+		}
+	}
+
+	// writes the build metadata files (if requested)
+	// nb. metadata files are not added to paths, because they are not artifacts
+	if e.metadata {
+		if _, err := writeMetadataFiles(e.dst, e.src, buildURLs, version, e.httpHeader); err != nil {
+			return nil, err
+		}
+	}
+
+	// rewrites the image repositories (if requested)
+	// nb. this must happen before writing the checksums file, so checksums match the rewritten images
+	if e.imageRepository != "" {
+		if err := rewriteImageRepositories(paths, e.imageRepository); err != nil {
+			return nil, err
+		}
+	}
+
+	// writes the image digests file (if requested)
+	// nb. this must happen before writing the checksums file, so the image digests file is included
+	if e.digestPinning {
+		if err := writeImageDigestsFile(e.dst, paths, resolveImageDigest, sourceType == CILabelOrVersionSource); err != nil {
+			return nil, errors.Wrapf(err, "error creating %s file in %s", imageDigestsFile, e.dst)
+		}
+	}
+
+	// saves the image attestations (if requested)
+	// nb. this must happen before writing the checksums file, so the attestations are included
+	if attestations {
+		if err := writeAttestations(e.dst, paths, resolveImageDigest, resolveImageConfigs, fetchAttestations, e.attestationVerifier); err != nil {
+			return nil, errors.Wrap(err, "error getting the image attestations")
+		}
+	}
+
+	// writes the checksums file (if requested)
+	// nb. checksums file is created so the target folder can be eventually used as a trusted source
+	var digests map[string]string
+	if e.writeChecksums {
+		digests, err = writeChecksumsFile(e.dst, paths)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating %s file in %s", checksumsFile, e.dst)
+		}
+	}
+
+	// writes the manifest describing the extraction (if requested)
+	// nb. this must happen after writing the checksums file, so the manifest includes the checksums
+	if e.manifestPath != "" {
+		if err := writeManifest(e.manifestPath, e.dst, e.src, version, paths, digests, time.Now()); err != nil {
+			return nil, errors.Wrapf(err, "error creating the manifest %s", e.manifestPath)
+		}
+	}
+
+	return paths, nil
 }
 
-// extractFunc define a function that implements an extractor method
-type extractFunc func(string, []string, string, fileNameMutator, bool) (map[string]string, error)
+// ExtractToWriter downloads a single file from the given source and streams it to w, without writing it to disk,
+// e.g. for piping a binary into a node with "docker cp -".
+// The download is retried according to httpGetBackoff, and mirrors, if any, are tried in order; please note that
+// mirrors are not tried once streaming started, because part of the file may already be written to w.
+// Options affecting the destination folder, like the file name mutators, the version file or the cache, are ignored.
+func (e *Extractor) ExtractToWriter(file string, w io.Writer) error {
+	var bases []string
 
-func extractFromCIBuild(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool) (paths map[string]string, err error) {
-	// cleanup the src from the prefix, if any
-	src = strings.TrimPrefix(src, "ci/")
+	switch GetSourceType(e.src) {
+	case ReleaseLabelOrVersionSource:
+		version, err := resolveVersion(strings.TrimPrefix(e.src, "release/"), releaseBuildURepository, e.mirrors, e.httpHeader)
+		if err != nil {
+			return err
+		}
+		bases = versionURLs(releaseBuildURepository, e.mirrors, version)
+	case CILabelOrVersionSource:
+		version, err := resolveVersion(strings.TrimPrefix(e.src, "ci/"), ciBuildRepository, e.mirrors, e.httpHeader)
+		if err != nil {
+			return err
+		}
+		bases = versionURLs(ciBuildRepository, e.mirrors, version)
+	case RemoteRepositorySource:
+		bases = append([]string{e.src}, e.mirrors...)
+	case LocalRepositorySource:
+		if len(e.mirrors) > 0 {
+			return errors.Errorf("mirrors are not supported when extracting from a local repository, got %s", e.src)
+		}
+		src := filepath.Join(strings.TrimPrefix(e.src, "file://"), file)
+		r, err := os.Open(src)
+		if err != nil {
+			return errors.Wrapf(err, "error opening %s", src)
+		}
+		defer r.Close()
+		if _, err := io.Copy(w, r); err != nil {
+			return errors.Wrapf(err, "error copying %s", src)
+		}
+		return nil
+	default:
+		return errors.Errorf("source %s did not resolve to a valid source type", e.src)
+	}
 
-	// gets the Kubernetes version from the src
+	var lastError error
+	for i, base := range bases {
+		uri := fmt.Sprintf("%s/%s", base, file)
+		if i > 0 {
+			log.Warnf("Trying mirror %s", uri)
+		}
+		_, r, err := httpGet(uri, e.httpHeader)
+		if err != nil {
+			lastError = err
+			continue
+		}
+		defer r.Close()
+
+		log.Infof("Streaming %s\n", uri)
+		if _, err := io.Copy(w, r); err != nil {
+			return errors.Wrapf(err, "error streaming %s", uri)
+		}
+		return nil
+	}
+	return errors.Wrapf(lastError, "failed to download %s from %s", file, e.src)
+}
+
+// resolveVersion returns the Kubernetes version for a release or ci build version or label
+func resolveVersion(src, repository string, mirrors []string, header httpHeaders) (*K8sVersion.Version, error) {
 	version, err := K8sVersion.ParseSemantic(src)
 	if err != nil {
-		version, err = resolveLabel(ciBuildRepository, src)
-		if err != nil {
-			return nil, err
+		return resolveLabelFromMirrors(append([]string{repository}, mirrors...), src, header)
+	}
+	return version, nil
+}
+
+// shipsConformanceImage returns true if a Kubernetes version ships the conformance image
+func shipsConformanceImage(version *K8sVersion.Version) bool {
+	if !version.AtLeast(conformanceImageMinVersion) {
+		return false
+	}
+	for _, v := range conformanceImageMissingVersions {
+		if c, err := version.Compare(v); err == nil && c == 0 {
+			return false
 		}
 	}
+	return true
+}
 
-	// saves the version file (if requested)
-	// nb. version file is created so the target folder can be eventually used as a source
-	if err := saveVersionFile(addVersionFileToDst, dst, version, m); err != nil {
-		return nil, errors.Wrapf(err, "error creating version file in %s", dst)
+// versionURLs returns the URLs of the linux/amd64 binaries for a release or ci build version,
+// on the given repository first and then on the mirrors
+func versionURLs(repository string, mirrors []string, version *K8sVersion.Version) []string {
+	urls := []string{}
+	for _, base := range append([]string{repository}, mirrors...) {
+		urls = append(urls, fmt.Sprintf("%s/v%s/bin/linux/amd64", base, version))
 	}
+	return urls
+}
 
-	// pass the version to the file name mutator
-	// nb. this will allow to save extracted files into a version folder
-	m.SetPrependVersionFolder(version)
+// KubernetesImageTarballs returns the image tarball names for the given Kubernetes image names, e.g. kube-proxy
+// or kube-proxy.tar; an error is returned if any image is not one of the AllKubernetesImages.
+func KubernetesImageTarballs(images []string) ([]string, error) {
+	tarballs := []string{}
+	for _, image := range images {
+		tarball := strings.TrimSuffix(image, ".tar") + ".tar"
+		known := false
+		for _, i := range AllKubernetesImages {
+			if i == tarball {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, errors.Errorf("unknown Kubernetes image %q, it must be one of %s", image, strings.Join(AllKubernetesImages, ", "))
+		}
+		tarballs = append(tarballs, tarball)
+	}
+	return tarballs, nil
+}
 
-	// sets the url for downloading the requested ci version
-	src = fmt.Sprintf("%s/v%s", ciBuildRepository, version)
+// excludeFiles returns the files not included in the excluded list
+func excludeFiles(files, excluded []string) []string {
+	filtered := []string{}
+	for _, f := range files {
+		skip := false
+		for _, x := range excluded {
+			if f == x {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered
+}
 
-	// read from the src via http, taking care of setting addVersionFileToDst (because it was already saved above)
-	return extractFromHTTP(src, files, dst, m, false)
+// extractOptions defines the options for an extractor method
+type extractOptions struct {
+	// files is the list of files to extract
+	files []string
+	// dst folder
+	dst string
+	// dst file name mutator
+	mutator fileNameMutator
+	// add version file to dst
+	addVersionFileToDst bool
+	// cache for the downloaded artifacts, if any
+	cache *artifactCache
+	// fallback base URLs for artifacts downloaded via http
+	mirrors []string
+	// headers to be added to the HTTP requests
+	header httpHeaders
+}
+
+// extractFunc define a function that implements an extractor method
+type extractFunc func(src string, o extractOptions) (map[string]string, error)
+
+func extractFromCIBuild(src string, o extractOptions) (paths map[string]string, err error) {
+	// cleanup the src from the prefix, if any
+	src = strings.TrimPrefix(src, "ci/")
+
+	return extractFromBuild(ciBuildRepository, src, o)
 }
 
-func extractFromReleaseBuild(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool) (paths map[string]string, err error) {
+func extractFromReleaseBuild(src string, o extractOptions) (paths map[string]string, err error) {
 	// cleanup the source src the prefix, if any
 	src = strings.TrimPrefix(src, "release/")
 
+	return extractFromBuild(releaseBuildURepository, src, o)
+}
+
+// extractFromBuild extracts files from a release or ci build in the given repository, or in the corresponding mirrors;
+// src is the Kubernetes version of the build or a label
+func extractFromBuild(repository, src string, o extractOptions) (paths map[string]string, err error) {
 	// gets the Kubernetes version from the src
-	version, err := K8sVersion.ParseSemantic(src)
+	version, err := resolveVersion(src, repository, o.mirrors, o.header)
 	if err != nil {
-		version, err = resolveLabel(releaseBuildURepository, src)
-		if err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
 
 	// saves the version file (if requested)
 	// nb. version file is created so the target folder can be eventually used as a source
-	if err := saveVersionFile(addVersionFileToDst, dst, version, m); err != nil {
-		return nil, errors.Wrapf(err, "error creating version file in %s", dst)
+	if err := saveVersionFile(o.addVersionFileToDst, o.dst, version, o.mutator); err != nil {
+		return nil, errors.Wrapf(err, "error creating version file in %s", o.dst)
 	}
 
 	// pass the version to the file name mutator
 	// nb. this will allow to save extracted files into a version folder
-	m.SetPrependVersionFolder(version)
-
-	// sets the url for downloading the requested release version
-	src = fmt.Sprintf("%s/v%s", releaseBuildURepository, version)
+	o.mutator.SetPrependVersionFolder(version)
+
+	// read from the url of the requested version via http, trying the corresponding urls on the mirrors in order,
+	// and taking care of setting addVersionFileToDst (because it was already saved above)
+	urls := versionURLs(repository, o.mirrors, version)
+	httpOptions := o
+	httpOptions.addVersionFileToDst = false
+	httpOptions.cache = o.cache.forVersion(version)
+	httpOptions.mirrors = urls[1:]
+	paths, err = extractFromHTTP(urls[0], httpOptions)
+	if err != nil {
+		return nil, err
+	}
 
-	// read from the src via http, taking care of setting addVersionFileToDst (because it was already saved above)
-	return extractFromHTTP(src, files, dst, m, false)
+	return addVersionFileToPaths(o.addVersionFileToDst, paths, o.dst, o.mutator), nil
 }
 
-func extractFromHTTP(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool) (paths map[string]string, err error) {
-	dst, _ = filepath.Abs(dst)
+func extractFromHTTP(src string, o extractOptions) (paths map[string]string, err error) {
+	files, m, c := o.files, o.mutator, o.cache
+	dst, _ := filepath.Abs(o.dst)
 	if _, err := os.Stat(dst); os.IsNotExist(err) {
 		return nil, errors.Errorf("destination path %s does not exists", dst)
 	}
@@ -307,23 +760,38 @@ func extractFromHTTP(src string, files []string, dst string, m fileNameMutator,
 
 	// if required, add the version file to the list of files to be copied to dest
 	// nb. version file is created so the target folder can be eventually used as a source
-	if addVersionFileToDst {
+	if o.addVersionFileToDst {
 		files = append(files, "version")
 	}
 
-	// in case the source is a Kubernetes build, add bin/OS/ARCH to the src uri
-	if strings.HasPrefix(src, releaseBuildURepository) || strings.HasPrefix(src, ciBuildRepository) {
+	// in case the source is a Kubernetes build, add bin/OS/ARCH to the src uri (if not already included)
+	if (strings.HasPrefix(src, releaseBuildURepository) || strings.HasPrefix(src, ciBuildRepository)) &&
+		!strings.HasSuffix(src, "/bin/linux/amd64") {
 		src = fmt.Sprintf("%s/bin/linux/amd64", src)
 	}
 
-	// Download the files.
+	// Download the files, trying the mirrors in order if the download from src fails.
+	// nb. the cache is used only for release or ci builds, when the Kubernetes version is resolved
 	paths = map[string]string{}
 	for _, f := range files {
 		srcFilePath := fmt.Sprintf("%s/%s", src, f)
-		log.Infof("Downloading %s\n", srcFilePath)
 		dstFilePath := path.Join(dst, m.Mutate(f))
-		if err := copyFromURI(srcFilePath, dstFilePath); err != nil {
-			return nil, errors.Wrapf(err, "failed to copy %s to %s", srcFilePath, dstFilePath)
+		if c != nil && c.get(f, dstFilePath) {
+			log.Infof("Using cached %s\n", srcFilePath)
+		} else {
+			log.Infof("Downloading %s\n", srcFilePath)
+			servedBy, err := copyFromMirrors(append([]string{src}, o.mirrors...), f, dstFilePath, o.header)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to copy %s to %s", srcFilePath, dstFilePath)
+			}
+			if servedBy != src {
+				log.Infof("%s served by mirror %s\n", f, servedBy)
+			}
+			if c != nil {
+				if err := c.put(f, dstFilePath); err != nil {
+					log.Warnf("Failed to add %s to the cache: %v", srcFilePath, err)
+				}
+			}
 		}
 		if f == kubeadmBinary || f == kubeletBinary || f == kubectlBinary {
 			os.Chmod(dstFilePath, 0755)
@@ -335,7 +803,9 @@ func extractFromHTTP(src string, files []string, dst string, m fileNameMutator,
 	return paths, nil
 }
 
-func extractFromLocalDir(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool) (paths map[string]string, err error) {
+func extractFromLocalDir(src string, o extractOptions) (paths map[string]string, err error) {
+	files, m := o.files, o.mutator
+
 	// checks if source folder exists
 	src, _ = filepath.Abs(src)
 	if _, err := os.Stat(src); os.IsNotExist(err) {
@@ -348,7 +818,7 @@ func extractFromLocalDir(src string, files []string, dst string, m fileNameMutat
 	}
 
 	// checks if target folder exists
-	dst, _ = filepath.Abs(dst)
+	dst, _ := filepath.Abs(o.dst)
 	if _, err := os.Stat(dst); os.IsNotExist(err) {
 		return nil, errors.Errorf("destination path %s does not exists", dst)
 	}
@@ -380,7 +850,7 @@ func extractFromLocalDir(src string, files []string, dst string, m fileNameMutat
 
 	// if required, add the version file to the list of files to be copied to dest
 	// nb. version file is created so the target folder can be eventually used as a source
-	if addVersionFileToDst {
+	if o.addVersionFileToDst {
 		files = append(files, "version")
 	}
 
@@ -434,7 +904,7 @@ func expandWildcards(src string, files []string) (expandedFiles []string, err er
 	return expandedFiles, nil
 }
 
-func resolveLabel(repository, label string) (version *K8sVersion.Version, err error) {
+func resolveLabel(repository, label string, header httpHeaders) (version *K8sVersion.Version, err error) {
 	// labels are .txt file containing a release version
 
 	// Gets the uri of the label file
@@ -445,8 +915,12 @@ func resolveLabel(repository, label string) (version *K8sVersion.Version, err er
 	log.Debugf("Resolving label %s\n", uri)
 
 	// Do an HTTP GET and read the version from the txt file.
-	_, r, err := httpGet(uri)
+	// nb. a missing label is not retried, so it fails fast, while transient errors are retried according to httpGetBackoff
+	_, r, err := httpGetWithBackoff(uri, header, httpGetBackoff, false)
 	if err != nil {
+		if isNotFound(err) {
+			return nil, errors.Wrapf(err, "label %s not found", label)
+		}
 		return nil, errors.Wrapf(err, "invalid version URI: %s", uri)
 	}
 	defer r.Close()
@@ -460,6 +934,20 @@ func resolveLabel(repository, label string) (version *K8sVersion.Version, err er
 	return version, nil
 }
 
+// resolveLabelFromMirrors resolves a label using the first repository serving it
+func resolveLabelFromMirrors(repositories []string, label string, header httpHeaders) (version *K8sVersion.Version, err error) {
+	for i, repository := range repositories {
+		if i > 0 {
+			log.Warnf("Resolving label %s from mirror %s", label, repository)
+		}
+		version, err = resolveLabel(repository, label, header)
+		if err == nil {
+			return version, nil
+		}
+	}
+	return nil, err
+}
+
 func readVersion(r io.Reader) (version *K8sVersion.Version, err error) {
 	buf, err := io.ReadAll(r)
 	if err != nil {
@@ -475,6 +963,31 @@ func readVersion(r io.Reader) (version *K8sVersion.Version, err error) {
 	return version, nil
 }
 
+// verifyVersionFile checks that the version file published with a build matches the expected version;
+// the version file is downloaded from the given build URLs, trying them in order.
+func verifyVersionFile(buildURLs []string, expected *K8sVersion.Version, header httpHeaders) error {
+	var lastError error
+	for _, base := range buildURLs {
+		uri := fmt.Sprintf("%s/version", base)
+		_, r, err := httpGet(uri, header)
+		if err != nil {
+			lastError = err
+			continue
+		}
+		actual, err := readVersion(r)
+		r.Close()
+		if err != nil {
+			return errors.Wrapf(err, "error reading the version file %s", uri)
+		}
+		if actual.String() != expected.String() {
+			return errors.Errorf("the version file %s reports v%s, but v%s was requested; the build folder might be stale or mislabeled", uri, actual, expected)
+		}
+		log.Infof("Version file %s matches v%s", uri, expected)
+		return nil
+	}
+	return errors.Wrapf(lastError, "failed to verify the version file for v%s", expected)
+}
+
 func saveVersionFile(addVersionFileToDst bool, dst string, version *K8sVersion.Version, m fileNameMutator) error {
 	if !addVersionFileToDst {
 		return nil
@@ -497,6 +1010,16 @@ func saveVersionFile(addVersionFileToDst bool, dst string, version *K8sVersion.V
 	return nil
 }
 
+// addVersionFileToPaths adds the version file saved by saveVersionFile (if any) to the list of extracted files,
+// so the returned paths are consistent with the ones returned when the version file is copied from the source.
+func addVersionFileToPaths(addVersionFileToDst bool, paths map[string]string, dst string, m fileNameMutator) map[string]string {
+	if addVersionFileToDst {
+		dst, _ = filepath.Abs(dst)
+		paths["version"] = filepath.Join(dst, m.Mutate("version"))
+	}
+	return paths
+}
+
 // Exponential backoff for httpGet (values exclude jitter):
 // 0, 2, 5, 8 ... 322 s
 var httpGetBackoff = wait.Backoff{
@@ -506,29 +1029,102 @@ var httpGetBackoff = wait.Backoff{
 	Jitter:   0.1,
 }
 
-func httpGet(uri string) (int64, io.ReadCloser, error) {
+// httpHeader defines a header to be added to the HTTP requests for the hosts matching hostPattern
+type httpHeader struct {
+	hostPattern string
+	key         string
+	value       string
+}
+
+// httpHeaders defines a list of headers to be added to HTTP requests, each one scoped to a host pattern
+type httpHeaders []httpHeader
+
+// forHost returns the headers to be added to the HTTP requests for the given host; host patterns are matched
+// case insensitive using the path.Match syntax, and invalid patterns never match
+func (h httpHeaders) forHost(host string) http.Header {
+	header := http.Header{}
+	for _, x := range h {
+		if match, err := path.Match(strings.ToLower(x.hostPattern), strings.ToLower(host)); err == nil && match {
+			header.Add(x.key, x.value)
+		}
+	}
+	return header
+}
+
+// setHeaders replaces the scoped headers in the request with the ones matching the request host
+func (h httpHeaders) setHeaders(req *http.Request) {
+	for _, x := range h {
+		req.Header.Del(x.key)
+	}
+	for k, v := range h.forHost(req.URL.Hostname()) {
+		req.Header[k] = v
+	}
+}
+
+// httpGet executes an HTTP GET for the given uri, adding the headers scoped to the uri host to the request, if any;
+// on redirects, headers are scoped again to the host of the redirect target
+func httpGet(uri string, header httpHeaders) (int64, io.ReadCloser, error) {
+	return httpGetWithBackoff(uri, header, httpGetBackoff, true)
+}
+
+// httpStatusError is returned by httpGet when the server responds with a status code other than 200 OK
+type httpStatusError struct {
+	method     string
+	uri        string
+	status     string
+	StatusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	method := e.method
+	if method == "" {
+		method = http.MethodGet
+	}
+	return fmt.Sprintf("HTTP %s %s failed: %s", method, e.uri, e.status)
+}
+
+// isNotFound returns true if the error is caused by an HTTP 404 Not Found response
+func isNotFound(err error) bool {
+	statusErr, ok := errors.Cause(err).(*httpStatusError)
+	return ok && statusErr.StatusCode == http.StatusNotFound
+}
+
+// httpGetWithBackoff executes an HTTP GET like httpGet, retrying according to the given backoff;
+// if retryNotFound is false, an HTTP 404 Not Found response is returned immediately as an httpStatusError,
+// while other status codes and connection errors are retried.
+func httpGetWithBackoff(uri string, header httpHeaders, backoff wait.Backoff, retryNotFound bool) (int64, io.ReadCloser, error) {
 	var lastError error
 	var resp *http.Response
 
 	// Create a custom http.Client with redirect behavior
 	client := &http.Client{
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			// Allow redirects
+			// Allow redirects, sending only the headers scoped to the redirect target
+			header.setHeaders(req)
 			return nil
 		},
 	}
 
-	err := wait.ExponentialBackoff(httpGetBackoff, func() (bool, error) {
-		var err error
-		resp, err = client.Get(uri)
+	err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+		req, err := http.NewRequest(http.MethodGet, uri, nil)
+		if err != nil {
+			lastError = errors.Wrapf(err, "invalid HTTP request for %s", uri)
+			return false, lastError
+		}
+		header.setHeaders(req)
+		resp, err = client.Do(req)
 		if err != nil {
 			log.Warnf("HTTP GET %s failed. Retry in few seconds", uri)
 			lastError = errors.Wrapf(err, "HTTP GET %s failed", uri)
 			return false, nil
 		}
 		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			lastError = errors.WithStack(&httpStatusError{uri: uri, status: resp.Status, StatusCode: resp.StatusCode})
+			if resp.StatusCode == http.StatusNotFound && !retryNotFound {
+				return false, lastError
+			}
 			log.Warnf("HTTP GET %s failed: %s. Retry in few seconds", uri, resp.Status)
-			lastError = errors.Errorf("HTTP GET %s failed: %s", uri, resp.Status)
 			return false, nil
 		}
 		return true, nil
@@ -540,8 +1136,8 @@ func httpGet(uri string) (int64, io.ReadCloser, error) {
 	return resp.ContentLength, resp.Body, nil
 }
 
-func copyFromURI(src, dst string) error {
-	size, r, err := httpGet(src)
+func copyFromURI(src, dst string, header httpHeaders) error {
+	size, r, err := httpGet(src, header)
 	if err != nil {
 		return errors.Wrapf(err, "error getting reader for %s", src)
 	}
@@ -568,6 +1164,24 @@ func copyFromURI(src, dst string) error {
 	return nil
 }
 
+// copyFromMirrors copies the file f from the first base URL serving it, and returns the base URL used;
+// each base URL is retried according to httpGetBackoff before moving to the next one
+func copyFromMirrors(bases []string, f, dst string, header httpHeaders) (string, error) {
+	var lastError error
+	for i, base := range bases {
+		uri := fmt.Sprintf("%s/%s", base, f)
+		if i > 0 {
+			log.Warnf("Trying mirror %s", uri)
+		}
+		if err := copyFromURI(uri, dst, header); err != nil {
+			lastError = err
+			continue
+		}
+		return base, nil
+	}
+	return "", lastError
+}
+
 type fileNameMutator struct {
 	nameOverride         string
 	namePrefix           string
@@ -629,6 +1243,52 @@ func (m *fileNameMutator) SetPrependVersionFolder(version *K8sVersion.Version) {
 	}
 }
 
+// ExtractVersionOnly returns the Kubernetes version of the given source without extracting any artifact,
+// and it can be used as a lightweight check that the source is reachable.
+// For release or ci builds, labels are resolved and then the build is confirmed by fetching the version file
+// published with the build, that must match the resolved version; for http and local sources, the version file is read.
+func ExtractVersionOnly(src string) (*K8sVersion.Version, error) {
+	var repository string
+	switch GetSourceType(src) {
+	case ReleaseLabelOrVersionSource:
+		src = strings.TrimPrefix(src, "release/")
+		repository = releaseBuildURepository
+	case CILabelOrVersionSource:
+		src = strings.TrimPrefix(src, "ci/")
+		repository = ciBuildRepository
+	case RemoteRepositorySource:
+		uri := fmt.Sprintf("%s/version", strings.TrimSuffix(src, "/"))
+		_, r, err := httpGet(uri, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version URI: %s", uri)
+		}
+		defer r.Close()
+		return readVersion(r)
+	case LocalRepositorySource:
+		versionFile := filepath.Join(strings.TrimPrefix(src, "file://"), "version")
+		f, err := os.Open(versionFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading version from %s", versionFile)
+		}
+		defer f.Close()
+		return readVersion(f)
+	default:
+		return nil, errors.Errorf("source %s did not resolve to a valid source type", src)
+	}
+
+	version, err := resolveVersion(src, repository, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	buildURL := fmt.Sprintf("%s/v%s", repository, version)
+	if err := verifyVersionFile([]string{buildURL}, version, nil); err != nil {
+		return nil, errors.Wrapf(err, "build v%s is not available", version)
+	}
+
+	return version, nil
+}
+
 // ResolveLabel provide a utility func for resolving a label
 func ResolveLabel(src string) (version string, err error) {
 	var repository string
@@ -645,7 +1305,7 @@ func ResolveLabel(src string) (version string, err error) {
 		return "", errors.Errorf("source %s did not resolve to a valid label", src)
 	}
 
-	v, err := resolveLabel(repository, src)
+	v, err := resolveLabel(repository, src, nil)
 	if err != nil {
 		return "", err
 	}
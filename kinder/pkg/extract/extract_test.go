@@ -0,0 +1,499 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestExtractVersionOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/build/version" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "v1.31.0-alpha.1.20+6b1b7e3c1b2d3a\n")
+	}))
+	defer server.Close()
+
+	local := t.TempDir()
+	if err := os.WriteFile(filepath.Join(local, "version"), []byte("v1.31.2"), 0644); err != nil {
+		t.Fatalf("failed to write the version file: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		src             string
+		expectedVersion string
+		expectedError   bool
+	}{
+		{
+			name:            "valid: http source",
+			src:             server.URL + "/build",
+			expectedVersion: "1.31.0-alpha.1.20+6b1b7e3c1b2d3a",
+		},
+		{
+			name:            "valid: local source",
+			src:             local,
+			expectedVersion: "1.31.2",
+		},
+		{
+			name:          "invalid: local source without version file",
+			src:           filepath.Join(local, "missing"),
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			version, err := ExtractVersionOnly(test.src)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err == nil && version.String() != test.expectedVersion {
+				t.Errorf("expected version %s, got %s", test.expectedVersion, version)
+			}
+		})
+	}
+}
+
+func TestExtractFromHTTPWithMirrors(t *testing.T) {
+	// do not retry failed downloads, so the test fails over to the next mirror immediately
+	defer func(b wait.Backoff) { httpGetBackoff = b }(httpGetBackoff)
+	httpGetBackoff = wait.Backoff{Steps: 1}
+
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/build/kubeadm" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "kubeadm from primary")
+	}))
+	defer primary.Close()
+
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s from mirror", filepath.Base(r.URL.Path))
+	}))
+	defer mirror.Close()
+
+	tests := []struct {
+		name             string
+		mirrors          []string
+		expectedContents map[string]string
+		expectedError    bool
+	}{
+		{
+			name:    "files missing on the primary are downloaded from the mirror",
+			mirrors: []string{primary.URL + "/missing", mirror.URL + "/build"},
+			expectedContents: map[string]string{
+				"kubeadm": "kubeadm from primary",
+				"kubelet": "kubelet from mirror",
+			},
+		},
+		{
+			name:          "files missing on all the mirrors",
+			mirrors:       []string{primary.URL + "/missing"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := t.TempDir()
+			paths, err := extractFromHTTP(primary.URL+"/build", extractOptions{files: []string{"kubeadm", "kubelet"}, dst: dst, mirrors: test.mirrors})
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			for f, expected := range test.expectedContents {
+				content, err := os.ReadFile(paths[f])
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", f, err)
+				}
+				if string(content) != expected {
+					t.Errorf("expected %s to contain %q, got %q", f, expected, content)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractToWriter(t *testing.T) {
+	// do not retry failed downloads, so the test fails over to the next mirror immediately
+	defer func(b wait.Backoff) { httpGetBackoff = b }(httpGetBackoff)
+	httpGetBackoff = wait.Backoff{Steps: 1}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/build/kubeadm" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "kubeadm from server")
+	}))
+	defer server.Close()
+
+	local := t.TempDir()
+	if err := os.WriteFile(filepath.Join(local, "kubeadm"), []byte("kubeadm from local"), 0644); err != nil {
+		t.Fatalf("failed to write kubeadm: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		src             string
+		mirrors         []string
+		file            string
+		expectedContent string
+		expectedError   bool
+	}{
+		{
+			name:            "stream from http",
+			src:             server.URL + "/build",
+			file:            "kubeadm",
+			expectedContent: "kubeadm from server",
+		},
+		{
+			name:            "stream from mirror",
+			src:             server.URL + "/missing",
+			mirrors:         []string{server.URL + "/build"},
+			file:            "kubeadm",
+			expectedContent: "kubeadm from server",
+		},
+		{
+			name:          "file missing",
+			src:           server.URL + "/build",
+			file:          "kubelet",
+			expectedError: true,
+		},
+		{
+			name:            "stream from local",
+			src:             local,
+			file:            "kubeadm",
+			expectedContent: "kubeadm from local",
+		},
+		{
+			name:          "mirrors are not supported for local sources",
+			src:           local,
+			mirrors:       []string{server.URL + "/build"},
+			file:          "kubeadm",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var b bytes.Buffer
+			err := NewExtractor(test.src, "", WithMirrors(test.mirrors)).ExtractToWriter(test.file, &b)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if b.String() != test.expectedContent {
+				t.Errorf("expected content %q, got %q", test.expectedContent, b.String())
+			}
+		})
+	}
+}
+
+func TestExtractWithHTTPHeader(t *testing.T) {
+	// do not retry failed downloads
+	defer func(b wait.Backoff) { httpGetBackoff = b }(httpGetBackoff)
+	httpGetBackoff = wait.Backoff{Steps: 1}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || len(r.Header.Values("X-Custom")) != 2 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "content of "+r.URL.Path)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		options       []Option
+		expectedError bool
+	}{
+		{
+			name: "headers are sent",
+			options: []Option{
+				WithHTTPHeader("127.0.0.1", "Authorization", "Bearer secret"),
+				WithHTTPHeader("*", "X-Custom", "a"),
+				WithHTTPHeader("127.0.0.*", "X-Custom", "b"),
+			},
+		},
+		{
+			name:          "missing headers",
+			expectedError: true,
+		},
+		{
+			name: "headers scoped to other hosts are not sent",
+			options: []Option{
+				WithHTTPHeader("*.example.com", "Authorization", "Bearer secret"),
+				WithHTTPHeader("*", "X-Custom", "a"),
+				WithHTTPHeader("*", "X-Custom", "b"),
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := t.TempDir()
+			e := NewExtractor(server.URL+"/build", dst, append(test.options, WithVersionFile(false))...)
+			e.SetFiles([]string{"kubeadm"})
+			_, err := e.Extract()
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			data, err := os.ReadFile(filepath.Join(dst, "kubeadm"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "content of /build/kubeadm" {
+				t.Errorf("unexpected content %q", data)
+			}
+		})
+	}
+}
+
+func TestExtractWithExcludeImages(t *testing.T) {
+	src := t.TempDir()
+	for _, f := range AllKubernetesImages {
+		if err := os.WriteFile(filepath.Join(src, f), []byte(f), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", f, err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		excludeImages []string
+		expectedFiles []string
+		expectedError bool
+	}{
+		{
+			name:          "images are excluded by name",
+			excludeImages: []string{"kube-proxy", "kube-scheduler.tar"},
+			expectedFiles: []string{"kube-apiserver.tar", "kube-controller-manager.tar"},
+		},
+		{
+			name:          "unknown images are rejected",
+			excludeImages: []string{"coredns"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := NewExtractor(src, t.TempDir(), OnlyKubernetesImages(true), WithExcludeImages(test.excludeImages))
+			paths, err := e.Extract()
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if len(paths) != len(test.expectedFiles) {
+				t.Fatalf("expected files %v, got %v", test.expectedFiles, paths)
+			}
+			for _, f := range test.expectedFiles {
+				if _, ok := paths[f]; !ok {
+					t.Errorf("expected file %s to be extracted, got %v", f, paths)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyVersionFile(t *testing.T) {
+	// disable retries, so missing files fail fast
+	defer func(b wait.Backoff) { httpGetBackoff = b }(httpGetBackoff)
+	httpGetBackoff = wait.Backoff{Steps: 1}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.29.0/version":
+			fmt.Fprint(w, "v1.29.0\n")
+		case "/stale/version":
+			fmt.Fprint(w, "v1.28.4\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		buildURLs     []string
+		expectedError bool
+	}{
+		{
+			name:      "valid: version file matches",
+			buildURLs: []string{server.URL + "/v1.29.0"},
+		},
+		{
+			name:      "valid: version file served by a mirror",
+			buildURLs: []string{server.URL + "/missing", server.URL + "/v1.29.0"},
+		},
+		{
+			name:          "invalid: version file does not match",
+			buildURLs:     []string{server.URL + "/stale"},
+			expectedError: true,
+		},
+		{
+			name:          "invalid: version file missing",
+			buildURLs:     []string{server.URL + "/missing"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := verifyVersionFile(test.buildURLs, K8sVersion.MustParseSemantic("v1.29.0"), nil)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestShipsConformanceImage(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{version: "v1.12.5", expected: false},
+		{version: "v1.13.0-alpha.1", expected: false},
+		{version: "v1.13.0-alpha.2", expected: true},
+		{version: "v1.21.0-beta.1", expected: false},
+		{version: "v1.21.0-beta.2", expected: true},
+		{version: "v1.31.0-alpha.1.20+6b1b7e3c1b2d3a", expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			if got := shipsConformanceImage(K8sVersion.MustParseSemantic(test.version)); got != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestExtractWithConformanceImageFromLocalDir(t *testing.T) {
+	e := NewExtractor(t.TempDir(), t.TempDir(), WithConformanceImage(true))
+	if _, err := e.Extract(); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestResolveLabelRetries(t *testing.T) {
+	// retry a few times without waiting, so transient errors can be told apart from missing labels
+	defer func(b wait.Backoff) { httpGetBackoff = b }(httpGetBackoff)
+	httpGetBackoff = wait.Backoff{Steps: 3, Duration: time.Millisecond}
+
+	tests := []struct {
+		name             string
+		status           int
+		expectedRequests int32
+		expectedNotFound bool
+	}{
+		{
+			name:             "missing label fails without retries",
+			status:           http.StatusNotFound,
+			expectedRequests: 1,
+			expectedNotFound: true,
+		},
+		{
+			name:             "transient errors are retried",
+			status:           http.StatusServiceUnavailable,
+			expectedRequests: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			_, err := resolveLabel(server.URL, "latest-1.99", nil)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if isNotFound(err) != test.expectedNotFound {
+				t.Errorf("expected not found: %v, got: %v", test.expectedNotFound, err)
+			}
+			if got := atomic.LoadInt32(&requests); got != test.expectedRequests {
+				t.Errorf("expected %d requests, got %d", test.expectedRequests, got)
+			}
+		})
+	}
+}
+
+func TestHTTPHeadersOnRedirect(t *testing.T) {
+	// do not retry failed downloads
+	defer func(b wait.Backoff) { httpGetBackoff = b }(httpGetBackoff)
+	httpGetBackoff = wait.Backoff{Steps: 1}
+
+	var target *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			// redirects to the same server using a different host name
+			http.Redirect(w, r, strings.Replace(fmt.Sprintf("http://%s/target", r.Host), "127.0.0.1", "localhost", 1), http.StatusFound)
+			return
+		}
+		target = r
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	header := httpHeaders{
+		{hostPattern: "127.0.0.1", key: "X-Scoped", value: "origin"},
+		{hostPattern: "localhost", key: "X-Scoped", value: "target"},
+		{hostPattern: "*", key: "X-Any", value: "any"},
+	}
+	_, r, err := httpGet(server.URL+"/redirect", header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+
+	if target == nil {
+		t.Fatal("the redirect target was not reached")
+	}
+	if v := target.Header.Values("X-Scoped"); len(v) != 1 || v[0] != "target" {
+		t.Errorf("expected the X-Scoped header scoped to the redirect target, got %v", v)
+	}
+	if v := target.Header.Get("X-Any"); v != "any" {
+		t.Errorf("expected the X-Any header, got %q", v)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+)
+
+// extractionManifest defines the JSON manifest describing an extraction, e.g. for archiving it in CI
+type extractionManifest struct {
+	// Source is the source the artifacts were extracted from, as requested
+	Source string `json:"source"`
+	// Version is the resolved Kubernetes version; it is set only when extracting from release or ci builds
+	Version string `json:"version,omitempty"`
+	// Timestamp is the time the extraction completed, in UTC
+	Timestamp string `json:"timestamp"`
+	// Files are the extracted files, sorted by path
+	Files []extractionManifestFile `json:"files"`
+}
+
+// extractionManifestFile defines a file produced by an extraction
+type extractionManifestFile struct {
+	// Path is the path of the file, relative to the destination folder
+	Path string `json:"path"`
+	// Size is the size of the file in bytes
+	Size int64 `json:"size"`
+	// SHA256 is the hex encoded SHA256 digest of the file; it is set only when checksums are computed
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// writeManifest writes a JSON manifest describing the extraction of all the given files into the manifest path;
+// if the manifest path is relative, it is considered relative to dst. Digests are indexed by file path, and
+// files without a digest are listed without checksum.
+func writeManifest(manifestPath, dst, src string, version *K8sVersion.Version, paths, digests map[string]string, timestamp time.Time) error {
+	dst, _ = filepath.Abs(dst)
+	if !filepath.IsAbs(manifestPath) {
+		manifestPath = filepath.Join(dst, manifestPath)
+	}
+
+	manifest := extractionManifest{
+		Source:    src,
+		Timestamp: timestamp.UTC().Format(time.RFC3339),
+		Files:     []extractionManifestFile{},
+	}
+	if version != nil {
+		manifest.Version = "v" + version.String()
+	}
+
+	for _, p := range paths {
+		name, err := filepath.Rel(dst, p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the path of %s relative to %s", p, dst)
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the size of %s", p)
+		}
+		manifest.Files = append(manifest.Files, extractionManifestFile{
+			Path:   filepath.ToSlash(name),
+			Size:   info.Size(),
+			SHA256: digests[p],
+		})
+	}
+
+	// sort files by path, so the output is stable
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the extraction manifest")
+	}
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create the folder for %s", manifestPath)
+	}
+	if err := os.WriteFile(manifestPath, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	log.Infof("Manifest %s created", manifestPath)
+
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestWriteManifest(t *testing.T) {
+	timestamp := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		manifestPath    string
+		version         string
+		checksums       bool
+		expectedPath    string
+		expectedContent string
+	}{
+		{
+			name:         "manifest with version and checksums",
+			manifestPath: "MANIFEST.json",
+			version:      "v1.31.0",
+			checksums:    true,
+			expectedPath: "MANIFEST.json",
+			expectedContent: `{
+  "source": "release/stable",
+  "version": "v1.31.0",
+  "timestamp": "2024-06-01T10:00:00Z",
+  "files": [
+    {
+      "path": "kubeadm",
+      "size": 7,
+      "sha256": "d5be5966597725a3cc0fa65c23fdcbd49dc3fae514a1ca0d439a56086565c5cc"
+    },
+    {
+      "path": "v1.31.0/kube-apiserver.tar",
+      "size": 14,
+      "sha256": "3ddd18007da0e2a1e7da24b251a2d691f53a918f9e2dedf116c0bd39de1149fe"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:         "manifest without version and checksums",
+			manifestPath: "out/manifest.json",
+			expectedPath: "out/manifest.json",
+			expectedContent: `{
+  "source": "release/stable",
+  "timestamp": "2024-06-01T10:00:00Z",
+  "files": [
+    {
+      "path": "kubeadm",
+      "size": 7
+    },
+    {
+      "path": "v1.31.0/kube-apiserver.tar",
+      "size": 14
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := t.TempDir()
+
+			paths := map[string]string{}
+			for name, content := range map[string]string{"kubeadm": "kubeadm", "v1.31.0/kube-apiserver.tar": "kube-apiserver"} {
+				p := filepath.Join(dst, name)
+				if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+					t.Fatalf("failed to create folder for %s: %v", p, err)
+				}
+				if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", p, err)
+				}
+				paths[filepath.Base(name)] = p
+			}
+
+			var digests map[string]string
+			if test.checksums {
+				var err error
+				digests, err = writeChecksumsFile(dst, paths)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			var version *K8sVersion.Version
+			if test.version != "" {
+				version = K8sVersion.MustParseSemantic(test.version)
+			}
+
+			if err := writeManifest(test.manifestPath, dst, "release/stable", version, paths, digests, timestamp); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dst, test.expectedPath))
+			if err != nil {
+				t.Fatalf("failed to read the manifest: %v", err)
+			}
+			if string(content) != test.expectedContent {
+				t.Errorf("expected manifest content:\n%s\ngot:\n%s", test.expectedContent, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// metadataFile is the name of the file describing the build the artifacts were extracted from,
+// including the source, the resolved Kubernetes version and the commit
+const metadataFile = "METADATA"
+
+// buildMetadataFiles defines the metadata files published together with release or ci builds
+// that are saved when extracting with metadata; files missing in the build are skipped.
+var buildMetadataFiles = []string{"git-version.txt"}
+
+// metadataBackoff defines the backoff for downloading build metadata files; metadata files are optional,
+// so they are not retried like artifacts, and a missing file does not delay the extraction.
+var metadataBackoff = wait.Backoff{Steps: 1}
+
+// writeMetadataFiles saves into dst the build metadata files available at the given base URLs, trying the base
+// URLs in order, and writes a metadataFile describing the build; the paths of the saved files are returned.
+func writeMetadataFiles(dst, src string, bases []string, version *K8sVersion.Version, header httpHeaders) ([]string, error) {
+	dst, _ = filepath.Abs(dst)
+
+	var paths []string
+	for _, f := range buildMetadataFiles {
+		dstFilePath := filepath.Join(dst, f)
+		saved := false
+		for _, base := range bases {
+			uri := fmt.Sprintf("%s/%s", base, f)
+			_, r, err := httpGetWithBackoff(uri, header, metadataBackoff, false)
+			if err != nil {
+				log.Debugf("build metadata %s not available: %v", uri, err)
+				continue
+			}
+			err = copyToFile(r, dstFilePath)
+			r.Close()
+			if err != nil {
+				return paths, errors.Wrapf(err, "error copying %s to %s", uri, dstFilePath)
+			}
+			saved = true
+			break
+		}
+		if !saved {
+			log.Infof("Build metadata %s is not available, skipping", f)
+			continue
+		}
+		paths = append(paths, dstFilePath)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "source: %s\n", src)
+	fmt.Fprintf(&b, "version: v%s\n", version)
+	// nb. the build metadata of ci versions is the commit the build was produced from, e.g. v1.31.0-alpha.1.20+6b1b7e3c1b2d3a
+	if commit := version.BuildMetadata(); commit != "" {
+		fmt.Fprintf(&b, "commit: %s\n", commit)
+	}
+	metadataFilePath := filepath.Join(dst, metadataFile)
+	if err := os.WriteFile(metadataFilePath, []byte(b.String()), 0644); err != nil {
+		return paths, errors.Wrapf(err, "error creating %s file in %s", metadataFile, dst)
+	}
+	log.Infof("%s file created", metadataFile)
+
+	return append(paths, metadataFilePath), nil
+}
+
+// copyToFile copies the content of r into the dst file
+func copyToFile(r io.Reader, dst string) error {
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = io.Copy(w, r)
+	return err
+}
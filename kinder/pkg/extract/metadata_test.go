@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestWriteMetadataFiles(t *testing.T) {
+	withMetadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.31.0-alpha.1.20+6b1b7e3c1b2d3a/git-version.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "v1.31.0-alpha.1.20+6b1b7e3c1b2d3a")
+	}))
+	defer withMetadata.Close()
+
+	withoutMetadata := httptest.NewServer(http.NotFoundHandler())
+	defer withoutMetadata.Close()
+
+	tests := []struct {
+		name             string
+		bases            []string
+		version          string
+		expectedContents map[string]string
+	}{
+		{
+			name:    "metadata files are saved",
+			bases:   []string{withMetadata.URL + "/v1.31.0-alpha.1.20+6b1b7e3c1b2d3a"},
+			version: "v1.31.0-alpha.1.20+6b1b7e3c1b2d3a",
+			expectedContents: map[string]string{
+				"git-version.txt": "v1.31.0-alpha.1.20+6b1b7e3c1b2d3a",
+				"METADATA":        "source: ci/latest\nversion: v1.31.0-alpha.1.20+6b1b7e3c1b2d3a\ncommit: 6b1b7e3c1b2d3a\n",
+			},
+		},
+		{
+			name:    "metadata files are saved from mirrors",
+			bases:   []string{withoutMetadata.URL, withMetadata.URL + "/v1.31.0-alpha.1.20+6b1b7e3c1b2d3a"},
+			version: "v1.31.0-alpha.1.20+6b1b7e3c1b2d3a",
+			expectedContents: map[string]string{
+				"git-version.txt": "v1.31.0-alpha.1.20+6b1b7e3c1b2d3a",
+			},
+		},
+		{
+			name:    "missing metadata files are skipped",
+			bases:   []string{withoutMetadata.URL},
+			version: "v1.31.0",
+			expectedContents: map[string]string{
+				"METADATA": "source: ci/latest\nversion: v1.31.0\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := t.TempDir()
+			paths, err := writeMetadataFiles(dst, "ci/latest", test.bases, K8sVersion.MustParseSemantic(test.version), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, p := range paths {
+				if _, err := os.Stat(p); err != nil {
+					t.Errorf("expected %s to exist: %v", p, err)
+				}
+			}
+			for name, expected := range test.expectedContents {
+				data, err := os.ReadFile(filepath.Join(dst, name))
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", name, err)
+				}
+				if string(data) != expected {
+					t.Errorf("expected %s to be %q, got %q", name, expected, string(data))
+				}
+			}
+			if _, ok := test.expectedContents["git-version.txt"]; !ok {
+				if _, err := os.Stat(filepath.Join(dst, "git-version.txt")); !os.IsNotExist(err) {
+					t.Errorf("expected git-version.txt to be skipped")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// registryClient defines the HTTP client used for calling the registry API
+var registryClient = &http.Client{Timeout: 30 * time.Second}
+
+// registryChallengeParamRegexp matches the parameters of a WWW-Authenticate challenge, e.g. realm="https://..."
+var registryChallengeParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// registryDo sends a request to the registry API; if the registry requires authentication, like registry.k8s.io,
+// an anonymous bearer token is requested from the auth service advertised in the WWW-Authenticate header of the
+// response, and the request is retried with the token.
+func registryDo(req *http.Request) (*http.Response, error) {
+	resp, err := registryClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "HTTP %s %s failed", req.Method, req.URL)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	token, err := registryToken(challenge)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to authenticate HTTP %s %s", req.Method, req.URL)
+	}
+
+	retry := req.Clone(req.Context())
+	retry.Header.Set("Authorization", "Bearer "+token)
+	resp, err = registryClient.Do(retry)
+	if err != nil {
+		return nil, errors.Wrapf(err, "HTTP %s %s failed", req.Method, req.URL)
+	}
+	return resp, nil
+}
+
+// registryToken requests an anonymous bearer token for the given WWW-Authenticate challenge,
+// e.g. Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:foo:pull"
+func registryToken(challenge string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", errors.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	params := map[string]string{}
+	for _, m := range registryChallengeParamRegexp.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme == "" || realm.Host == "" {
+		return "", errors.Errorf("invalid realm in the authentication challenge %q", challenge)
+	}
+
+	query := realm.Query()
+	for _, k := range []string{"service", "scope"} {
+		if v := params[k]; v != "" {
+			query.Set(k, v)
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	resp, err := registryClient.Get(realm.String())
+	if err != nil {
+		return "", errors.Wrapf(err, "HTTP GET %s failed", realm)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", &httpStatusError{uri: realm.String(), status: resp.Status, StatusCode: resp.StatusCode}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", realm)
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return "", errors.Wrapf(err, "failed to parse the token returned by %s", realm)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+	return "", errors.Errorf("%s did not return a token", realm)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/kubeadm/kinder/pkg/cri/host"
+)
+
+// rewriteImageRepositories rewrites the repository of all the image tarballs in paths, replacing
+// the registry and the path of each image with the given image repository,
+// e.g. registry.k8s.io/kube-apiserver-amd64 -> registry.example.com/k8s/kube-apiserver-amd64
+func rewriteImageRepositories(paths map[string]string, imageRepository string) error {
+	imageRepository = strings.TrimSuffix(imageRepository, "/")
+	rewrite := func(repository string) string {
+		return imageRepository + "/" + path.Base(repository)
+	}
+
+	for _, p := range paths {
+		if filepath.Ext(p) != ".tar" {
+			continue
+		}
+
+		log.Infof("Rewriting the image repository of %s", p)
+		if err := rewriteImageTar(p, rewrite); err != nil {
+			return errors.Wrapf(err, "failed to rewrite the image repository of %s", p)
+		}
+	}
+	return nil
+}
+
+// rewriteImageTar edits the repositories of an image tarball in place
+func rewriteImageTar(p string, rewrite func(string) string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var b bytes.Buffer
+	if err := host.EditArchiveRepositories(f, &b, rewrite); err != nil {
+		return err
+	}
+	f.Close()
+
+	return os.WriteFile(p, b.Bytes(), 0644)
+}
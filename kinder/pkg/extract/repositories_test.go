@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/cri/host"
+)
+
+func TestRewriteImageRepositories(t *testing.T) {
+	tests := []struct {
+		name            string
+		imageRepository string
+		tag             string
+		expectedTag     string
+	}{
+		{
+			name:            "registry is replaced",
+			imageRepository: "registry.example.com",
+			tag:             "registry.k8s.io/kube-apiserver-amd64:v1.31.0",
+			expectedTag:     "registry.example.com/kube-apiserver-amd64:v1.31.0",
+		},
+		{
+			name:            "registry and path are replaced",
+			imageRepository: "registry.example.com/k8s/",
+			tag:             "gcr.io/k8s-staging-ci-images/kube-proxy-amd64:v1.32.0-alpha.0.100_78573805a7292a",
+			expectedTag:     "registry.example.com/k8s/kube-proxy-amd64:v1.32.0-alpha.0.100_78573805a7292a",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := t.TempDir()
+			p := filepath.Join(dst, "image.tar")
+			writeImageTarball(t, p, test.tag)
+
+			version := filepath.Join(dst, "version")
+			if err := os.WriteFile(version, []byte("v1.31.0"), 0644); err != nil {
+				t.Fatalf("failed to write %s: %v", version, err)
+			}
+
+			if err := rewriteImageRepositories(map[string]string{"image.tar": p, "version": version}, test.imageRepository); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tags, err := host.GetArchiveTags(p)
+			if err != nil {
+				t.Fatalf("failed to read the image tags: %v", err)
+			}
+			if len(tags) != 1 || tags[0] != test.expectedTag {
+				t.Errorf("expected tag %s, got %v", test.expectedTag, tags)
+			}
+		})
+	}
+}
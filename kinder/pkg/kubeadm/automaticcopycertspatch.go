@@ -26,21 +26,26 @@ import (
 )
 
 // GetAutomaticCopyCertsPatches returns the kubeadm config patch that will instruct kubeadm
-// to use a well known certificate key for init/join.
-func GetAutomaticCopyCertsPatches(kubeadmConfigVersion string) ([]string, error) {
+// to use the given certificate key for init/join; if the certificate key is empty,
+// a well known certificate key is used.
+func GetAutomaticCopyCertsPatches(kubeadmConfigVersion, certificateKey string) ([]string, error) {
+	if certificateKey == "" {
+		certificateKey = constants.CertificateKey
+	}
+
 	// select the patches for the kubeadm config version
 	log.Debugf("Preparing automaticCopyCertsPatches for kubeadm config %s", kubeadmConfigVersion)
 
 	switch kubeadmConfigVersion {
 	case "v1beta3":
 		return []string{
-			fmt.Sprintf(automaticCopyCertsInitv1beta3, constants.CertificateKey),
-			fmt.Sprintf(automaticCopyCertsJoinv1beta3, constants.CertificateKey),
+			fmt.Sprintf(automaticCopyCertsInitv1beta3, certificateKey),
+			fmt.Sprintf(automaticCopyCertsJoinv1beta3, certificateKey),
 		}, nil
 	case "v1beta4":
 		return []string{
-			fmt.Sprintf(automaticCopyCertsInitv1beta4, constants.CertificateKey),
-			fmt.Sprintf(automaticCopyCertsJoinv1beta4, constants.CertificateKey),
+			fmt.Sprintf(automaticCopyCertsInitv1beta4, certificateKey),
+			fmt.Sprintf(automaticCopyCertsJoinv1beta4, certificateKey),
 		}, nil
 	}
 
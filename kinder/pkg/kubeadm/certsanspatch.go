@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetCertSANsPatch returns the kubeadm config patch that will instruct kubeadm to add the given
+// Subject Alternative Names to the API server serving certificate; the SANs are appended to the
+// default SANs set by kinder, that are localhost and the API server address.
+func GetCertSANsPatch(kubeadmConfigVersion string, certSANs []string) (PatchJSON6902, error) {
+	log.Debugf("Preparing certSANs patch for kubeadm config %s", kubeadmConfigVersion)
+
+	switch kubeadmConfigVersion {
+	case "v1beta3", "v1beta4":
+	default:
+		return PatchJSON6902{}, errors.Errorf("unknown kubeadm config version: %s", kubeadmConfigVersion)
+	}
+
+	var b strings.Builder
+	for _, san := range certSANs {
+		if strings.TrimSpace(san) == "" {
+			return PatchJSON6902{}, errors.New("certSANs can't contain empty values")
+		}
+		value, err := json.Marshal(san)
+		if err != nil {
+			return PatchJSON6902{}, errors.Wrapf(err, "invalid certSAN %q", san)
+		}
+		fmt.Fprintf(&b, "\n- op: add\n  path: \"/apiServer/certSANs/-\"\n  value: %s", value)
+	}
+
+	return PatchJSON6902{
+		Group:   "kubeadm.k8s.io",
+		Version: kubeadmConfigVersion,
+		Kind:    "ClusterConfiguration",
+		Patch:   b.String(),
+	}, nil
+}
+
+// CertSANs returns the DNS names and the IP addresses included in the Subject Alternative Names
+// of a PEM encoded certificate, e.g. the API server serving certificate generated by kubeadm
+func CertSANs(cert []byte) ([]string, error) {
+	block, _ := pem.Decode(cert)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("failed to decode the PEM encoded certificate")
+	}
+
+	c, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the certificate")
+	}
+
+	sans := append([]string{}, c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return sans, nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestGetCertSANsPatch(t *testing.T) {
+	data := ConfigData{
+		ClusterName:       "kinder",
+		KubernetesVersion: "v1.31.0",
+		APIServerAddress:  "172.17.0.2",
+		NodeAddress:       "172.17.0.2",
+		ControlPlane:      true,
+	}
+
+	tests := []struct {
+		name          string
+		configVersion string
+		certSANs      []string
+		expected      []string
+		expectedError bool
+	}{
+		{
+			name:          "valid: v1beta3",
+			configVersion: "v1beta3",
+			certSANs:      []string{"kinder.example.com"},
+			expected:      []string{"localhost", "172.17.0.2", "kinder.example.com"},
+		},
+		{
+			name:          "valid: v1beta4 with many SANs",
+			configVersion: "v1beta4",
+			certSANs:      []string{"kinder.example.com", "10.0.0.1"},
+			expected:      []string{"localhost", "172.17.0.2", "kinder.example.com", "10.0.0.1"},
+		},
+		{
+			name:          "invalid: empty SAN",
+			configVersion: "v1beta4",
+			certSANs:      []string{" "},
+			expectedError: true,
+		},
+		{
+			name:          "invalid: unknown config version",
+			configVersion: "v1beta2",
+			certSANs:      []string{"kinder.example.com"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patch, err := GetCertSANsPatch(test.configVersion, test.certSANs)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			config, err := RenderConfig(test.configVersion, data, nil, []PatchJSON6902{patch})
+			if err != nil {
+				t.Fatalf("failed to render config: %v", err)
+			}
+
+			var clusterConfiguration struct {
+				APIServer struct {
+					CertSANs []string `json:"certSANs"`
+				} `json:"apiServer"`
+			}
+			for _, doc := range strings.Split(config, "---\n") {
+				if strings.Contains(doc, "kind: ClusterConfiguration") {
+					if err := yaml.Unmarshal([]byte(doc), &clusterConfiguration); err != nil {
+						t.Fatalf("failed to parse the ClusterConfiguration: %v", err)
+					}
+				}
+			}
+			if !reflect.DeepEqual(clusterConfiguration.APIServer.CertSANs, test.expected) {
+				t.Errorf("expected certSANs %v, got %v", test.expected, clusterConfiguration.APIServer.CertSANs)
+			}
+		})
+	}
+}
+
+func TestCertSANs(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kube-apiserver"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"kubernetes", "kinder.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("10.96.0.1"), net.ParseIP("172.17.0.2")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create a certificate: %v", err)
+	}
+	caCert, err := os.ReadFile(filepath.Join("testdata", "ca.crt"))
+	if err != nil {
+		t.Fatalf("failed to read the CA certificate: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		cert          []byte
+		expected      []string
+		expectedError bool
+	}{
+		{
+			name:     "certificate with DNS and IP SANs",
+			cert:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			expected: []string{"kubernetes", "kinder.example.com", "10.96.0.1", "172.17.0.2"},
+		},
+		{
+			name:     "certificate without SANs",
+			cert:     caCert,
+			expected: []string{},
+		},
+		{
+			name:          "not a PEM encoded certificate",
+			cert:          []byte("not a certificate"),
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sans, err := CertSANs(test.cert)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(sans, test.expected) {
+				t.Errorf("expected SANs %v, got %v", test.expected, sans)
+			}
+		})
+	}
+}
@@ -18,12 +18,15 @@ package kubeadm
 
 import (
 	"bytes"
+	"net"
+	"path"
 	"strings"
 	"text/template"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"k8s.io/apimachinery/pkg/util/validation"
 	K8sVersion "k8s.io/apimachinery/pkg/util/version"
 )
 
@@ -32,6 +35,23 @@ import (
 func Config(kubeadmConfigVersion string, data ConfigData) (config string, err error) {
 	// select the patches for the kubeadm config version
 	log.Debugf("Preparing kubeadm config %s", kubeadmConfigVersion)
+	if err := ValidateClusterDNS(data.ClusterDNS); err != nil {
+		return "", err
+	}
+	// nb. the kubelet version is assumed to be the same as the Kubernetes version
+	kubeletVersion, _ := K8sVersion.ParseSemantic(data.KubernetesVersion)
+	if err := ValidateSwapBehavior(data.SwapBehavior, kubeletVersion); err != nil {
+		return "", err
+	}
+	if data.EtcdImageTag != "" {
+		if err := ValidateEtcdImageTag(data.EtcdImageTag); err != nil {
+			return "", err
+		}
+	}
+	if data.Reset.IsSet() && kubeadmConfigVersion != "v1beta4" {
+		return "", errors.Errorf("customizing the ResetConfiguration requires kubeadm config version v1beta4, got %s", kubeadmConfigVersion)
+	}
+
 	var templateSource string
 	switch kubeadmConfigVersion {
 	case "v1beta3":
@@ -60,15 +80,257 @@ func Config(kubeadmConfigVersion string, data ConfigData) (config string, err er
 	return buff.String(), nil
 }
 
-// GetKubeadmConfigVersion returns the kubeadm config version corresponding to a Kubernetes kubeadmVersion
+// RenderConfig returns a kubeadm config generated using the config API version
+// and the customizable settings based on data, with the given patches applied.
+// Differently from the KubeadmConfig action, RenderConfig does not depend on
+// a running cluster, and thus it can be used e.g. for testing the config generation.
+func RenderConfig(kubeadmConfigVersion string, data ConfigData, patches []string, patches6902 []PatchJSON6902) (string, error) {
+	// generate the "raw config", using the kubeadm config template provided by kind
+	rawconfig, err := Config(kubeadmConfigVersion, data)
+	if err != nil {
+		return "", err
+	}
+
+	// apply patches; with v1beta4, patches are applied as strategic merge patches, so lists keyed by name,
+	// e.g. extraArgs, are merged with the list in the config instead of replacing it
+	if kubeadmConfigVersion == "v1beta4" {
+		return BuildWithStrategicMerge(rawconfig, nil, patches, patches6902)
+	}
+	return Build(rawconfig, patches, patches6902)
+}
+
+// GetKubeadmConfigVersion returns the kubeadm config version corresponding to a Kubernetes kubeadmVersion,
+// that is the newest kubeadm config version supported by kubeadmVersion
 func GetKubeadmConfigVersion(kubeadmVersion *K8sVersion.Version) string {
 	// v1alpha1 (that is Kubernetes v1.10.0) is out of support
 	// v1alpha2 (that is Kubernetes v1.11.0) is out of support
 	// v1alpha3 (that is Kubernetes v1.13.0) is out of support
-	if kubeadmVersion.Major() > 1 || kubeadmVersion.Minor() >= 31 {
-		return "v1beta4"
+	// nb. kubeadm versions older than the oldest known kubeadm config version get the oldest known kubeadm config version
+	if supported := SupportedConfigVersions(kubeadmVersion); len(supported) > 0 {
+		return supported[len(supported)-1]
+	}
+	return kubeadmConfigVersions[0]
+}
+
+// SupportedConfigVersions returns the kubeadm config versions supported by a kubeadm version, from the oldest
+// to the newest, e.g. [v1beta3 v1beta4] for kubeadm v1.31; the result is empty if the kubeadm version
+// is older than the minimum kubeadm version of all the known kubeadm config versions.
+func SupportedConfigVersions(kubeadmVersion *K8sVersion.Version) []string {
+	supported := []string{}
+	for _, v := range kubeadmConfigVersions {
+		if kubeadmVersion.AtLeast(minKubeadmVersionForConfigVersion[v]) {
+			supported = append(supported, v)
+		}
+	}
+	return supported
+}
+
+// ConfigVersionNode defines the subset of status.Node used for resolving the kubeadm config version of a node
+type ConfigVersionNode interface {
+	Name() string
+	KubeadmVersion() (*K8sVersion.Version, error)
+}
+
+// ResolveConfigVersion returns the kubeadm config version to be used on a node; if override is set, it is validated
+// against the version of the kubeadm binary installed on the node, otherwise the kubeadm config version
+// corresponding to the kubeadm binary is returned. The kubeadm version is returned as well.
+func ResolveConfigVersion(n ConfigVersionNode, override string) (string, *K8sVersion.Version, error) {
+	kubeadmVersion, err := n.KubeadmVersion()
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "could not obtain the kubeadm version for node %s", n.Name())
 	}
-	return "v1beta3"
+	log.Debugf("kubeadm version %s", kubeadmVersion)
+
+	if override == "" {
+		return GetKubeadmConfigVersion(kubeadmVersion), kubeadmVersion, nil
+	}
+	if err := ValidateKubeadmConfigVersion(override, kubeadmVersion); err != nil {
+		return "", nil, errors.Wrapf(err, "invalid kubeadm config version for node %s", n.Name())
+	}
+	return override, kubeadmVersion, nil
+}
+
+// minKubeadmVersionForConfigVersion defines the minimum kubeadm version supporting each kubeadm config version
+var minKubeadmVersionForConfigVersion = map[string]*K8sVersion.Version{
+	"v1beta3": K8sVersion.MustParseSemantic("v1.22.0-0"),
+	"v1beta4": K8sVersion.MustParseSemantic("v1.31.0-0"),
+}
+
+// kubeadmConfigVersions defines the known kubeadm config versions, from the oldest to the newest
+var kubeadmConfigVersions = []string{"v1beta3", "v1beta4"}
+
+// HighestCommonConfigVersion returns the newest kubeadm config version supported by both the given kubeadm versions,
+// e.g. for generating a single kubeadm config usable across kubeadm init and kubeadm join during skew tests
+func HighestCommonConfigVersion(a, b *K8sVersion.Version) (string, error) {
+	if a == nil || b == nil {
+		return "", errors.New("kubeadm versions must be set")
+	}
+	supportedByB := map[string]bool{}
+	for _, v := range SupportedConfigVersions(b) {
+		supportedByB[v] = true
+	}
+	supportedByA := SupportedConfigVersions(a)
+	for i := len(supportedByA) - 1; i >= 0; i-- {
+		if supportedByB[supportedByA[i]] {
+			return supportedByA[i], nil
+		}
+	}
+	return "", errors.Errorf("there is no kubeadm config version supported by both kubeadm v%s and kubeadm v%s", a, b)
+}
+
+// ValidateKubeadmConfigVersion checks if the kubeadm config version is supported by the given kubeadm version
+func ValidateKubeadmConfigVersion(kubeadmConfigVersion string, kubeadmVersion *K8sVersion.Version) error {
+	minKubeadmVersion, ok := minKubeadmVersionForConfigVersion[kubeadmConfigVersion]
+	if !ok {
+		return errors.Errorf("unknown kubeadm config version: %s", kubeadmConfigVersion)
+	}
+	supported := SupportedConfigVersions(kubeadmVersion)
+	for _, v := range supported {
+		if v == kubeadmConfigVersion {
+			return nil
+		}
+	}
+	return errors.Errorf("kubeadm config version %s is not supported by kubeadm v%s, it requires kubeadm v%d.%d or greater; supported versions are %v",
+		kubeadmConfigVersion, kubeadmVersion, minKubeadmVersion.Major(), minKubeadmVersion.Minor(), supported)
+}
+
+// DefaultDNSDomain defines the DNS domain used by services when not otherwise specified
+const DefaultDNSDomain = "cluster.local"
+
+// ValidateDNSDomain checks if the DNS domain used by services is a valid DNS name
+func ValidateDNSDomain(dnsDomain string) error {
+	if errs := validation.IsDNS1123Subdomain(dnsDomain); len(errs) > 0 {
+		return errors.Errorf("invalid DNS domain %q: %s", dnsDomain, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// ValidateClusterDNS checks if all the cluster DNS server addresses are valid IP addresses
+func ValidateClusterDNS(clusterDNS []string) error {
+	for _, ip := range clusterDNS {
+		if net.ParseIP(ip) == nil {
+			return errors.Errorf("invalid cluster DNS address %q, it must be an IP address", ip)
+		}
+	}
+	return nil
+}
+
+// minKubeletVersionWithoutUnlimitedSwap defines the kubelet version where the UnlimitedSwap swap behavior was removed
+var minKubeletVersionWithoutUnlimitedSwap = K8sVersion.MustParseSemantic("v1.30.0-0")
+
+// ValidateSwapBehavior checks if the swap behavior of the kubelet is one of the values known for the given kubelet
+// version; an empty value is valid, and it means the kubelet default is used. UnlimitedSwap is accepted only
+// for kubelet versions older than v1.30, and it is rejected if the kubelet version is not known.
+func ValidateSwapBehavior(swapBehavior string, kubeletVersion *K8sVersion.Version) error {
+	switch swapBehavior {
+	case "", "NoSwap", "LimitedSwap":
+	case "UnlimitedSwap":
+		if kubeletVersion == nil || kubeletVersion.AtLeast(minKubeletVersionWithoutUnlimitedSwap) {
+			return errors.Errorf("invalid kubelet swap behavior %q, it is supported only by kubelet versions older than v%d.%d",
+				swapBehavior, minKubeletVersionWithoutUnlimitedSwap.Major(), minKubeletVersionWithoutUnlimitedSwap.Minor())
+		}
+	default:
+		return errors.Errorf("invalid kubelet swap behavior %q, it must be one of NoSwap, LimitedSwap", swapBehavior)
+	}
+	return nil
+}
+
+// ValidateEtcdImageTag checks if the tag of the etcd image looks like an etcd version, e.g. 3.5.15-0 or v3.5.15
+func ValidateEtcdImageTag(tag string) error {
+	v, err := K8sVersion.ParseSemantic(tag)
+	if err != nil {
+		return errors.Wrapf(err, "invalid etcd image tag %q, it must be an etcd version", tag)
+	}
+	if v.Major() != 3 {
+		return errors.Errorf("invalid etcd image tag %q, only etcd v3 is supported", tag)
+	}
+	return nil
+}
+
+// ValidateResetConfigData checks if the ResetConfiguration settings are valid and supported by the given kubeadm version;
+// please note that ResetConfiguration was introduced with the v1beta4 kubeadm config version
+func ValidateResetConfigData(reset ResetConfigData, kubeadmVersion *K8sVersion.Version) error {
+	if reset.CRISocket != "" {
+		if err := ValidateCRISocket(reset.CRISocket); err != nil {
+			return err
+		}
+	}
+	if !reset.IsSet() {
+		return nil
+	}
+	if err := ValidateKubeadmConfigVersion("v1beta4", kubeadmVersion); err != nil {
+		return errors.Wrap(err, "ResetConfiguration is not supported")
+	}
+	if reset.CertificatesDir != "" && !path.IsAbs(reset.CertificatesDir) {
+		return errors.Errorf("invalid certificates dir %q, it must be an absolute path", reset.CertificatesDir)
+	}
+	return nil
+}
+
+// preflightChecks defines the names of the kubeadm preflight checks known to kinder; this list is
+// only used to warn about possible typos, because kubeadm remains the source of truth for the checks
+// supported by each version
+var preflightChecks = []string{
+	"all", "CRI", "ContainerRuntimeVersion", "ControlPlaneNodesReady", "CoreDNSMigration", "CoreDNSUnsupportedPlugins",
+	"CreateJob", "ExternalEtcdVersion", "Firewalld", "Hostname", "ImagePull", "IsPrivilegedUser", "KubeletVersion",
+	"KubernetesVersion", "Mem", "NumCPU", "StaticPodManifest", "Swap", "SystemVerification",
+}
+
+// preflightCheckPrefixes defines the prefixes of the kubeadm preflight checks that are parametrized,
+// e.g. Port-6443 or FileContent--proc-sys-net-bridge-bridge-nf-call-iptables
+var preflightCheckPrefixes = []string{
+	"DirAvailable-", "FileAvailable-", "FileContent-", "FileExisting-", "HTTPProxy-", "HTTPProxyCIDR-", "Port-", "Service-",
+}
+
+// ValidateIgnorePreflightErrors checks the preflight errors to ignore for obvious typos, like empty names
+// or parametrized checks without a parameter; names not known to kinder are passed through to kubeadm
+// with a warning, given that new checks can be added by any kubeadm release
+func ValidateIgnorePreflightErrors(ignorePreflightErrors []string) error {
+	for _, name := range ignorePreflightErrors {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return errors.New("empty preflight check name")
+		}
+		if isPreflightCheckPrefix(name) {
+			return errors.Errorf("preflight check %q requires a parameter", name)
+		}
+		if !isPreflightCheckKnown(name) {
+			log.Warnf("preflight check %q is not known to kinder, passing it to kubeadm as is", name)
+		}
+	}
+	return nil
+}
+
+// isPreflightCheckKnown returns true if the name matches a known preflight check; like in kubeadm,
+// names are case insensitive
+func isPreflightCheckKnown(name string) bool {
+	for _, check := range preflightChecks {
+		if strings.EqualFold(check, name) {
+			return true
+		}
+	}
+	return isPreflightCheckPrefixed(name)
+}
+
+// isPreflightCheckPrefix returns true if the name is the prefix of a parametrized preflight check
+// without the parameter, e.g. Port-
+func isPreflightCheckPrefix(name string) bool {
+	for _, prefix := range preflightCheckPrefixes {
+		if strings.EqualFold(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPreflightCheckPrefixed returns true if the name matches a parametrized preflight check
+func isPreflightCheckPrefixed(name string) bool {
+	for _, prefix := range preflightCheckPrefixes {
+		if len(name) > len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 // ConfigData is supplied to the kubeadm config template, with values populated
@@ -93,6 +355,16 @@ type ConfigData struct {
 	PodSubnet string
 	// The subnet used for services
 	ServiceSubnet string
+	// The DNS domain used by services, defaults to cluster.local
+	DNSDomain string
+	// The resolver configuration file used by the kubelet, if empty the kubelet default is used
+	ResolvConf string
+	// The IP addresses of the cluster DNS server used by the kubelet, if empty the kubeadm default is used
+	ClusterDNS []string
+	// FailSwapOn instructs the kubelet to fail if swap is enabled on the node; it defaults to false on kinder nodes
+	FailSwapOn bool
+	// SwapBehavior defines how the kubelet lets workloads use swap, e.g. LimitedSwap; if empty the kubelet default is used
+	SwapBehavior string
 	// IPv4 values take precedence over IPv6 by default, if true set IPv6 default values
 	IPv6 bool
 	// The kubeadm feature-gate
@@ -100,6 +372,8 @@ type ConfigData struct {
 	FeatureGateValue string
 	// The encryption algorithm
 	EncryptionAlgorithm string
+	// CRISocket overrides the default CRI socket of the CRI installed on the node
+	CRISocket string
 	// UpgradeVersion is the version passed to kubeadm upgrade
 	UpgradeVersion string
 	// DerivedConfigData is populated by Derive()
@@ -108,6 +382,42 @@ type ConfigData struct {
 	DerivedConfigData
 	// IgnorePreflightErrors is a list of preflight errors to ignore
 	IgnorePreflightErrors []string
+	// Reset customizes the ResetConfiguration; it is supported only by kubeadm config version v1beta4
+	Reset ResetConfigData
+	// EtcdImageTag overrides the tag of the local etcd image, e.g. for testing etcd upgrades independently
+	// of Kubernetes; if empty, the etcd version corresponding to the Kubernetes version is used
+	EtcdImageTag string
+	// EtcdImageRepository overrides the repository of the local etcd image, e.g. for testing custom etcd builds
+	EtcdImageRepository string
+	// CoreDNSImageTag overrides the tag of the CoreDNS image, e.g. for testing CoreDNS upgrades independently
+	// of Kubernetes; if empty, the CoreDNS version corresponding to the Kubernetes version is used
+	CoreDNSImageTag string
+	// CoreDNSImageRepository overrides the repository of the CoreDNS image, e.g. for testing custom CoreDNS builds
+	CoreDNSImageRepository string
+	// SkipKubeProxy instructs kubeadm init to skip the kube-proxy addon, e.g. for testing CNI plugins replacing kube-proxy;
+	// with the v1beta4 kubeadm config version, the kube-proxy addon is skipped also by kubeadm upgrade
+	SkipKubeProxy bool
+	// CertSANs defines additional Subject Alternative Names for the API server serving certificate;
+	// they are added to the config using the patch returned by GetCertSANsPatch
+	CertSANs []string
+}
+
+// ResetConfigData defines the ResetConfiguration settings that can be customized, e.g. for testing
+// kubeadm reset edge cases; if not set, kubeadm defaults are used
+type ResetConfigData struct {
+	// CleanupTmpDir instructs kubeadm reset to cleanup the /etc/kubernetes/tmp directory
+	CleanupTmpDir bool
+	// CRISocket is the CRI socket used by kubeadm reset for cleaning up the containers
+	CRISocket string
+	// CertificatesDir is the directory where the certificates to be removed are stored
+	CertificatesDir string
+}
+
+// IsSet returns true if any of the ResetConfiguration settings that require the v1beta4 kubeadm config version
+// is customized; CRISocket is not considered, because with older config versions it can be passed to kubeadm reset
+// using the --cri-socket flag
+func (r ResetConfigData) IsSet() bool {
+	return r.CleanupTmpDir || r.CertificatesDir != ""
 }
 
 // DerivedConfigData fields are automatically derived by
@@ -117,11 +427,14 @@ type DerivedConfigData struct {
 	DockerStableTag string
 }
 
-// Derive automatically derives DockerStableTag if not specified
+// Derive automatically derives DockerStableTag and defaults DNSDomain if not specified
 func (c *ConfigData) Derive() {
 	if c.DockerStableTag == "" {
 		c.DockerStableTag = strings.Replace(c.KubernetesVersion, "+", "_", -1)
 	}
+	if c.DNSDomain == "" {
+		c.DNSDomain = DefaultDNSDomain
+	}
 }
 
 // See docs for these APIs at:
@@ -155,9 +468,25 @@ scheduler:
   - name: bind-address
     vaue: "::1"
   {{- end }}
+{{ if or .EtcdImageTag .EtcdImageRepository -}}
+etcd:
+  local:
+{{ if .EtcdImageRepository }}    imageRepository: "{{ .EtcdImageRepository }}"
+{{ end -}}
+{{ if .EtcdImageTag }}    imageTag: "{{ .EtcdImageTag }}"
+{{ end -}}
+{{ end -}}
+{{ if or .CoreDNSImageTag .CoreDNSImageRepository -}}
+dns:
+{{ if .CoreDNSImageRepository }}  imageRepository: "{{ .CoreDNSImageRepository }}"
+{{ end -}}
+{{ if .CoreDNSImageTag }}  imageTag: "{{ .CoreDNSImageTag }}"
+{{ end -}}
+{{ end -}}
 networking:
   podSubnet: "{{ .PodSubnet }}"
   serviceSubnet: "{{ .ServiceSubnet }}"
+  dnsDomain: "{{ .DNSDomain }}"
 {{ if .FeatureGateName -}}
 featureGates:
   {{ .FeatureGateName }}: {{ .FeatureGateValue }}
@@ -165,6 +494,10 @@ featureGates:
 ---
 apiVersion: kubeadm.k8s.io/v1beta4
 kind: InitConfiguration
+{{ if .SkipKubeProxy -}}
+skipPhases:
+- addon/kube-proxy
+{{ end -}}
 # we use a well know token for TLS bootstrap
 bootstrapTokens:
 - token: "{{ .Token }}"
@@ -220,6 +553,9 @@ node:
   {{end}}
   patches:
     directory: "/kinder/patches"
+{{ if .SkipKubeProxy }}  skipPhases:
+  - addon/kube-proxy
+{{ end -}}
 diff:
   kubernetesVersion: {{.UpgradeVersion}}
 apply:
@@ -232,10 +568,22 @@ apply:
   {{end}}
   patches:
     directory: "/kinder/patches"
+{{ if .SkipKubeProxy }}  skipPhases:
+  - addon/kube-proxy
+{{ end -}}
 ---
 apiVersion: kubeadm.k8s.io/v1beta4
 kind: ResetConfiguration
 force: true
+{{ if .Reset.CleanupTmpDir -}}
+cleanupTmpDir: true
+{{ end -}}
+{{ if .Reset.CRISocket -}}
+criSocket: "{{ .Reset.CRISocket }}"
+{{ end -}}
+{{ if .Reset.CertificatesDir -}}
+certificatesDir: "{{ .Reset.CertificatesDir }}"
+{{ end -}}
 ---
 apiVersion: kubelet.config.k8s.io/v1beta1
 kind: KubeletConfiguration
@@ -248,7 +596,11 @@ healthzBindAddress: "::"
 # kubelet will see the host disk that the inner container runtime
 # is ultimately backed by and attempt to recover disk space. we don't want that.
 imageGCHighThresholdPercent: 100
-failSwapOn: false
+failSwapOn: {{ .FailSwapOn }}
+{{ if .SwapBehavior -}}
+memorySwap:
+  swapBehavior: "{{ .SwapBehavior }}"
+{{ end -}}
 evictionHard:
   nodefs.available: "0%"
   nodefs.inodesFree: "0%"
@@ -256,6 +608,15 @@ evictionHard:
 # pin the cgroup driver to systemd.
 # this assumes that the CR on the node image is configured accordingly.
 cgroupDriver: "systemd"
+{{ if .ResolvConf -}}
+resolvConf: "{{ .ResolvConf }}"
+{{ end -}}
+{{ if .ClusterDNS -}}
+clusterDNS:
+{{ range .ClusterDNS -}}
+- "{{ . }}"
+{{ end -}}
+{{ end -}}
 ---
 # no-op entry that exists solely so it can be patched
 apiVersion: kubeproxy.config.k8s.io/v1alpha1
@@ -291,9 +652,25 @@ scheduler:
     address: "::"
     bind-address: "::1"
     {{- end }}
+{{ if or .EtcdImageTag .EtcdImageRepository -}}
+etcd:
+  local:
+{{ if .EtcdImageRepository }}    imageRepository: "{{ .EtcdImageRepository }}"
+{{ end -}}
+{{ if .EtcdImageTag }}    imageTag: "{{ .EtcdImageTag }}"
+{{ end -}}
+{{ end -}}
+{{ if or .CoreDNSImageTag .CoreDNSImageRepository -}}
+dns:
+{{ if .CoreDNSImageRepository }}  imageRepository: "{{ .CoreDNSImageRepository }}"
+{{ end -}}
+{{ if .CoreDNSImageTag }}  imageTag: "{{ .CoreDNSImageTag }}"
+{{ end -}}
+{{ end -}}
 networking:
   podSubnet: "{{ .PodSubnet }}"
   serviceSubnet: "{{ .ServiceSubnet }}"
+  dnsDomain: "{{ .DNSDomain }}"
 {{ if .FeatureGateName -}}
 featureGates:
   {{ .FeatureGateName }}: {{ .FeatureGateValue }}
@@ -301,6 +678,10 @@ featureGates:
 ---
 apiVersion: kubeadm.k8s.io/v1beta3
 kind: InitConfiguration
+{{ if .SkipKubeProxy -}}
+skipPhases:
+- addon/kube-proxy
+{{ end -}}
 # we use a well know token for TLS bootstrap
 bootstrapTokens:
 - token: "{{ .Token }}"
@@ -351,7 +732,11 @@ healthzBindAddress: "::"
 # kubelet will see the host disk that the inner container runtime
 # is ultimately backed by and attempt to recover disk space. we don't want that.
 imageGCHighThresholdPercent: 100
-failSwapOn: false
+failSwapOn: {{ .FailSwapOn }}
+{{ if .SwapBehavior -}}
+memorySwap:
+  swapBehavior: "{{ .SwapBehavior }}"
+{{ end -}}
 evictionHard:
   nodefs.available: "0%"
   nodefs.inodesFree: "0%"
@@ -359,6 +744,15 @@ evictionHard:
 # pin the cgroup driver to systemd.
 # this assumes that the CR on the node image is configured accordingly.
 cgroupDriver: "systemd"
+{{ if .ResolvConf -}}
+resolvConf: "{{ .ResolvConf }}"
+{{ end -}}
+{{ if .ClusterDNS -}}
+clusterDNS:
+{{ range .ClusterDNS -}}
+- "{{ . }}"
+{{ end -}}
+{{ end -}}
 ---
 # no-op entry that exists solely so it can be patched
 apiVersion: kubeproxy.config.k8s.io/v1alpha1
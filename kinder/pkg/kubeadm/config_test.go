@@ -0,0 +1,1032 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestRenderConfig(t *testing.T) {
+	data := ConfigData{
+		ClusterName:          "kinder",
+		KubernetesVersion:    "v1.31.0",
+		ControlPlaneEndpoint: "172.17.0.2:6443",
+		APIBindPort:          6443,
+		APIServerAddress:     "172.17.0.2",
+		ControlPlane:         true,
+		NodeAddress:          "172.17.0.2",
+		Token:                "abcdef.0123456789abcdef",
+		PodSubnet:            "192.168.0.0/16",
+		UpgradeVersion:       "v1.31.1",
+	}
+
+	tests := []struct {
+		name             string
+		configVersion    string
+		dnsDomain        string
+		resolvConf       string
+		clusterDNS       []string
+		reset            ResetConfigData
+		etcdImageTag     string
+		etcdImageRepo    string
+		corednsImageTag  string
+		corednsImageRepo string
+		skipKubeProxy    bool
+		failSwapOn       bool
+		swapBehavior     string
+		patches          []string
+		patches6902      []PatchJSON6902
+		expectedContains []string
+		expectedMissing  []string
+		golden           string
+		expectedError    bool
+	}{
+		{
+			name:          "valid: v1beta3 without patches",
+			configVersion: "v1beta3",
+			golden:        "v1beta3.golden",
+			expectedContains: []string{
+				"apiVersion: kubeadm.k8s.io/v1beta3",
+				"clusterName: kinder",
+				"kubernetesVersion: v1.31.0",
+				"advertiseAddress: 172.17.0.2",
+			},
+			expectedMissing: []string{
+				"kind: ResetConfiguration",
+			},
+		},
+		{
+			name:          "valid: v1beta4 without patches",
+			configVersion: "v1beta4",
+			golden:        "v1beta4.golden",
+			expectedContains: []string{
+				"apiVersion: kubeadm.k8s.io/v1beta4",
+				"kind: ResetConfiguration",
+				"podSubnet: 192.168.0.0/16",
+			},
+		},
+		{
+			name:          "valid: v1beta4 with merge patch",
+			configVersion: "v1beta4",
+			patches:       []string{mustGetFileDiscoveryPatch(t, "v1beta4")},
+			expectedContains: []string{
+				"kubeConfigPath: /kinder/discovery.conf",
+			},
+		},
+		{
+			name:          "valid: v1beta4 merge patches merge extraArgs by name",
+			configVersion: "v1beta4",
+			patches: []string{
+				"apiVersion: kubeadm.k8s.io/v1beta4\nkind: ClusterConfiguration\napiServer:\n  extraArgs:\n  - name: v\n    value: \"2\"\n",
+				"apiVersion: kubeadm.k8s.io/v1beta4\nkind: ClusterConfiguration\napiServer:\n  extraArgs:\n  - name: profiling\n    value: \"false\"\n    $patch: merge\n",
+			},
+			expectedContains: []string{
+				"  extraArgs:\n  - name: v\n    value: \"2\"\n  - name: profiling\n    value: \"false\"\n",
+			},
+			expectedMissing: []string{
+				"$patch",
+			},
+		},
+		{
+			name:          "valid: v1beta4 with json 6902 patch",
+			configVersion: "v1beta4",
+			patches6902:   []PatchJSON6902{mustGetRemoveTokenPatch(t, "v1beta4")},
+			golden:        "v1beta4-remove-token.golden",
+			expectedContains: []string{
+				"discovery: {}",
+				"token: abcdef.0123456789abcdef",
+			},
+			expectedMissing: []string{
+				"apiServerEndpoint: 172.17.0.2:6443",
+				"unsafeSkipCAVerification: true",
+			},
+		},
+		{
+			name:          "valid: v1beta4 with cri socket patch",
+			configVersion: "v1beta4",
+			patches:       mustGetCRISocketPatch(t, "v1beta4", "unix:///run/custom/containerd.sock"),
+			expectedContains: []string{
+				"criSocket: unix:///run/custom/containerd.sock",
+			},
+			expectedMissing: []string{
+				"criSocket: /run/containerd/containerd.sock",
+			},
+		},
+		{
+			name:          "valid: v1beta3 with dns domain",
+			configVersion: "v1beta3",
+			dnsDomain:     "cluster.internal",
+			expectedContains: []string{
+				"dnsDomain: cluster.internal",
+			},
+		},
+		{
+			name:          "valid: v1beta4 with dns domain",
+			configVersion: "v1beta4",
+			dnsDomain:     "cluster.internal",
+			expectedContains: []string{
+				"dnsDomain: cluster.internal",
+			},
+			expectedMissing: []string{
+				"dnsDomain: cluster.local",
+			},
+		},
+		{
+			name:          "valid: v1beta3 with kubelet resolvConf and clusterDNS",
+			configVersion: "v1beta3",
+			resolvConf:    "/etc/kinder/resolv.conf",
+			clusterDNS:    []string{"10.96.0.10", "fd00::10"},
+			expectedContains: []string{
+				"resolvConf: /etc/kinder/resolv.conf",
+				"clusterDNS:\n- 10.96.0.10\n- fd00::10\n",
+			},
+		},
+		{
+			name:          "valid: v1beta4 with kubelet clusterDNS",
+			configVersion: "v1beta4",
+			clusterDNS:    []string{"10.96.0.10"},
+			expectedContains: []string{
+				"clusterDNS:\n- 10.96.0.10\n",
+			},
+			expectedMissing: []string{
+				"resolvConf:",
+			},
+		},
+		{
+			name:          "invalid: v1beta4 with a kubelet clusterDNS that is not an IP",
+			configVersion: "v1beta4",
+			clusterDNS:    []string{"kube-dns"},
+			expectedError: true,
+		},
+		{
+			name:          "valid: v1beta4 with reset config",
+			configVersion: "v1beta4",
+			reset: ResetConfigData{
+				CleanupTmpDir:   true,
+				CRISocket:       "unix:///run/custom/containerd.sock",
+				CertificatesDir: "/etc/kinder/pki",
+			},
+			expectedContains: []string{
+				"apiVersion: kubeadm.k8s.io/v1beta4\ncertificatesDir: /etc/kinder/pki\ncleanupTmpDir: true\ncriSocket: unix:///run/custom/containerd.sock\nforce: true\nkind: ResetConfiguration\n",
+			},
+		},
+		{
+			name:          "valid: v1beta4 without reset config",
+			configVersion: "v1beta4",
+			expectedContains: []string{
+				"apiVersion: kubeadm.k8s.io/v1beta4\nforce: true\nkind: ResetConfiguration\n",
+			},
+			expectedMissing: []string{
+				"cleanupTmpDir:",
+				"certificatesDir:",
+			},
+		},
+		{
+			name:          "invalid: v1beta3 with reset config",
+			configVersion: "v1beta3",
+			reset:         ResetConfigData{CleanupTmpDir: true},
+			expectedError: true,
+		},
+		{
+			name:          "valid: v1beta3 with reset cri socket",
+			configVersion: "v1beta3",
+			reset:         ResetConfigData{CRISocket: "unix:///run/containerd/containerd.sock"},
+			expectedMissing: []string{
+				"kind: ResetConfiguration",
+			},
+		},
+		{
+			name:          "valid: v1beta3 with etcd image tag",
+			configVersion: "v1beta3",
+			etcdImageTag:  "3.5.15-0",
+			expectedContains: []string{
+				"etcd:\n  local:\n    imageTag: 3.5.15-0\n",
+			},
+		},
+		{
+			name:          "valid: v1beta4 with etcd image tag and repository",
+			configVersion: "v1beta4",
+			etcdImageTag:  "v3.6.0-rc.0",
+			etcdImageRepo: "gcr.io/etcd-development",
+			expectedContains: []string{
+				"etcd:\n  local:\n    imageRepository: gcr.io/etcd-development\n    imageTag: v3.6.0-rc.0\n",
+			},
+		},
+		{
+			name:          "valid: v1beta4 without etcd image tag",
+			configVersion: "v1beta4",
+			expectedMissing: []string{
+				"etcd:",
+			},
+		},
+		{
+			name:            "valid: v1beta3 with CoreDNS image tag",
+			configVersion:   "v1beta3",
+			corednsImageTag: "v1.11.3",
+			expectedContains: []string{
+				"dns:\n  imageTag: v1.11.3\n",
+			},
+		},
+		{
+			name:             "valid: v1beta4 with CoreDNS image tag and repository",
+			configVersion:    "v1beta4",
+			corednsImageTag:  "v1.12.0",
+			corednsImageRepo: "docker.io/coredns",
+			expectedContains: []string{
+				"dns:\n  imageRepository: docker.io/coredns\n  imageTag: v1.12.0\n",
+			},
+		},
+		{
+			name:          "valid: v1beta4 without CoreDNS image tag",
+			configVersion: "v1beta4",
+			expectedMissing: []string{
+				"dns:",
+			},
+		},
+		{
+			name:          "invalid: v1beta4 with an etcd image tag that is not a version",
+			configVersion: "v1beta4",
+			etcdImageTag:  "latest",
+			expectedError: true,
+		},
+		{
+			name:          "invalid: v1beta4 with an etcd v2 image tag",
+			configVersion: "v1beta4",
+			etcdImageTag:  "2.3.8",
+			expectedError: true,
+		},
+		{
+			name:          "valid: v1beta3 without kube-proxy",
+			configVersion: "v1beta3",
+			skipKubeProxy: true,
+			expectedContains: []string{
+				"skipPhases:\n- addon/kube-proxy\n",
+			},
+		},
+		{
+			name:          "valid: v1beta4 without kube-proxy",
+			configVersion: "v1beta4",
+			skipKubeProxy: true,
+			expectedContains: []string{
+				"skipPhases:\n- addon/kube-proxy\n",
+				"  skipPhases:\n  - addon/kube-proxy\ndiff:\n",
+				"  skipPhases:\n  - addon/kube-proxy\nplan:\n",
+			},
+		},
+		{
+			name:          "valid: v1beta4 with kube-proxy",
+			configVersion: "v1beta4",
+			expectedMissing: []string{
+				"skipPhases:",
+			},
+		},
+		{
+			name:          "valid: v1beta3 with swap",
+			configVersion: "v1beta3",
+			failSwapOn:    true,
+			swapBehavior:  "LimitedSwap",
+			expectedContains: []string{
+				"failSwapOn: true",
+				"memorySwap:\n  swapBehavior: LimitedSwap\n",
+			},
+		},
+		{
+			name:          "valid: v1beta4 without swap",
+			configVersion: "v1beta4",
+			expectedContains: []string{
+				"failSwapOn: false",
+			},
+			expectedMissing: []string{
+				"memorySwap:",
+			},
+		},
+		{
+			name:          "invalid: unknown swap behavior",
+			configVersion: "v1beta4",
+			swapBehavior:  "MaxSwap",
+			expectedError: true,
+		},
+		{
+			name:          "invalid: swap behavior removed from the kubelet version",
+			configVersion: "v1beta4",
+			swapBehavior:  "UnlimitedSwap",
+			expectedError: true,
+		},
+		{
+			name:          "invalid: unknown config version",
+			configVersion: "v1alpha1",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := data
+			data.DNSDomain = test.dnsDomain
+			data.ResolvConf = test.resolvConf
+			data.ClusterDNS = test.clusterDNS
+			data.Reset = test.reset
+			data.EtcdImageTag = test.etcdImageTag
+			data.EtcdImageRepository = test.etcdImageRepo
+			data.CoreDNSImageTag = test.corednsImageTag
+			data.CoreDNSImageRepository = test.corednsImageRepo
+			data.SkipKubeProxy = test.skipKubeProxy
+			data.FailSwapOn = test.failSwapOn
+			data.SwapBehavior = test.swapBehavior
+			config, err := RenderConfig(test.configVersion, data, test.patches, test.patches6902)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			for _, s := range test.expectedContains {
+				if !strings.Contains(config, s) {
+					t.Errorf("expected config to contain %q, got:\n%s", s, config)
+				}
+			}
+			for _, s := range test.expectedMissing {
+				if strings.Contains(config, s) {
+					t.Errorf("expected config to not contain %q, got:\n%s", s, config)
+				}
+			}
+			if test.golden != "" {
+				assertGolden(t, filepath.Join("testdata", test.golden), config)
+			}
+		})
+	}
+}
+
+func TestSupportedConfigVersions(t *testing.T) {
+	tests := []struct {
+		kubeadmVersion string
+		expected       []string
+	}{
+		{
+			kubeadmVersion: "v1.21.0",
+			expected:       []string{},
+		},
+		{
+			kubeadmVersion: "v1.22.0",
+			expected:       []string{"v1beta3"},
+		},
+		{
+			kubeadmVersion: "v1.30.2",
+			expected:       []string{"v1beta3"},
+		},
+		{
+			kubeadmVersion: "v1.31.0-alpha.0.100+78573805a7292a",
+			expected:       []string{"v1beta3", "v1beta4"},
+		},
+		{
+			kubeadmVersion: "v1.33.0",
+			expected:       []string{"v1beta3", "v1beta4"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.kubeadmVersion, func(t *testing.T) {
+			supported := SupportedConfigVersions(K8sVersion.MustParseSemantic(test.kubeadmVersion))
+			if !reflect.DeepEqual(supported, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, supported)
+			}
+		})
+	}
+}
+
+// TestRenderConfigSupportedVersions renders the kubeadm config for every kubeadm config version supported
+// by the newest kubeadm version, and compares it with the corresponding golden file, e.g. testdata/v1beta4.golden
+func TestRenderConfigSupportedVersions(t *testing.T) {
+	data := ConfigData{
+		ClusterName:          "kinder",
+		KubernetesVersion:    "v1.31.0",
+		ControlPlaneEndpoint: "172.17.0.2:6443",
+		APIBindPort:          6443,
+		APIServerAddress:     "172.17.0.2",
+		ControlPlane:         true,
+		NodeAddress:          "172.17.0.2",
+		Token:                "abcdef.0123456789abcdef",
+		PodSubnet:            "192.168.0.0/16",
+		UpgradeVersion:       "v1.31.1",
+	}
+
+	newest := kubeadmConfigVersions[len(kubeadmConfigVersions)-1]
+	supported := SupportedConfigVersions(minKubeadmVersionForConfigVersion[newest])
+	if !reflect.DeepEqual(supported, kubeadmConfigVersions) {
+		t.Fatalf("expected all the known kubeadm config versions %v to be supported, got %v", kubeadmConfigVersions, supported)
+	}
+
+	for _, configVersion := range supported {
+		t.Run(configVersion, func(t *testing.T) {
+			config, err := RenderConfig(configVersion, data, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to render config: %v", err)
+			}
+			assertGolden(t, filepath.Join("testdata", configVersion+".golden"), config)
+		})
+	}
+}
+
+func TestValidateKubeadmConfigVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		configVersion  string
+		kubeadmVersion string
+		expectedError  bool
+	}{
+		{
+			name:           "valid: v1beta3 with kubeadm v1.22",
+			configVersion:  "v1beta3",
+			kubeadmVersion: "v1.22.0",
+		},
+		{
+			name:           "valid: v1beta3 with kubeadm v1.31",
+			configVersion:  "v1beta3",
+			kubeadmVersion: "v1.31.0",
+		},
+		{
+			name:           "valid: v1beta4 with a kubeadm v1.31 pre-release",
+			configVersion:  "v1beta4",
+			kubeadmVersion: "v1.31.0-alpha.0.100+78573805a7292a",
+		},
+		{
+			name:           "invalid: v1beta4 with kubeadm v1.30",
+			configVersion:  "v1beta4",
+			kubeadmVersion: "v1.30.2",
+			expectedError:  true,
+		},
+		{
+			name:           "invalid: unknown config version",
+			configVersion:  "v1alpha3",
+			kubeadmVersion: "v1.31.0",
+			expectedError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateKubeadmConfigVersion(test.configVersion, K8sVersion.MustParseSemantic(test.kubeadmVersion))
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestHighestCommonConfigVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		a               string
+		b               string
+		expectedVersion string
+		expectedError   bool
+	}{
+		{
+			name:            "same kubeadm version",
+			a:               "v1.31.0",
+			b:               "v1.31.0",
+			expectedVersion: "v1beta4",
+		},
+		{
+			name:            "skewed kubeadm versions",
+			a:               "v1.31.0",
+			b:               "v1.30.2",
+			expectedVersion: "v1beta3",
+		},
+		{
+			name:            "skewed kubeadm versions, reverse order",
+			a:               "v1.30.2",
+			b:               "v1.32.0-alpha.0.100+78573805a7292a",
+			expectedVersion: "v1beta3",
+		},
+		{
+			name:          "no common config version",
+			a:             "v1.31.0",
+			b:             "v1.21.0",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			version, err := HighestCommonConfigVersion(K8sVersion.MustParseSemantic(test.a), K8sVersion.MustParseSemantic(test.b))
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if version != test.expectedVersion {
+				t.Fatalf("expected version: %q, got: %q", test.expectedVersion, version)
+			}
+		})
+	}
+}
+
+// fakeConfigVersionNode implements ConfigVersionNode for testing ResolveConfigVersion
+type fakeConfigVersionNode struct {
+	kubeadmVersion string
+}
+
+func (n fakeConfigVersionNode) Name() string { return "node" }
+
+func (n fakeConfigVersionNode) KubeadmVersion() (*K8sVersion.Version, error) {
+	if n.kubeadmVersion == "" {
+		return nil, errors.New("kubeadm not found")
+	}
+	return K8sVersion.MustParseSemantic(n.kubeadmVersion), nil
+}
+
+func TestResolveConfigVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		kubeadmVersion  string
+		override        string
+		expectedVersion string
+		expectedError   bool
+	}{
+		{
+			name:            "auto-detect v1beta3",
+			kubeadmVersion:  "v1.30.2",
+			expectedVersion: "v1beta3",
+		},
+		{
+			name:            "auto-detect v1beta4 with a kubeadm v1.31 pre-release",
+			kubeadmVersion:  "v1.31.0-alpha.0.100+78573805a7292a",
+			expectedVersion: "v1beta4",
+		},
+		{
+			name:            "auto-detect with an unsupported kubeadm falls back to the oldest config version",
+			kubeadmVersion:  "v1.21.0",
+			expectedVersion: "v1beta3",
+		},
+		{
+			name:            "valid override",
+			kubeadmVersion:  "v1.31.0",
+			override:        "v1beta3",
+			expectedVersion: "v1beta3",
+		},
+		{
+			name:           "override not supported by kubeadm",
+			kubeadmVersion: "v1.30.2",
+			override:       "v1beta4",
+			expectedError:  true,
+		},
+		{
+			name:           "unknown override",
+			kubeadmVersion: "v1.31.0",
+			override:       "v1alpha3",
+			expectedError:  true,
+		},
+		{
+			name:          "kubeadm version not available",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			version, _, err := ResolveConfigVersion(fakeConfigVersionNode{kubeadmVersion: test.kubeadmVersion}, test.override)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if version != test.expectedVersion {
+				t.Fatalf("expected version: %q, got: %q", test.expectedVersion, version)
+			}
+		})
+	}
+}
+
+func TestValidateIgnorePreflightErrors(t *testing.T) {
+	tests := []struct {
+		name                  string
+		ignorePreflightErrors []string
+		expectedError         bool
+	}{
+		{
+			name:                  "valid: kinder defaults",
+			ignorePreflightErrors: []string{"Swap", "SystemVerification", "FileContent--proc-sys-net-bridge-bridge-nf-call-iptables"},
+		},
+		{
+			name:                  "valid: names are case insensitive",
+			ignorePreflightErrors: []string{"numcpu", "port-6443", "ALL", "ContainerRuntimeVersion"},
+		},
+		{
+			name:                  "valid: unknown checks are passed through",
+			ignorePreflightErrors: []string{"Swap", "NoSuchCheck"},
+		},
+		{
+			name:                  "invalid: empty name",
+			ignorePreflightErrors: []string{"Swap", " "},
+			expectedError:         true,
+		},
+		{
+			name:                  "invalid: prefix without parameter",
+			ignorePreflightErrors: []string{"Port-"},
+			expectedError:         true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateIgnorePreflightErrors(test.ignorePreflightErrors)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateSwapBehavior(t *testing.T) {
+	tests := []struct {
+		name           string
+		swapBehavior   string
+		kubeletVersion string
+		expectedError  bool
+	}{
+		{
+			name:           "valid: kubelet default",
+			kubeletVersion: "v1.31.0",
+		},
+		{
+			name:           "valid: limited swap",
+			swapBehavior:   "LimitedSwap",
+			kubeletVersion: "v1.31.0",
+		},
+		{
+			name:           "valid: unlimited swap before v1.30",
+			swapBehavior:   "UnlimitedSwap",
+			kubeletVersion: "v1.29.5",
+		},
+		{
+			name:           "invalid: unlimited swap since v1.30",
+			swapBehavior:   "UnlimitedSwap",
+			kubeletVersion: "v1.30.0-alpha.0",
+			expectedError:  true,
+		},
+		{
+			name:          "invalid: unlimited swap with unknown kubelet version",
+			swapBehavior:  "UnlimitedSwap",
+			expectedError: true,
+		},
+		{
+			name:           "invalid: unknown swap behavior",
+			swapBehavior:   "MaxSwap",
+			kubeletVersion: "v1.29.5",
+			expectedError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var kubeletVersion *K8sVersion.Version
+			if test.kubeletVersion != "" {
+				kubeletVersion = K8sVersion.MustParseSemantic(test.kubeletVersion)
+			}
+			err := ValidateSwapBehavior(test.swapBehavior, kubeletVersion)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateResetConfigData(t *testing.T) {
+	tests := []struct {
+		name           string
+		reset          ResetConfigData
+		kubeadmVersion string
+		expectedError  bool
+	}{
+		{
+			name:           "valid: defaults are supported by any kubeadm version",
+			kubeadmVersion: "v1.30.0",
+		},
+		{
+			name: "valid: reset config supported by the kubeadm version",
+			reset: ResetConfigData{
+				CleanupTmpDir:   true,
+				CRISocket:       "unix:///run/containerd/containerd.sock",
+				CertificatesDir: "/etc/kubernetes/pki",
+			},
+			kubeadmVersion: "v1.31.0",
+		},
+		{
+			name:           "valid: cri socket is supported by any kubeadm version",
+			reset:          ResetConfigData{CRISocket: "unix:///run/containerd/containerd.sock"},
+			kubeadmVersion: "v1.30.0",
+		},
+		{
+			name:           "invalid: reset config not supported by the kubeadm version",
+			reset:          ResetConfigData{CleanupTmpDir: true},
+			kubeadmVersion: "v1.30.0",
+			expectedError:  true,
+		},
+		{
+			name:           "invalid: cri socket without scheme",
+			reset:          ResetConfigData{CRISocket: "/run/containerd/containerd.sock"},
+			kubeadmVersion: "v1.31.0",
+			expectedError:  true,
+		},
+		{
+			name:           "invalid: relative certificates dir",
+			reset:          ResetConfigData{CertificatesDir: "pki"},
+			kubeadmVersion: "v1.31.0",
+			expectedError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateResetConfigData(test.reset, K8sVersion.MustParseSemantic(test.kubeadmVersion))
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateDNSDomain(t *testing.T) {
+	tests := []struct {
+		name          string
+		dnsDomain     string
+		expectedError bool
+	}{
+		{
+			name:      "valid: default domain",
+			dnsDomain: "cluster.local",
+		},
+		{
+			name:      "valid: custom domain",
+			dnsDomain: "cluster.internal",
+		},
+		{
+			name:          "invalid: upper case",
+			dnsDomain:     "Cluster.Local",
+			expectedError: true,
+		},
+		{
+			name:          "invalid: trailing dot",
+			dnsDomain:     "cluster.local.",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateDNSDomain(test.dnsDomain)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateCRISocket(t *testing.T) {
+	tests := []struct {
+		name          string
+		criSocket     string
+		expectedError bool
+	}{
+		{
+			name:      "valid: unix socket",
+			criSocket: "unix:///run/containerd/containerd.sock",
+		},
+		{
+			name:          "invalid: missing scheme",
+			criSocket:     "/run/containerd/containerd.sock",
+			expectedError: true,
+		},
+		{
+			name:          "invalid: relative path",
+			criSocket:     "unix://containerd.sock",
+			expectedError: true,
+		},
+		{
+			name:          "invalid: unsupported scheme",
+			criSocket:     "npipe:////./pipe/containerd-containerd",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateCRISocket(test.criSocket)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestGetEncryptionAlgorithmPatch(t *testing.T) {
+	data := ConfigData{
+		ClusterName:       "kinder",
+		KubernetesVersion: "v1.33.0",
+		APIServerAddress:  "172.17.0.2",
+		NodeAddress:       "172.17.0.2",
+	}
+
+	tests := []struct {
+		name             string
+		configVersion    string
+		algorithm        string
+		kubeadmVersion   string
+		expectedContains string
+		expectedError    bool
+	}{
+		{
+			name:             "valid: v1beta4 with RSA-2048",
+			configVersion:    "v1beta4",
+			algorithm:        "RSA-2048",
+			kubeadmVersion:   "v1.31.0",
+			expectedContains: "encryptionAlgorithm: RSA-2048",
+		},
+		{
+			name:             "valid: v1beta4 with ECDSA-P521",
+			configVersion:    "v1beta4",
+			algorithm:        "ECDSA-P521",
+			kubeadmVersion:   "v1.33.0",
+			expectedContains: "encryptionAlgorithm: ECDSA-P521",
+		},
+		{
+			name:             "valid: v1beta4 with Ed25519 and a kubeadm pre-release",
+			configVersion:    "v1beta4",
+			algorithm:        "Ed25519",
+			kubeadmVersion:   "v1.33.0-alpha.1.20+6b1b7e3c1b2d3a",
+			expectedContains: "encryptionAlgorithm: Ed25519",
+		},
+		{
+			name:             "valid: algorithm name is case insensitive",
+			configVersion:    "v1beta4",
+			algorithm:        "ed25519",
+			kubeadmVersion:   "v1.33.0",
+			expectedContains: "encryptionAlgorithm: Ed25519",
+		},
+		{
+			name:           "invalid: v1beta4 with ECDSA-P384 and kubeadm v1.31",
+			configVersion:  "v1beta4",
+			algorithm:      "ECDSA-P384",
+			kubeadmVersion: "v1.31.3",
+			expectedError:  true,
+		},
+		{
+			name:           "invalid: v1beta3 with Ed25519",
+			configVersion:  "v1beta3",
+			algorithm:      "Ed25519",
+			kubeadmVersion: "v1.33.0",
+			expectedError:  true,
+		},
+		{
+			name:             "valid: unknown algorithm is passed to kubeadm as is",
+			configVersion:    "v1beta4",
+			algorithm:        "ML-DSA-65",
+			kubeadmVersion:   "v1.33.0",
+			expectedContains: "encryptionAlgorithm: ML-DSA-65",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patch, err := GetEncryptionAlgorithmPatch(test.configVersion, test.algorithm, K8sVersion.MustParseSemantic(test.kubeadmVersion))
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			config, err := RenderConfig(test.configVersion, data, []string{patch}, nil)
+			if err != nil {
+				t.Fatalf("failed to render config: %v", err)
+			}
+			if !strings.Contains(config, test.expectedContains) {
+				t.Errorf("expected config to contain %q, got:\n%s", test.expectedContains, config)
+			}
+		})
+	}
+}
+
+func TestRenderPatchFile(t *testing.T) {
+	data := ConfigData{
+		ClusterName:       "kinder",
+		KubernetesVersion: "v1.31.0",
+		NodeAddress:       "172.17.0.3",
+	}
+
+	tests := []struct {
+		name          string
+		fileName      string
+		content       string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "valid: merge patch with node address",
+			fileName: "kube-apiserver+merge.yaml",
+			content:  "metadata:\n  annotations:\n    node-address: {{ .NodeAddress }}\n",
+			expected: "metadata:\n  annotations:\n    node-address: 172.17.0.3\n",
+		},
+		{
+			name:     "valid: suffix and default patch type",
+			fileName: "kubeletconfiguration0.json",
+			content:  `{"clusterDomain": "{{ .ClusterName }}.local"}`,
+			expected: `{"clusterDomain": "kinder.local"}`,
+		},
+		{
+			name:          "invalid: unknown target",
+			fileName:      "kube-proxy+merge.yaml",
+			content:       "{}",
+			expectedError: true,
+		},
+		{
+			name:          "invalid: unknown patch type",
+			fileName:      "etcd+apply.yaml",
+			content:       "{}",
+			expectedError: true,
+		},
+		{
+			name:          "invalid: unknown extension",
+			fileName:      "etcd.yml",
+			content:       "{}",
+			expectedError: true,
+		},
+		{
+			name:          "invalid: unknown template field",
+			fileName:      "etcd.yaml",
+			content:       "{{ .Unknown }}",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patch, err := RenderPatchFile(test.fileName, test.content, data)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if patch != test.expected {
+				t.Errorf("expected patch %q, got %q", test.expected, patch)
+			}
+		})
+	}
+}
+
+// assertGolden compares the actual output with the content of a golden file;
+// golden files can be regenerated by running the tests with the -update flag.
+func assertGolden(t *testing.T, golden, actual string) {
+	if *update {
+		if err := os.WriteFile(golden, []byte(actual), 0644); err != nil {
+			t.Fatalf("failed to update golden file %s: %v", golden, err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read golden file %s: %v", golden, err)
+	}
+	if string(expected) != actual {
+		t.Errorf("output does not match golden file %s, expected:\n%s\ngot:\n%s", golden, expected, actual)
+	}
+}
+
+func mustGetFileDiscoveryPatch(t *testing.T, kubeadmConfigVersion string) string {
+	patch, err := GetFileDiscoveryPatch(kubeadmConfigVersion)
+	if err != nil {
+		t.Fatalf("failed to get the file discovery patch: %v", err)
+	}
+	return patch
+}
+
+func mustGetRemoveTokenPatch(t *testing.T, kubeadmConfigVersion string) PatchJSON6902 {
+	patch, err := GetRemoveTokenPatch(kubeadmConfigVersion)
+	if err != nil {
+		t.Fatalf("failed to get the remove token patch: %v", err)
+	}
+	return patch
+}
+
+func mustGetCRISocketPatch(t *testing.T, kubeadmConfigVersion, criSocket string) []string {
+	patches, err := GetCRISocketPatch(kubeadmConfigVersion, criSocket)
+	if err != nil {
+		t.Fatalf("failed to get the cri socket patch: %v", err)
+	}
+	return patches
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// criSocketScheme defines the URL scheme required for CRI socket paths
+const criSocketScheme = "unix://"
+
+// ValidateCRISocket checks if the CRI socket is formatted as unix:///path/to/socket
+func ValidateCRISocket(criSocket string) error {
+	if !strings.HasPrefix(criSocket, criSocketScheme+"/") {
+		return errors.Errorf("invalid CRI socket %q, it must be formatted as %s/path/to/socket", criSocket, criSocketScheme)
+	}
+	return nil
+}
+
+// GetCRISocketPatch returns the kubeadm config patches that will instruct kubeadm
+// to use a CRI socket different from the default one of the CRI installed on the node.
+func GetCRISocketPatch(kubeadmConfigVersion, criSocket string) ([]string, error) {
+	log.Debugf("Preparing criSocket patch for kubeadm config %s", kubeadmConfigVersion)
+
+	if err := ValidateCRISocket(criSocket); err != nil {
+		return nil, err
+	}
+
+	var basePatch string
+	switch kubeadmConfigVersion {
+	case "v1beta3":
+		basePatch = criSocketPatchv1beta3
+	case "v1beta4":
+		basePatch = criSocketPatchv1beta4
+	default:
+		return nil, errors.Errorf("unknown kubeadm config version: %s", kubeadmConfigVersion)
+	}
+
+	return []string{
+		fmt.Sprintf(basePatch, "InitConfiguration", criSocket),
+		fmt.Sprintf(basePatch, "JoinConfiguration", criSocket),
+	}, nil
+}
+
+const criSocketPatchv1beta3 = `apiVersion: kubeadm.k8s.io/v1beta3
+kind: %s
+nodeRegistration:
+  criSocket: %s`
+
+const criSocketPatchv1beta4 = `apiVersion: kubeadm.k8s.io/v1beta4
+kind: %s
+nodeRegistration:
+  criSocket: %s`
@@ -17,12 +17,31 @@ limitations under the License.
 package kubeadm
 
 import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
 	"fmt"
+	"net/url"
 
 	"github.com/pkg/errors"
 
 	log "github.com/sirupsen/logrus"
+	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
 	"k8s.io/kubeadm/kinder/pkg/constants"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// discoveryClusterName defines the name of the cluster in the discovery kubeconfig
+	discoveryClusterName = "kubernetes"
+
+	// DiscoveryUserName defines the name of the user in the discovery kubeconfig;
+	// the user has no credentials, and it can be augmented by the discovery modes
+	DiscoveryUserName = "discovery"
+
+	// discoveryContextName defines the name of the context in the discovery kubeconfig
+	discoveryContextName = "discovery"
 )
 
 // GetRemoveTokenPatch returns the kubeadm config patch that will instruct kubeadm
@@ -118,3 +137,63 @@ const tlsBootstrapPatchv1beta4 = `apiVersion: kubeadm.k8s.io/v1beta4
 kind: JoinConfiguration
 discovery:
   tlsBootstrapToken: %s`
+
+// CACertHash returns the hash of the public key of a PEM encoded CA certificate in the format used by kubeadm
+// for --discovery-token-ca-cert-hash, that is "sha256:" followed by the hex encoded SHA-256 of the
+// DER encoded SubjectPublicKeyInfo of the certificate
+func CACertHash(caCert []byte) (string, error) {
+	block, _ := pem.Decode(caCert)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", errors.New("failed to decode the PEM encoded CA certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the CA certificate")
+	}
+
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256:" + hex.EncodeToString(hash[:]), nil
+}
+
+// BuildDiscoveryKubeconfig returns a minimal kubeconfig to be used as a discovery file, containing only the
+// cluster CA and the server address, e.g. https://172.17.0.2:6443; the kubeconfig defines a DiscoveryUserName
+// user without credentials, that can be augmented by the discovery modes, e.g. with a token.
+func BuildDiscoveryKubeconfig(caData []byte, server string) ([]byte, error) {
+	if block, _ := pem.Decode(caData); block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("failed to decode the PEM encoded CA certificate")
+	}
+	u, err := url.Parse(server)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, errors.Errorf("invalid server %q, it must be an https URL", server)
+	}
+
+	config := clientcmdapiv1.Config{
+		APIVersion: "v1",
+		Kind:       "Config",
+		Clusters: []clientcmdapiv1.NamedCluster{{
+			Name: discoveryClusterName,
+			Cluster: clientcmdapiv1.Cluster{
+				Server:                   server,
+				CertificateAuthorityData: caData,
+			},
+		}},
+		AuthInfos: []clientcmdapiv1.NamedAuthInfo{{
+			Name: DiscoveryUserName,
+		}},
+		Contexts: []clientcmdapiv1.NamedContext{{
+			Name: discoveryContextName,
+			Context: clientcmdapiv1.Context{
+				Cluster:  discoveryClusterName,
+				AuthInfo: DiscoveryUserName,
+			},
+		}},
+		CurrentContext: discoveryContextName,
+	}
+
+	kubeconfig, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode the discovery kubeconfig")
+	}
+	return kubeconfig, nil
+}
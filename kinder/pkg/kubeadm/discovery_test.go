@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestCACertHash(t *testing.T) {
+	// NB. the expected hash was computed with:
+	// openssl x509 -pubkey -in ca.crt | openssl rsa -pubin -outform der | sha256sum
+	caCert, err := os.ReadFile(filepath.Join("testdata", "ca.crt"))
+	if err != nil {
+		t.Fatalf("failed to read the CA certificate: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		caCert        []byte
+		expectedHash  string
+		expectedError bool
+	}{
+		{
+			name:         "valid CA certificate",
+			caCert:       caCert,
+			expectedHash: "sha256:243ab482f64b5ee2a09985a7c278d56c79024af68e174468b07ecfe902db88f3",
+		},
+		{
+			name:          "not a PEM encoded certificate",
+			caCert:        []byte("not a certificate"),
+			expectedError: true,
+		},
+		{
+			name:          "invalid certificate",
+			caCert:        []byte("-----BEGIN CERTIFICATE-----\nbm90IGEgY2VydGlmaWNhdGU=\n-----END CERTIFICATE-----\n"),
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hash, err := CACertHash(test.caCert)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if hash != test.expectedHash {
+				t.Errorf("expected hash: %q, got: %q", test.expectedHash, hash)
+			}
+		})
+	}
+}
+
+func TestBuildDiscoveryKubeconfig(t *testing.T) {
+	caCert, err := os.ReadFile(filepath.Join("testdata", "ca.crt"))
+	if err != nil {
+		t.Fatalf("failed to read the CA certificate: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		caData        []byte
+		server        string
+		expectedError bool
+	}{
+		{
+			name:   "valid CA certificate and server",
+			caData: caCert,
+			server: "https://172.17.0.2:6443",
+		},
+		{
+			name:          "not a PEM encoded certificate",
+			caData:        []byte("not a certificate"),
+			server:        "https://172.17.0.2:6443",
+			expectedError: true,
+		},
+		{
+			name:          "server without scheme",
+			caData:        caCert,
+			server:        "172.17.0.2:6443",
+			expectedError: true,
+		},
+		{
+			name:          "http server",
+			caData:        caCert,
+			server:        "http://172.17.0.2:6443",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kubeconfig, err := BuildDiscoveryKubeconfig(test.caData, test.server)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			config, err := clientcmd.Load(kubeconfig)
+			if err != nil {
+				t.Fatalf("failed to parse the discovery kubeconfig: %v", err)
+			}
+			context, ok := config.Contexts[config.CurrentContext]
+			if !ok {
+				t.Fatalf("expected current context %q to exist", config.CurrentContext)
+			}
+			cluster := config.Clusters[context.Cluster]
+			if cluster == nil || cluster.Server != test.server || !bytes.Equal(cluster.CertificateAuthorityData, test.caData) {
+				t.Errorf("unexpected cluster: %+v", cluster)
+			}
+			if context.AuthInfo != DiscoveryUserName {
+				t.Errorf("expected user %q, got %q", DiscoveryUserName, context.AuthInfo)
+			}
+			authInfo := config.AuthInfos[context.AuthInfo]
+			if authInfo == nil || authInfo.Token != "" || len(authInfo.ClientCertificateData) > 0 || len(authInfo.ClientKeyData) > 0 {
+				t.Errorf("expected a user without credentials, got %+v", authInfo)
+			}
+		})
+	}
+}
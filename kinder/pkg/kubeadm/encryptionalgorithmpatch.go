@@ -18,14 +18,44 @@ package kubeadm
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
 )
 
+// encryptionAlgorithm defines an encryption algorithm supported by kubeadm
+type encryptionAlgorithm struct {
+	// value is the value expected by kubeadm in ClusterConfiguration.encryptionAlgorithm
+	value string
+	// minKubeadmVersion is the minimum kubeadm version supporting the encryption algorithm;
+	// if nil, the kubeadm version is not checked by kinder and the value is validated by kubeadm only
+	minKubeadmVersion *K8sVersion.Version
+}
+
+// encryptionAlgorithms defines the encryption algorithms known by kinder;
+// the map is keyed by the upper case name of the algorithm, so the value passed to kinder is case insensitive.
+// RSA-* and ECDSA-P256 were introduced in kubeadm v1.31 together with v1beta4, ECDSA-P384 in kubeadm v1.32
+// (see CHANGELOG-1.31.md and CHANGELOG-1.32.md); ECDSA-P521 and Ed25519 are mapped to the value expected
+// by kubeadm, but they are not gated on a kubeadm version, so unsupported versions are reported by kubeadm.
+var encryptionAlgorithms = map[string]encryptionAlgorithm{
+	"RSA-2048":   {value: "RSA-2048", minKubeadmVersion: K8sVersion.MustParseSemantic("v1.31.0-0")},
+	"RSA-3072":   {value: "RSA-3072", minKubeadmVersion: K8sVersion.MustParseSemantic("v1.31.0-0")},
+	"RSA-4096":   {value: "RSA-4096", minKubeadmVersion: K8sVersion.MustParseSemantic("v1.31.0-0")},
+	"ECDSA-P256": {value: "ECDSA-P256", minKubeadmVersion: K8sVersion.MustParseSemantic("v1.31.0-0")},
+	"ECDSA-P384": {value: "ECDSA-P384", minKubeadmVersion: K8sVersion.MustParseSemantic("v1.32.0-0")},
+	"ECDSA-P521": {value: "ECDSA-P521"},
+	"ED25519":    {value: "Ed25519"},
+}
+
 // GetEncryptionAlgorithmPatch returns the kubeadm config patch that will instruct kubeadm
-// to use a specific encryption algorithm
-func GetEncryptionAlgorithmPatch(kubeadmConfigVersion string, algorithm string) (string, error) {
+// to use a specific encryption algorithm; the algorithm name is case insensitive, and an error
+// is returned if the algorithm is known to be not supported by the given kubeadm version.
+// Unknown algorithms are passed to kubeadm as is, so kinder can be used for testing new algorithms.
+func GetEncryptionAlgorithmPatch(kubeadmConfigVersion string, algorithm string, kubeadmVersion *K8sVersion.Version) (string, error) {
 	var patch string
 	log.Debugf("Preparing encryptionAlgorithm patch for kubeadm config %s", kubeadmConfigVersion)
 
@@ -38,7 +68,31 @@ func GetEncryptionAlgorithmPatch(kubeadmConfigVersion string, algorithm string)
 		return "", errors.Errorf("unknown kubeadm config version: %s", kubeadmConfigVersion)
 	}
 
-	return fmt.Sprintf(patch, algorithm), nil
+	value, err := getEncryptionAlgorithm(algorithm, kubeadmVersion)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(patch, value), nil
+}
+
+// getEncryptionAlgorithm returns the value expected by kubeadm for the given encryption algorithm
+func getEncryptionAlgorithm(algorithm string, kubeadmVersion *K8sVersion.Version) (string, error) {
+	a, ok := encryptionAlgorithms[strings.ToUpper(algorithm)]
+	if !ok {
+		known := []string{}
+		for _, a := range encryptionAlgorithms {
+			known = append(known, a.value)
+		}
+		sort.Strings(known)
+		log.Warnf("Unknown encryption algorithm %q, known values are %s; the value is passed to kubeadm as is", algorithm, strings.Join(known, ", "))
+		return algorithm, nil
+	}
+	if a.minKubeadmVersion != nil && !kubeadmVersion.AtLeast(a.minKubeadmVersion) {
+		return "", errors.Errorf("encryption algorithm %s is not supported by kubeadm v%s, it requires kubeadm v%d.%d or greater",
+			a.value, kubeadmVersion, a.minKubeadmVersion.Major(), a.minKubeadmVersion.Minor())
+	}
+	return a.value, nil
 }
 
 const encryptionAlgorithmPatchV1beta4 = `apiVersion: kubeadm.k8s.io/v1beta4
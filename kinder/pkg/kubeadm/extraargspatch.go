@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+// ExtraVolume defines a hostPath volume to be mounted into the static pod of a control-plane component
+type ExtraVolume struct {
+	Name      string `json:"name"`
+	HostPath  string `json:"hostPath"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+	PathType  string `json:"pathType,omitempty"`
+}
+
+// GetControlPlaneComponentPatch returns the strategic merge patch for the ClusterConfiguration that adds
+// extra args and extra volumes to a control-plane component, e.g. apiServer.
+// NB. the patch should be applied with BuildWithStrategicMerge, so in v1beta4 extra args are merged by name
+// with the existing ones.
+func GetControlPlaneComponentPatch(kubeadmConfigVersion, component string, extraArgs map[string]string, extraVolumes []ExtraVolume) (string, error) {
+	log.Debugf("Preparing %s extra args and extra volumes patch for kubeadm config %s", component, kubeadmConfigVersion)
+
+	switch component {
+	case "apiServer", "controllerManager", "scheduler":
+	default:
+		return "", errors.Errorf("unknown control-plane component: %s", component)
+	}
+
+	settings := map[string]interface{}{}
+	switch kubeadmConfigVersion {
+	case "v1beta3":
+		if len(extraArgs) > 0 {
+			settings["extraArgs"] = extraArgs
+		}
+	case "v1beta4":
+		// in v1beta4 extra args are a list of name/value pairs; sort them, so the output is stable
+		names := []string{}
+		for name := range extraArgs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		args := []map[string]string{}
+		for _, name := range names {
+			args = append(args, map[string]string{"name": name, "value": extraArgs[name]})
+		}
+		if len(args) > 0 {
+			settings["extraArgs"] = args
+		}
+	default:
+		return "", errors.Errorf("unknown kubeadm config version: %s", kubeadmConfigVersion)
+	}
+	if len(extraVolumes) > 0 {
+		settings["extraVolumes"] = extraVolumes
+	}
+
+	patch, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "kubeadm.k8s.io/" + kubeadmConfigVersion,
+		"kind":       "ClusterConfiguration",
+		component:    settings,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal the %s patch", component)
+	}
+	return string(patch), nil
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParsedConfig defines a kubeadm config parsed into typed objects, so callers can assert on
+// fields programmatically; kinds not included in the kubeadm config are nil.
+// NB. kinder does not depend on k8s.io/kubeadm and k8s.io/kubelet, so the types defined here
+// include only the subset of the fields that is relevant for kinder.
+type ParsedConfig struct {
+	// ConfigVersion is the version of the kubeadm config API used by the kubeadm config, e.g. v1beta4
+	ConfigVersion string
+
+	ClusterConfiguration *ClusterConfiguration
+	InitConfiguration    *InitConfiguration
+	JoinConfiguration    *JoinConfiguration
+	KubeletConfiguration *KubeletConfiguration
+}
+
+// ClusterConfiguration defines the subset of the kubeadm ClusterConfiguration that is relevant for kinder
+type ClusterConfiguration struct {
+	KubernetesVersion    string                `json:"kubernetesVersion,omitempty"`
+	ClusterName          string                `json:"clusterName,omitempty"`
+	ControlPlaneEndpoint string                `json:"controlPlaneEndpoint,omitempty"`
+	APIServer            APIServer             `json:"apiServer,omitempty"`
+	ControllerManager    ControlPlaneComponent `json:"controllerManager,omitempty"`
+	Scheduler            ControlPlaneComponent `json:"scheduler,omitempty"`
+	Etcd                 Etcd                  `json:"etcd,omitempty"`
+	DNS                  DNS                   `json:"dns,omitempty"`
+	Networking           Networking            `json:"networking,omitempty"`
+	ImageRepository      string                `json:"imageRepository,omitempty"`
+	CertificatesDir      string                `json:"certificatesDir,omitempty"`
+	EncryptionAlgorithm  string                `json:"encryptionAlgorithm,omitempty"`
+	FeatureGates         map[string]bool       `json:"featureGates,omitempty"`
+}
+
+// ControlPlaneComponent defines the settings of a control-plane component
+type ControlPlaneComponent struct {
+	ExtraArgs    Args          `json:"extraArgs,omitempty"`
+	ExtraVolumes []ExtraVolume `json:"extraVolumes,omitempty"`
+}
+
+// APIServer defines the settings of the API server
+type APIServer struct {
+	ControlPlaneComponent
+	CertSANs []string `json:"certSANs,omitempty"`
+}
+
+// Etcd defines the settings of etcd, either local or external
+type Etcd struct {
+	Local    *LocalEtcd    `json:"local,omitempty"`
+	External *ExternalEtcd `json:"external,omitempty"`
+}
+
+// LocalEtcd defines the settings of the etcd instances managed by kubeadm
+type LocalEtcd struct {
+	ImageRepository string `json:"imageRepository,omitempty"`
+	ImageTag        string `json:"imageTag,omitempty"`
+	DataDir         string `json:"dataDir,omitempty"`
+	ExtraArgs       Args   `json:"extraArgs,omitempty"`
+}
+
+// ExternalEtcd defines the settings of an external etcd cluster
+type ExternalEtcd struct {
+	Endpoints []string `json:"endpoints,omitempty"`
+	CAFile    string   `json:"caFile,omitempty"`
+	CertFile  string   `json:"certFile,omitempty"`
+	KeyFile   string   `json:"keyFile,omitempty"`
+}
+
+// DNS defines the settings of the DNS addon
+type DNS struct {
+	ImageRepository string `json:"imageRepository,omitempty"`
+	ImageTag        string `json:"imageTag,omitempty"`
+}
+
+// Networking defines the networking settings of the cluster
+type Networking struct {
+	ServiceSubnet string `json:"serviceSubnet,omitempty"`
+	PodSubnet     string `json:"podSubnet,omitempty"`
+	DNSDomain     string `json:"dnsDomain,omitempty"`
+}
+
+// InitConfiguration defines the subset of the kubeadm InitConfiguration that is relevant for kinder
+type InitConfiguration struct {
+	BootstrapTokens  []BootstrapToken `json:"bootstrapTokens,omitempty"`
+	NodeRegistration NodeRegistration `json:"nodeRegistration,omitempty"`
+	LocalAPIEndpoint APIEndpoint      `json:"localAPIEndpoint,omitempty"`
+	CertificateKey   string           `json:"certificateKey,omitempty"`
+	SkipPhases       []string         `json:"skipPhases,omitempty"`
+	Patches          *Patches         `json:"patches,omitempty"`
+}
+
+// JoinConfiguration defines the subset of the kubeadm JoinConfiguration that is relevant for kinder
+type JoinConfiguration struct {
+	NodeRegistration NodeRegistration  `json:"nodeRegistration,omitempty"`
+	CACertPath       string            `json:"caCertPath,omitempty"`
+	Discovery        Discovery         `json:"discovery,omitempty"`
+	ControlPlane     *JoinControlPlane `json:"controlPlane,omitempty"`
+	SkipPhases       []string          `json:"skipPhases,omitempty"`
+	Patches          *Patches          `json:"patches,omitempty"`
+}
+
+// BootstrapToken defines a bootstrap token
+type BootstrapToken struct {
+	Token  string   `json:"token"`
+	TTL    string   `json:"ttl,omitempty"`
+	Usages []string `json:"usages,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// NodeRegistration defines the settings for registering a node
+type NodeRegistration struct {
+	Name                  string   `json:"name,omitempty"`
+	CRISocket             string   `json:"criSocket,omitempty"`
+	KubeletExtraArgs      Args     `json:"kubeletExtraArgs,omitempty"`
+	IgnorePreflightErrors []string `json:"ignorePreflightErrors,omitempty"`
+}
+
+// APIEndpoint defines the endpoint of an API server instance
+type APIEndpoint struct {
+	AdvertiseAddress string `json:"advertiseAddress,omitempty"`
+	BindPort         int32  `json:"bindPort,omitempty"`
+}
+
+// JoinControlPlane defines the settings for joining a control-plane node
+type JoinControlPlane struct {
+	LocalAPIEndpoint APIEndpoint `json:"localAPIEndpoint,omitempty"`
+	CertificateKey   string      `json:"certificateKey,omitempty"`
+}
+
+// Discovery defines the settings for discovering the cluster when joining a node
+type Discovery struct {
+	BootstrapToken    *BootstrapTokenDiscovery `json:"bootstrapToken,omitempty"`
+	File              *FileDiscovery           `json:"file,omitempty"`
+	TLSBootstrapToken string                   `json:"tlsBootstrapToken,omitempty"`
+}
+
+// BootstrapTokenDiscovery defines the settings for the bootstrap token discovery
+type BootstrapTokenDiscovery struct {
+	Token                    string   `json:"token"`
+	APIServerEndpoint        string   `json:"apiServerEndpoint,omitempty"`
+	CACertHashes             []string `json:"caCertHashes,omitempty"`
+	UnsafeSkipCAVerification bool     `json:"unsafeSkipCAVerification,omitempty"`
+}
+
+// FileDiscovery defines the settings for the file discovery
+type FileDiscovery struct {
+	KubeConfigPath string `json:"kubeConfigPath"`
+}
+
+// Patches defines the settings for the kubeadm patches
+type Patches struct {
+	Directory string `json:"directory,omitempty"`
+}
+
+// KubeletConfiguration defines the subset of the kubelet config that is relevant for kinder,
+// e.g. for asserting on the KubeletConfiguration generated by kubeadm, including patches.
+type KubeletConfiguration struct {
+	APIVersion                  string            `json:"apiVersion"`
+	Kind                        string            `json:"kind"`
+	Address                     string            `json:"address,omitempty"`
+	HealthzBindAddress          string            `json:"healthzBindAddress,omitempty"`
+	StaticPodPath               string            `json:"staticPodPath,omitempty"`
+	CgroupDriver                string            `json:"cgroupDriver,omitempty"`
+	ClusterDomain               string            `json:"clusterDomain,omitempty"`
+	ClusterDNS                  []string          `json:"clusterDNS,omitempty"`
+	ResolvConf                  string            `json:"resolvConf,omitempty"`
+	RotateCertificates          bool              `json:"rotateCertificates,omitempty"`
+	ServerTLSBootstrap          bool              `json:"serverTLSBootstrap,omitempty"`
+	ContainerLogMaxSize         string            `json:"containerLogMaxSize,omitempty"`
+	ContainerLogMaxFiles        *int32            `json:"containerLogMaxFiles,omitempty"`
+	ImageGCHighThresholdPercent *int32            `json:"imageGCHighThresholdPercent,omitempty"`
+	EvictionHard                map[string]string `json:"evictionHard,omitempty"`
+	FailSwapOn                  *bool             `json:"failSwapOn,omitempty"`
+	MemorySwap                  KubeletMemorySwap `json:"memorySwap,omitempty"`
+	FeatureGates                map[string]bool   `json:"featureGates,omitempty"`
+}
+
+// KubeletMemorySwap defines the swap settings of the kubelet
+type KubeletMemorySwap struct {
+	SwapBehavior string `json:"swapBehavior,omitempty"`
+}
+
+// Args defines the extra args of a component. In v1beta3 extra args are a map, while in v1beta4
+// they are a list of name/value pairs; both forms are parsed into a map, and for repeated names in
+// v1beta4 the last value wins.
+type Args map[string]string
+
+// UnmarshalJSON implements json.Unmarshaler for Args
+func (a *Args) UnmarshalJSON(data []byte) error {
+	m := map[string]string{}
+	if err := json.Unmarshal(data, &m); err == nil {
+		*a = m
+		return nil
+	}
+
+	list := []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.Errorf("extra args must be a map or a list of name/value pairs: %s", string(data))
+	}
+	for _, arg := range list {
+		m[arg.Name] = arg.Value
+	}
+	*a = m
+	return nil
+}
+
+// ParseConfig parses a kubeadm config, e.g. the output of RenderConfig or the kubeadm config file on a node,
+// into typed objects. All the documents of the kubeadm config API must use the same, known kubeadm config version;
+// component configs other than the KubeletConfiguration and unknown kinds are ignored.
+func ParseConfig(yaml string) (*ParsedConfig, error) {
+	resources, err := parseResources(yaml)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the kubeadm config")
+	}
+
+	config := &ParsedConfig{}
+	for _, r := range resources {
+		if r.matchInfo.Kind == "" {
+			// e.g. a document with comments only
+			continue
+		}
+
+		var obj interface{}
+		groupVersion := strings.SplitN(r.matchInfo.APIVersion, "/", 2)
+		if groupVersion[0] == kubeadmAPIGroup {
+			if len(groupVersion) != 2 {
+				return nil, errors.Errorf("invalid apiVersion %q for %s", r.matchInfo.APIVersion, r.matchInfo.Kind)
+			}
+			version := groupVersion[1]
+			if _, ok := configFields[version]; !ok {
+				return nil, errors.Errorf("unknown kubeadm config version: %s", version)
+			}
+			if config.ConfigVersion != "" && config.ConfigVersion != version {
+				return nil, errors.Errorf("%s: apiVersion %s does not match the kubeadm config version %s", r.matchInfo.Kind, r.matchInfo.APIVersion, config.ConfigVersion)
+			}
+			config.ConfigVersion = version
+
+			switch r.matchInfo.Kind {
+			case "ClusterConfiguration":
+				if config.ClusterConfiguration != nil {
+					return nil, errors.New("the kubeadm config contains more than one ClusterConfiguration")
+				}
+				config.ClusterConfiguration = &ClusterConfiguration{}
+				obj = config.ClusterConfiguration
+			case "InitConfiguration":
+				if config.InitConfiguration != nil {
+					return nil, errors.New("the kubeadm config contains more than one InitConfiguration")
+				}
+				config.InitConfiguration = &InitConfiguration{}
+				obj = config.InitConfiguration
+			case "JoinConfiguration":
+				if config.JoinConfiguration != nil {
+					return nil, errors.New("the kubeadm config contains more than one JoinConfiguration")
+				}
+				config.JoinConfiguration = &JoinConfiguration{}
+				obj = config.JoinConfiguration
+			}
+		} else if r.matchInfo.Kind == "KubeletConfiguration" {
+			if config.KubeletConfiguration != nil {
+				return nil, errors.New("the kubeadm config contains more than one KubeletConfiguration")
+			}
+			config.KubeletConfiguration = &KubeletConfiguration{}
+			obj = config.KubeletConfiguration
+		}
+		if obj == nil {
+			continue
+		}
+
+		if err := json.Unmarshal(r.json, obj); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the %s document", r.matchInfo.Kind)
+		}
+	}
+
+	if config.ConfigVersion == "" {
+		return nil, errors.New("the kubeadm config does not contain documents of the kubeadm config API")
+	}
+	return config, nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	data := ConfigData{
+		ClusterName:          "kinder",
+		KubernetesVersion:    "v1.31.0",
+		ControlPlaneEndpoint: "172.17.0.2:6443",
+		APIBindPort:          6443,
+		APIServerAddress:     "172.17.0.2",
+		ControlPlane:         true,
+		NodeAddress:          "172.17.0.2",
+		Token:                "abcdef.0123456789abcdef",
+		PodSubnet:            "192.168.0.0/16",
+		UpgradeVersion:       "v1.31.1",
+		EtcdImageTag:         "3.5.15-0",
+		CoreDNSImageTag:      "v1.11.3",
+		SkipKubeProxy:        true,
+		ClusterDNS:           []string{"10.96.0.10"},
+	}
+
+	for _, configVersion := range []string{"v1beta3", "v1beta4"} {
+		t.Run(configVersion, func(t *testing.T) {
+			rendered, err := RenderConfig(configVersion, data, nil, nil)
+			if err != nil {
+				t.Fatalf("unexpected error rendering the config: %v", err)
+			}
+
+			config, err := ParseConfig(rendered)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if config.ConfigVersion != configVersion {
+				t.Errorf("expected config version %s, got %s", configVersion, config.ConfigVersion)
+			}
+
+			cluster := config.ClusterConfiguration
+			if cluster == nil {
+				t.Fatal("expected a ClusterConfiguration")
+			}
+			if cluster.ClusterName != "kinder" || cluster.KubernetesVersion != "v1.31.0" {
+				t.Errorf("unexpected ClusterConfiguration: %+v", cluster)
+			}
+			if cluster.Networking.PodSubnet != "192.168.0.0/16" {
+				t.Errorf("expected podSubnet 192.168.0.0/16, got %q", cluster.Networking.PodSubnet)
+			}
+			if !reflect.DeepEqual(cluster.APIServer.CertSANs, []string{"localhost", "172.17.0.2"}) {
+				t.Errorf("unexpected certSANs: %v", cluster.APIServer.CertSANs)
+			}
+			if cluster.Etcd.Local == nil || cluster.Etcd.Local.ImageTag != "3.5.15-0" {
+				t.Errorf("expected etcd imageTag 3.5.15-0, got %+v", cluster.Etcd.Local)
+			}
+			if cluster.DNS.ImageTag != "v1.11.3" {
+				t.Errorf("expected dns imageTag v1.11.3, got %q", cluster.DNS.ImageTag)
+			}
+
+			init := config.InitConfiguration
+			if init == nil {
+				t.Fatal("expected an InitConfiguration")
+			}
+			if init.LocalAPIEndpoint != (APIEndpoint{AdvertiseAddress: "172.17.0.2", BindPort: 6443}) {
+				t.Errorf("unexpected localAPIEndpoint: %+v", init.LocalAPIEndpoint)
+			}
+			if !reflect.DeepEqual(init.SkipPhases, []string{"addon/kube-proxy"}) {
+				t.Errorf("unexpected skipPhases: %v", init.SkipPhases)
+			}
+			// extra args are parsed into a map for both the v1beta3 and the v1beta4 format
+			if ip := init.NodeRegistration.KubeletExtraArgs["node-ip"]; ip != "172.17.0.2" {
+				t.Errorf("expected kubelet extra arg node-ip=172.17.0.2, got %q", ip)
+			}
+
+			join := config.JoinConfiguration
+			if join == nil {
+				t.Fatal("expected a JoinConfiguration")
+			}
+			if join.ControlPlane == nil || join.ControlPlane.LocalAPIEndpoint.BindPort != 6443 {
+				t.Errorf("unexpected controlPlane: %+v", join.ControlPlane)
+			}
+			if join.Discovery.BootstrapToken == nil || join.Discovery.BootstrapToken.APIServerEndpoint != "172.17.0.2:6443" {
+				t.Errorf("unexpected discovery: %+v", join.Discovery)
+			}
+
+			kubelet := config.KubeletConfiguration
+			if kubelet == nil {
+				t.Fatal("expected a KubeletConfiguration")
+			}
+			if kubelet.CgroupDriver != "systemd" || kubelet.FailSwapOn == nil || *kubelet.FailSwapOn {
+				t.Errorf("unexpected KubeletConfiguration: %+v", kubelet)
+			}
+			if !reflect.DeepEqual(kubelet.ClusterDNS, []string{"10.96.0.10"}) {
+				t.Errorf("unexpected clusterDNS: %v", kubelet.ClusterDNS)
+			}
+		})
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{
+			name:   "invalid: unknown kubeadm config version",
+			config: "apiVersion: kubeadm.k8s.io/v1beta1\nkind: ClusterConfiguration\n",
+		},
+		{
+			name: "invalid: mixed kubeadm config versions",
+			config: "apiVersion: kubeadm.k8s.io/v1beta3\nkind: ClusterConfiguration\n---\n" +
+				"apiVersion: kubeadm.k8s.io/v1beta4\nkind: InitConfiguration\n",
+		},
+		{
+			name: "invalid: duplicated kind",
+			config: "apiVersion: kubeadm.k8s.io/v1beta4\nkind: InitConfiguration\n---\n" +
+				"apiVersion: kubeadm.k8s.io/v1beta4\nkind: InitConfiguration\n",
+		},
+		{
+			name:   "invalid: invalid extra args",
+			config: "apiVersion: kubeadm.k8s.io/v1beta4\nkind: ClusterConfiguration\napiServer:\n  extraArgs: foo\n",
+		},
+		{
+			name:   "invalid: no documents of the kubeadm config API",
+			config: "apiVersion: kubelet.config.k8s.io/v1beta1\nkind: KubeletConfiguration\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ParseConfig(test.config); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -55,7 +55,14 @@ type PatchJSON6902 struct {
 //
 // Patches match if their kind and apiVersion match a document, with the exception
 // that if the patch does not set apiVersion it will be ignored.
+//
+// Merge patches are applied before JSON 6902 patches; see BuildWithStrategicMerge
+// for using strategic merge patches as well.
 func Build(toPatch string, patches []string, patches6902 []PatchJSON6902) (string, error) {
+	return build(toPatch, patches, nil, patches6902)
+}
+
+func build(toPatch string, patches, strategicPatches []string, patches6902 []PatchJSON6902) (string, error) {
 	// pre-process, including splitting up documents etc.
 	resources, err := parseResources(toPatch)
 	if err != nil {
@@ -65,6 +72,10 @@ func Build(toPatch string, patches []string, patches6902 []PatchJSON6902) (strin
 	if err != nil {
 		return "", errors.Wrap(err, "failed to parse patches")
 	}
+	strategicMergePatches, err := parseMergePatches(strategicPatches)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse strategic merge patches")
+	}
 	json6902patches, err := convertJSON6902Patches(patches6902)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to parse JSON 6902 patches")
@@ -78,6 +89,12 @@ func Build(toPatch string, patches []string, patches6902 []PatchJSON6902) (strin
 				return "", errors.Wrap(err, "failed to apply patch")
 			}
 		}
+		// apply strategic merge patches
+		for _, p := range strategicMergePatches {
+			if _, err := r.applyStrategicMergePatch(p); err != nil {
+				return "", errors.Wrap(err, "failed to apply strategic merge patch")
+			}
+		}
 		// apply RFC 6902 JSON patches
 		for _, p := range json6902patches {
 			if _, err := r.apply6902Patch(p); err != nil {
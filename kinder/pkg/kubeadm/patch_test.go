@@ -0,0 +1,270 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"testing"
+)
+
+func TestBuildWithStrategicMerge(t *testing.T) {
+	toPatch := `apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+apiServer:
+  certSANs:
+  - localhost
+  extraArgs:
+  - name: bind-address
+    value: "::"
+  - name: v
+    value: "2"
+---
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: InitConfiguration
+`
+
+	tests := []struct {
+		name             string
+		patches          []string
+		strategicPatches []string
+		patches6902      []PatchJSON6902
+		expected         string
+		expectedError    bool
+	}{
+		{
+			name: "strategic merge patch merges lists by name",
+			strategicPatches: []string{`apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+apiServer:
+  certSANs:
+  - example.com
+  extraArgs:
+  - name: v
+    value: "5"
+  - name: bind-address
+    $patch: delete
+  - name: audit-log-path
+    value: /var/log/audit.log
+`},
+			expected: `apiServer:
+  certSANs:
+  - example.com
+  extraArgs:
+  - name: v
+    value: "5"
+  - name: audit-log-path
+    value: /var/log/audit.log
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+---
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: InitConfiguration
+`,
+		},
+		{
+			name: "merge, strategic merge and JSON 6902 patches are applied in order",
+			patches: []string{`apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+apiServer:
+  extraArgs:
+  - name: v
+    value: "3"
+`},
+			strategicPatches: []string{`apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+apiServer:
+  extraArgs:
+  - name: v
+    value: "4"
+  - name: profiling
+    value: "false"
+`},
+			patches6902: []PatchJSON6902{{
+				Group:   "kubeadm.k8s.io",
+				Version: "v1beta4",
+				Kind:    "ClusterConfiguration",
+				Patch:   `[{"op": "replace", "path": "/apiServer/extraArgs/0/value", "value": "6"}]`,
+			}},
+			expected: `apiServer:
+  certSANs:
+  - localhost
+  extraArgs:
+  - name: v
+    value: "6"
+  - name: profiling
+    value: "false"
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+---
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: InitConfiguration
+`,
+		},
+		{
+			name: "strategic merge patch directives are removed and repeated keys are appended",
+			strategicPatches: []string{`apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+apiServer:
+  extraArgs:
+  - name: v
+    value: "4"
+    $patch: merge
+  - name: feature-gates
+    value: Foo=true
+  - name: feature-gates
+    value: Bar=true
+`},
+			expected: `apiServer:
+  certSANs:
+  - localhost
+  extraArgs:
+  - name: bind-address
+    value: '::'
+  - name: v
+    value: "4"
+  - name: feature-gates
+    value: Foo=true
+  - name: feature-gates
+    value: Bar=true
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+---
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: InitConfiguration
+`,
+		},
+		{
+			name: "strategic merge patch for another kind is ignored",
+			strategicPatches: []string{`apiVersion: kubeadm.k8s.io/v1beta4
+kind: JoinConfiguration
+nodeRegistration:
+  name: foo
+`},
+			expected: `apiServer:
+  certSANs:
+  - localhost
+  extraArgs:
+  - name: bind-address
+    value: '::'
+  - name: v
+    value: "2"
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+---
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: InitConfiguration
+`,
+		},
+		{
+			name:             "invalid strategic merge patch",
+			strategicPatches: []string{"kind: [ClusterConfiguration"},
+			expectedError:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patched, err := BuildWithStrategicMerge(toPatch, test.patches, test.strategicPatches, test.patches6902)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if patched != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, patched)
+			}
+		})
+	}
+}
+
+func TestGetControlPlaneComponentPatch(t *testing.T) {
+	extraArgs := map[string]string{
+		"audit-policy-file": "/etc/kubernetes/audit/policy.yaml",
+		"audit-log-path":    "/var/log/kubernetes/audit/audit.log",
+	}
+	extraVolumes := []ExtraVolume{
+		{Name: "audit", HostPath: "/etc/kubernetes/audit", MountPath: "/etc/kubernetes/audit", ReadOnly: true, PathType: "DirectoryOrCreate"},
+	}
+
+	tests := []struct {
+		name          string
+		configVersion string
+		component     string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:          "v1beta3 extra args are a map",
+			configVersion: "v1beta3",
+			component:     "apiServer",
+			expected: `apiServer:
+  extraArgs:
+    audit-log-path: /var/log/kubernetes/audit/audit.log
+    audit-policy-file: /etc/kubernetes/audit/policy.yaml
+  extraVolumes:
+  - hostPath: /etc/kubernetes/audit
+    mountPath: /etc/kubernetes/audit
+    name: audit
+    pathType: DirectoryOrCreate
+    readOnly: true
+apiVersion: kubeadm.k8s.io/v1beta3
+kind: ClusterConfiguration
+`,
+		},
+		{
+			name:          "v1beta4 extra args are a list sorted by name",
+			configVersion: "v1beta4",
+			component:     "apiServer",
+			expected: `apiServer:
+  extraArgs:
+  - name: audit-log-path
+    value: /var/log/kubernetes/audit/audit.log
+  - name: audit-policy-file
+    value: /etc/kubernetes/audit/policy.yaml
+  extraVolumes:
+  - hostPath: /etc/kubernetes/audit
+    mountPath: /etc/kubernetes/audit
+    name: audit
+    pathType: DirectoryOrCreate
+    readOnly: true
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+`,
+		},
+		{
+			name:          "invalid component",
+			configVersion: "v1beta4",
+			component:     "etcd",
+			expectedError: true,
+		},
+		{
+			name:          "invalid config version",
+			configVersion: "v1beta2",
+			component:     "apiServer",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patch, err := GetControlPlaneComponentPatch(test.configVersion, test.component, extraArgs, extraVolumes)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if patch != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, patch)
+			}
+		})
+	}
+}
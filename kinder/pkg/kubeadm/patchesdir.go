@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"bytes"
+	"regexp"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// patchFileNameRegexp matches the file names supported by kubeadm --patches, that are in the form
+// target[suffix][+patchtype].extension, e.g. kube-apiserver0+merge.yaml
+var patchFileNameRegexp = regexp.MustCompile(
+	`^(etcd|kube-apiserver|kube-controller-manager|kube-scheduler|kubeletconfiguration|corednsdeployment)` +
+		`([0-9a-zA-Z]*)(\+(strategic|merge|json))?\.(json|yaml)$`,
+)
+
+// ValidatePatchFileName checks if the file name is supported by kubeadm --patches
+func ValidatePatchFileName(name string) error {
+	if !patchFileNameRegexp.MatchString(name) {
+		return errors.Errorf("invalid patch file name %q, it must be formatted as target[suffix][+patchtype].extension, "+
+			"where target is one of etcd, kube-apiserver, kube-controller-manager, kube-scheduler, kubeletconfiguration or corednsdeployment, "+
+			"patchtype is one of strategic, merge or json, and extension is one of json or yaml", name)
+	}
+	return nil
+}
+
+// RenderPatchFile returns the content of a patch file for kubeadm --patches, after expanding
+// the Go template in the file using the settings in data, e.g. {{ .NodeAddress }}
+func RenderPatchFile(name, content string, data ConfigData) (string, error) {
+	if err := ValidatePatchFileName(name); err != nil {
+		return "", err
+	}
+
+	t, err := template.New(name).Parse(content)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse patch file %s", name)
+	}
+
+	data.Derive()
+
+	var buff bytes.Buffer
+	if err := t.Execute(&buff, data); err != nil {
+		return "", errors.Wrapf(err, "error executing patch file %s template", name)
+	}
+
+	return buff.String(), nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+/*
+strategicmerge.go provides a minimal implementation of strategic merge patches for the kubeadm config.
+
+k8s.io/apimachinery/pkg/util/strategicpatch can't be used because it requires the Go types or the OpenAPI schema
+of the kubeadm config API, that are not available in kinder; instead, patches are applied with the following rules,
+that cover the lists used in the kubeadm config API, e.g. extraArgs, extraEnvs and extraVolumes in v1beta4:
+- maps are merged recursively, and a null value deletes the corresponding key (like in JSON merge patches)
+- lists of maps with the strategicMergeKey field are merged by key; list items with the "$patch: delete"
+  directive delete the item with the same key. Items with a key not in the original list are appended, so
+  a patch can add repeated items with the same key, e.g. repeated extraArgs
+- all the other lists are replaced
+- patch directives are not included in the result
+*/
+
+const (
+	// strategicMergeKey defines the field used for merging list items
+	strategicMergeKey = "name"
+
+	// strategicMergeDirective defines the field used for patch directives in list items
+	strategicMergeDirective = "$patch"
+
+	// strategicMergeDelete defines the patch directive for deleting list items
+	strategicMergeDelete = "delete"
+)
+
+// BuildWithStrategicMerge is like Build, but it additionally accepts strategic merge patches.
+//
+// Patches are applied to each document in the following order:
+// - merge patches
+// - strategic merge patches
+// - JSON 6902 patches
+// Within each stage, patches are applied in the given order.
+func BuildWithStrategicMerge(toPatch string, patches, strategicMergePatches []string, patches6902 []PatchJSON6902) (string, error) {
+	return build(toPatch, patches, strategicMergePatches, patches6902)
+}
+
+func (r *resource) applyStrategicMergePatch(patch mergePatch) (matches bool, err error) {
+	if !r.matches(patch.matchInfo) {
+		return false, nil
+	}
+
+	original := map[string]interface{}{}
+	if err := json.Unmarshal(r.json, &original); err != nil {
+		return true, errors.WithStack(err)
+	}
+	p := map[string]interface{}{}
+	if err := json.Unmarshal(patch.json, &p); err != nil {
+		return true, errors.WithStack(err)
+	}
+
+	patched, err := json.Marshal(strategicMergeMap(original, p))
+	if err != nil {
+		return true, errors.WithStack(err)
+	}
+	r.json = patched
+	return true, nil
+}
+
+// strategicMergeMap merges patch into original
+func strategicMergeMap(original, patch map[string]interface{}) map[string]interface{} {
+	for k, pv := range patch {
+		if k == strategicMergeDirective {
+			continue
+		}
+		if pv == nil {
+			delete(original, k)
+			continue
+		}
+
+		switch p := pv.(type) {
+		case map[string]interface{}:
+			if o, ok := original[k].(map[string]interface{}); ok {
+				original[k] = strategicMergeMap(o, p)
+				continue
+			}
+			original[k] = strategicMergeMap(map[string]interface{}{}, p)
+		case []interface{}:
+			o, _ := original[k].([]interface{})
+			original[k] = strategicMergeList(o, p)
+		default:
+			original[k] = pv
+		}
+	}
+	return original
+}
+
+// strategicMergeList merges patch into original if all the list items are maps with the strategicMergeKey field,
+// otherwise it returns patch
+func strategicMergeList(original, patch []interface{}) []interface{} {
+	if !hasStrategicMergeKey(original) || !hasStrategicMergeKey(patch) {
+		return patch
+	}
+
+	// NB. patch items are matched only with the items of the original list
+	merged := append([]interface{}{}, original...)
+	n := len(merged)
+	for _, pv := range patch {
+		p := pv.(map[string]interface{})
+		i := indexOfStrategicMergeKey(merged[:n], p[strategicMergeKey])
+
+		if p[strategicMergeDirective] == strategicMergeDelete {
+			if i >= 0 {
+				merged = append(merged[:i], merged[i+1:]...)
+				n--
+			}
+			continue
+		}
+		if i >= 0 {
+			merged[i] = strategicMergeMap(merged[i].(map[string]interface{}), p)
+			continue
+		}
+		merged = append(merged, strategicMergeMap(map[string]interface{}{}, p))
+	}
+	return merged
+}
+
+// hasStrategicMergeKey returns true if all the list items are maps with the strategicMergeKey field
+func hasStrategicMergeKey(list []interface{}) bool {
+	for _, v := range list {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m[strategicMergeKey]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// indexOfStrategicMergeKey returns the index of the list item with the given key, or -1
+func indexOfStrategicMergeKey(list []interface{}, key interface{}) int {
+	for i, v := range list {
+		if v.(map[string]interface{})[strategicMergeKey] == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/kubeadm/kinder/pkg/exec"
+)
+
+// kubeadmAPIGroup defines the API group of the kubeadm config API
+const kubeadmAPIGroup = "kubeadm.k8s.io"
+
+// commonConfigFields defines the top level fields that exist in all the kinds of the kubeadm config API
+var commonConfigFields = []string{"apiVersion", "kind"}
+
+// configFields defines, for each version of the kubeadm config API, the top level fields of each kind;
+// NB. nested fields are validated by kubeadm only
+var configFields = map[string]map[string]sets.String{
+	"v1beta3": {
+		"ClusterConfiguration": sets.NewString(append(commonConfigFields,
+			"etcd", "networking", "kubernetesVersion", "controlPlaneEndpoint", "apiServer", "controllerManager",
+			"scheduler", "dns", "certificatesDir", "imageRepository", "featureGates", "clusterName")...),
+		"InitConfiguration": sets.NewString(append(commonConfigFields,
+			"bootstrapTokens", "nodeRegistration", "localAPIEndpoint", "certificateKey", "skipPhases", "patches")...),
+		"JoinConfiguration": sets.NewString(append(commonConfigFields,
+			"nodeRegistration", "caCertPath", "discovery", "controlPlane", "skipPhases", "patches")...),
+	},
+	"v1beta4": {
+		"ClusterConfiguration": sets.NewString(append(commonConfigFields,
+			"etcd", "networking", "kubernetesVersion", "controlPlaneEndpoint", "apiServer", "controllerManager",
+			"scheduler", "dns", "proxy", "certificatesDir", "imageRepository", "featureGates", "clusterName",
+			"encryptionAlgorithm", "certificateValidityPeriod", "caCertificateValidityPeriod")...),
+		"InitConfiguration": sets.NewString(append(commonConfigFields,
+			"bootstrapTokens", "dryRun", "nodeRegistration", "localAPIEndpoint", "certificateKey", "skipPhases",
+			"patches", "timeouts")...),
+		"JoinConfiguration": sets.NewString(append(commonConfigFields,
+			"dryRun", "nodeRegistration", "caCertPath", "discovery", "controlPlane", "skipPhases", "patches",
+			"timeouts")...),
+		"ResetConfiguration": sets.NewString(append(commonConfigFields,
+			"cleanupTmpDir", "certificatesDir", "criSocket", "dryRun", "force", "ignorePreflightErrors",
+			"skipPhases", "unmountFlags", "timeouts")...),
+		"UpgradeConfiguration": sets.NewString(append(commonConfigFields,
+			"apply", "diff", "node", "plan", "timeouts")...),
+	},
+}
+
+// ConfigValidationNode defines the subset of status.Node used for validating a kubeadm config on a node
+type ConfigValidationNode interface {
+	Name() string
+	Command(command string, args ...string) *exec.NodeCmd
+}
+
+// ValidateConfigOnNode validates a kubeadm config with `kubeadm config validate` on the given node,
+// piping the config via stdin; see ValidateConfig for validating a kubeadm config when no node is available.
+func ValidateConfigOnNode(n ConfigValidationNode, config string) error {
+	// NB. a nil *status.Node is a non-nil ConfigValidationNode
+	if n == nil || (reflect.ValueOf(n).Kind() == reflect.Ptr && reflect.ValueOf(n).IsNil()) {
+		return errors.New("a node is required for validating the kubeadm config with kubeadm")
+	}
+
+	lines, err := n.Command(
+		"kubeadm", "config", "validate", "--config=/dev/stdin",
+	).Silent().Stdin(strings.NewReader(config)).RunAndCapture()
+	if err != nil {
+		return errors.Errorf("invalid kubeadm config for node %s: %s", n.Name(), strings.Join(lines, "; "))
+	}
+	return nil
+}
+
+// ValidateConfig validates a kubeadm config offline, checking that all the documents of the kubeadm config API
+// use the given version and define only known kinds and known top level fields.
+func ValidateConfig(configVersion, config string) error {
+	fields, ok := configFields[configVersion]
+	if !ok {
+		return errors.Errorf("unknown kubeadm config version: %s", configVersion)
+	}
+
+	resources, err := parseResources(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the kubeadm config")
+	}
+
+	problems := []string{}
+	for _, r := range resources {
+		group := strings.Split(r.matchInfo.APIVersion, "/")[0]
+		if group != kubeadmAPIGroup {
+			// component configs, e.g. the KubeletConfiguration, are validated by kubeadm only
+			continue
+		}
+		if r.matchInfo.APIVersion != fmt.Sprintf("%s/%s", kubeadmAPIGroup, configVersion) {
+			problems = append(problems, fmt.Sprintf("%s: apiVersion %s does not match the kubeadm config version %s", r.matchInfo.Kind, r.matchInfo.APIVersion, configVersion))
+			continue
+		}
+
+		known, ok := fields[r.matchInfo.Kind]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown kind %q for apiVersion %s", r.matchInfo.Kind, r.matchInfo.APIVersion))
+			continue
+		}
+
+		doc := map[string]interface{}{}
+		if err := json.Unmarshal(r.json, &doc); err != nil {
+			return errors.Wrapf(err, "failed to parse the %s document", r.matchInfo.Kind)
+		}
+		unknown := []string{}
+		for field := range doc {
+			if !known.Has(field) {
+				unknown = append(unknown, field)
+			}
+		}
+		sort.Strings(unknown)
+		for _, field := range unknown {
+			problems = append(problems, fmt.Sprintf("%s: unknown field %q", r.matchInfo.Kind, field))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.Errorf("invalid kubeadm config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
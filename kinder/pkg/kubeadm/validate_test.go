@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/exec"
+)
+
+func TestValidateConfig(t *testing.T) {
+	golden := func(name string) string {
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		return string(b)
+	}
+
+	tests := []struct {
+		name          string
+		configVersion string
+		config        string
+		expectedError string
+	}{
+		{
+			name:          "valid: v1beta3 config",
+			configVersion: "v1beta3",
+			config:        golden("v1beta3.golden"),
+		},
+		{
+			name:          "valid: v1beta4 config",
+			configVersion: "v1beta4",
+			config:        golden("v1beta4.golden"),
+		},
+		{
+			name:          "invalid: unknown field",
+			configVersion: "v1beta4",
+			config: `apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+apiServerr:
+  certSANs: [localhost]
+`,
+			expectedError: `ClusterConfiguration: unknown field "apiServerr"`,
+		},
+		{
+			name:          "invalid: field not available in v1beta3",
+			configVersion: "v1beta3",
+			config: `apiVersion: kubeadm.k8s.io/v1beta3
+kind: ClusterConfiguration
+encryptionAlgorithm: RSA-2048
+`,
+			expectedError: `ClusterConfiguration: unknown field "encryptionAlgorithm"`,
+		},
+		{
+			name:          "invalid: kind not available in v1beta3",
+			configVersion: "v1beta3",
+			config: `apiVersion: kubeadm.k8s.io/v1beta3
+kind: UpgradeConfiguration
+`,
+			expectedError: `unknown kind "UpgradeConfiguration"`,
+		},
+		{
+			name:          "invalid: mixed config versions",
+			configVersion: "v1beta4",
+			config: `apiVersion: kubeadm.k8s.io/v1beta3
+kind: InitConfiguration
+`,
+			expectedError: "does not match the kubeadm config version v1beta4",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateConfig(test.configVersion, test.config)
+			if test.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+				t.Errorf("expected error containing %q, got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+// fakeValidationNode implements ConfigValidationNode for testing ValidateConfigOnNode
+type fakeValidationNode struct{}
+
+func (n *fakeValidationNode) Name() string { return "node" }
+
+func (n *fakeValidationNode) Command(command string, args ...string) *exec.NodeCmd { return nil }
+
+func TestValidateConfigOnNodeWithoutNode(t *testing.T) {
+	var typedNil *fakeValidationNode
+
+	for _, n := range []ConfigValidationNode{nil, typedNil} {
+		if err := ValidateConfigOnNode(n, "kind: ClusterConfiguration"); err == nil {
+			t.Errorf("expected error for node %#v", n)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// collectArtifacts copies the files matching the artifacts glob paths of a task into the dest folder,
+// preserving their path relative to the task dir; directories are copied recursively.
+// The paths of the collected files are returned, also in case of errors.
+func collectArtifacts(t *Task, dest string) ([]string, error) {
+	base := t.Dir
+	if base == "" {
+		var err error
+		if base, err = os.Getwd(); err != nil {
+			return nil, errors.Wrap(err, "error getting current directory")
+		}
+	}
+
+	var collected []string
+	for _, pattern := range t.Artifacts {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(base, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return collected, errors.Wrapf(err, "invalid artifacts path %q", pattern)
+		}
+
+		for _, match := range matches {
+			err := filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+
+				// files outside of the task dir are collected in the root of the dest folder
+				rel, err := filepath.Rel(base, path)
+				if err != nil || strings.HasPrefix(rel, "..") {
+					rel = filepath.Base(path)
+				}
+				target := filepath.Join(dest, rel)
+				if err := copyFile(path, target); err != nil {
+					return err
+				}
+				collected = append(collected, target)
+				return nil
+			})
+			if err != nil {
+				return collected, errors.Wrapf(err, "error collecting artifacts %q", match)
+			}
+		}
+	}
+	return collected, nil
+}
+
+// copyFile copies the src file to dest, creating the parent folders if necessary
+func copyFile(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return errors.Wrapf(err, "error creating folder %s", filepath.Dir(dest))
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "error opening %s", src)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return errors.Wrapf(err, "error creating %s", dest)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return errors.Wrapf(err, "error copying %s to %s", src, dest)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCollectArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.log", "b.log", "c.txt", "configs/kubeadm.yaml"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		patterns []string
+		expected []string
+	}{
+		{
+			name:     "glob",
+			patterns: []string{"*.log"},
+			expected: []string{"a.log", "b.log"},
+		},
+		{
+			name:     "directory",
+			patterns: []string{"configs"},
+			expected: []string{"configs/kubeadm.yaml"},
+		},
+		{
+			name:     "no match",
+			patterns: []string{"*.json"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dest := t.TempDir()
+			collected, err := collectArtifacts(&Task{Dir: dir, Artifacts: tc.patterns}, dest)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var expected []string
+			for _, name := range tc.expected {
+				expected = append(expected, filepath.Join(dest, name))
+			}
+			sort.Strings(collected)
+			if !reflect.DeepEqual(collected, expected) {
+				t.Fatalf("expected %v, got %v", expected, collected)
+			}
+			for i, path := range collected {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != tc.expected[i] {
+					t.Errorf("expected content %q for %s, got %q", tc.expected[i], path, data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// PreFlight defines a set of checks on the environment to be executed before running the workflow tasks;
+// if any check fails, the workflow is aborted before executing any task.
+type PreFlight struct {
+	// Commands defines a list of commands that must be available in the PATH, e.g. docker
+	Commands []string
+
+	// Images defines a list of images that must exist in the local docker image store;
+	// images can be a literal or a template
+	Images []string
+
+	// MinFreeDiskSpace defines the minimum free disk space required in the artifacts folder, e.g. 10Gi
+	MinFreeDiskSpace string
+}
+
+// preFlightCheck defines a single check executed by PreFlight
+type preFlightCheck struct {
+	name  string
+	check func() error
+}
+
+// validate checks that the PreFlight settings are formally correct
+func (p *PreFlight) validate() error {
+	if p.MinFreeDiskSpace != "" {
+		if _, err := resource.ParseQuantity(p.MinFreeDiskSpace); err != nil {
+			return errors.Wrapf(err, "invalid minFreeDiskSpace %q", p.MinFreeDiskSpace)
+		}
+	}
+	return nil
+}
+
+// merge adds the checks from another PreFlight, e.g. a PreFlight defined in an imported workflow;
+// in case of conflicts, the current PreFlight settings take precedence
+func (p *PreFlight) merge(px *PreFlight) {
+	p.Commands = append(p.Commands, px.Commands...)
+	p.Images = append(p.Images, px.Images...)
+	if p.MinFreeDiskSpace == "" {
+		p.MinFreeDiskSpace = px.MinFreeDiskSpace
+	}
+}
+
+// run executes all the pre-flight checks and reports the result of each one of them;
+// all the checks are executed, and an error is returned if any check fails
+func (p *PreFlight) run(out io.Writer, c *taskCmdBuilder, artifacts string) error {
+	checks, err := p.checks(c, artifacts)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, pc := range checks {
+		if err := pc.check(); err != nil {
+			failed++
+			fmt.Fprintf(out, "[preflight] %s: failed, %v\n", pc.name, err)
+			continue
+		}
+		fmt.Fprintf(out, "[preflight] %s: ok\n", pc.name)
+	}
+	fmt.Fprintln(out)
+
+	if failed > 0 {
+		return errors.Errorf("%d of %d pre-flight checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// checks returns the list of pre-flight checks, with templates already expanded
+func (p *PreFlight) checks(c *taskCmdBuilder, artifacts string) ([]preFlightCheck, error) {
+	checks := []preFlightCheck{}
+
+	for _, cmd := range p.Commands {
+		cmd := cmd
+		checks = append(checks, preFlightCheck{
+			name: fmt.Sprintf("command %s is available", cmd),
+			check: func() error {
+				_, err := exec.LookPath(cmd)
+				return err
+			},
+		})
+	}
+
+	for i, image := range p.Images {
+		image, err := c.expand(image)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error expanding preFlight images[%d]", i)
+		}
+		checks = append(checks, preFlightCheck{
+			name: fmt.Sprintf("image %s exists", image),
+			check: func() error {
+				if err := exec.Command("docker", "image", "inspect", image).Run(); err != nil {
+					return errors.New("image not found in the local docker image store")
+				}
+				return nil
+			},
+		})
+	}
+
+	if p.MinFreeDiskSpace != "" {
+		minFreeDiskSpace, err := resource.ParseQuantity(p.MinFreeDiskSpace)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid minFreeDiskSpace %q", p.MinFreeDiskSpace)
+		}
+		checks = append(checks, preFlightCheck{
+			name: fmt.Sprintf("at least %s of free disk space", p.MinFreeDiskSpace),
+			check: func() error {
+				free, err := freeDiskSpace(artifacts)
+				if err != nil {
+					return err
+				}
+				if free < uint64(minFreeDiskSpace.Value()) {
+					return errors.Errorf("only %s of free disk space in %s", resource.NewQuantity(int64(free), resource.BinarySI), artifacts)
+				}
+				return nil
+			},
+		})
+	}
+
+	return checks, nil
+}
+
+// freeDiskSpace returns the free disk space available to unprivileged users in the given path;
+// if the path does not exist yet, the free disk space of the nearest existing parent folder is returned
+func freeDiskSpace(path string) (uint64, error) {
+	path, _ = filepath.Abs(path)
+	for {
+		if _, err := os.Stat(path); err == nil || filepath.Dir(path) == path {
+			break
+		}
+		path = filepath.Dir(path)
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, errors.Wrapf(err, "failed to get the free disk space in %s", path)
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"io"
+	"testing"
+)
+
+func TestPreFlight(t *testing.T) {
+	tests := []struct {
+		name                  string
+		preFlight             *PreFlight
+		expectedInvalid       bool
+		expectedChecksFailure bool
+	}{
+		{
+			name: "valid: all checks pass",
+			preFlight: &PreFlight{
+				Commands:         []string{"sh"},
+				MinFreeDiskSpace: "1Ki",
+			},
+		},
+		{
+			name: "valid: missing command",
+			preFlight: &PreFlight{
+				Commands: []string{"sh", "kinder-not-existing-command"},
+			},
+			expectedChecksFailure: true,
+		},
+		{
+			name: "valid: not enough free disk space",
+			preFlight: &PreFlight{
+				MinFreeDiskSpace: "1Ei",
+			},
+			expectedChecksFailure: true,
+		},
+		{
+			name: "invalid: free disk space is not a quantity",
+			preFlight: &PreFlight{
+				MinFreeDiskSpace: "a lot",
+			},
+			expectedInvalid: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.preFlight.validate()
+			if (err != nil) != test.expectedInvalid {
+				t.Fatalf("expected invalid: %v, got: %v", test.expectedInvalid, err)
+			}
+			if err != nil {
+				return
+			}
+
+			err = test.preFlight.run(io.Discard, &taskCmdBuilder{}, t.TempDir())
+			if (err != nil) != test.expectedChecksFailure {
+				t.Errorf("expected checks failure: %v, got: %v", test.expectedChecksFailure, err)
+			}
+		})
+	}
+}
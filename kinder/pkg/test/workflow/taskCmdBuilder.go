@@ -121,6 +121,18 @@ func (c *taskCmdBuilder) build(t *Task, verbose bool) (tcmd *taskCmd, err error)
 		}
 	}
 
+	t.Dir, err = c.expand(t.Dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error expanding dir for task %q", t.Name)
+	}
+
+	for n, v := range t.Artifacts {
+		t.Artifacts[n], err = c.expand(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error expanding artifacts[%d] for task %q", n, t.Name)
+		}
+	}
+
 	// creates the command
 	cmd := exec.Command(t.Cmd, t.Args...)
 
@@ -129,6 +141,9 @@ func (c *taskCmdBuilder) build(t *Task, verbose bool) (tcmd *taskCmd, err error)
 
 	// set the working dir if different from the current one
 	if t.Dir != "" {
+		if err := ensureDir(t.Dir, t.CreateDir); err != nil {
+			return nil, errors.Wrapf(err, "invalid dir for task %q", t.Name)
+		}
 		cmd.Dir = t.Dir
 	}
 
@@ -147,3 +162,25 @@ func (c *taskCmdBuilder) build(t *Task, verbose bool) (tcmd *taskCmd, err error)
 		CmdText: cmdText,
 	}, nil
 }
+
+// ensureDir checks that the given path exists and it is a directory;
+// if create is set, a missing directory is created
+func ensureDir(path string, create bool) error {
+	info, err := os.Stat(path)
+	if err == nil {
+		if !info.IsDir() {
+			return errors.Errorf("%s is not a directory", path)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to read directory %s", path)
+	}
+	if !create {
+		return errors.Errorf("directory %s does not exist", path)
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildDir(t *testing.T) {
+	base := t.TempDir()
+	if err := os.Mkdir(filepath.Join(base, "v1.31"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		dir           string
+		createDir     bool
+		expectedDir   string
+		expectedError string
+	}{
+		{
+			name:        "templated dir is resolved",
+			dir:         "{{ .vars.workdir }}/{{ .vars.version }}",
+			expectedDir: filepath.Join(base, "v1.31"),
+		},
+		{
+			name:          "nonexistent dir",
+			dir:           "{{ .vars.workdir }}/missing",
+			expectedError: "does not exist",
+		},
+		{
+			name:        "nonexistent dir is created",
+			dir:         "{{ .vars.workdir }}/created",
+			createDir:   true,
+			expectedDir: filepath.Join(base, "created"),
+		},
+		{
+			name:          "invalid template",
+			dir:           "{{ .vars.unknown }}",
+			expectedError: "error expanding dir",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &taskCmdBuilder{
+				vars: map[string]string{
+					"workdir": base,
+					"version": "v1.31",
+				},
+			}
+
+			tcmd, err := c.build(&Task{Name: "test", Cmd: "true", Dir: test.dir, CreateDir: test.createDir}, false)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got: %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tcmd.Cmd.Dir != test.expectedDir {
+				t.Errorf("expected dir %q, got %q", test.expectedDir, tcmd.Cmd.Dir)
+			}
+			if info, err := os.Stat(test.expectedDir); err != nil || !info.IsDir() {
+				t.Errorf("expected dir %q to exist, got: %v", test.expectedDir, err)
+			}
+		})
+	}
+}
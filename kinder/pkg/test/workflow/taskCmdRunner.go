@@ -37,11 +37,19 @@ import (
 type taskCmdRunner struct {
 	start    time.Time
 	suite    junitTestSuite
+	policy   ExecutionPolicy
 	failed   bool
 	canceled bool
 	timedOut bool
+
+	// stoppedChains defines the reason for skipping the tasks of the chains with a failed task, by chain;
+	// chains are identified by the junit classname of the tasks.
+	stoppedChains map[string]string
 }
 
+// junitClassNamePrefix defines the junit classname used for the workflow tasks
+const junitClassNamePrefix = "kinder.test.workflow"
+
 // junitTestSuite implements junit TestSuite standard object
 type junitTestSuite struct {
 	XMLName  xml.Name `xml:"testsuite"`
@@ -59,13 +67,16 @@ type junitTestCase struct {
 	Time      float64  `xml:"time,attr"`
 	Failure   string   `xml:"failure,omitempty"`
 	Skipped   string   `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
 }
 
-// newTaskCmdRunner returns a new taskCmdRunner
-func newTaskCmdRunner() *taskCmdRunner {
+// newTaskCmdRunner returns a new taskCmdRunner using the given execution policy
+func newTaskCmdRunner(policy ExecutionPolicy) *taskCmdRunner {
 	return &taskCmdRunner{
-		start: time.Now(),
-		suite: junitTestSuite{},
+		start:         time.Now(),
+		suite:         junitTestSuite{},
+		policy:        policy,
+		stoppedChains: map[string]string{},
 	}
 }
 
@@ -74,17 +85,11 @@ func (c *taskCmdRunner) Run(t *taskCmd, artifacts string, verbose bool) error {
 	start := time.Now()
 
 	// unless the cmd execution is forced, check if the taskCmd should be skipped because one of
-	// the previous taskCmd failed, timedOut or was canceled.
+	// the previous taskCmd failed, timedOut or was canceled, according to the execution policy.
 	// if this is the case record test case as skipped and exits with error
 	if !t.Force {
-		if c.failed {
-			return c.registerTestCase(t.Name, withSkipped("skipping because a predecessor task failed"))
-		}
-		if c.timedOut {
-			return c.registerTestCase(t.Name, withSkipped("skipping because a predecessor task timed-out"))
-		}
-		if c.canceled {
-			return c.registerTestCase(t.Name, withSkipped("skipping because task workflow was canceled by the user"))
+		if reason := c.skipReason(t.Task); reason != "" {
+			return c.registerTestCase(t.Task, withSkipped(reason))
 		}
 	}
 
@@ -123,10 +128,10 @@ func (c *taskCmdRunner) Run(t *taskCmd, artifacts string, verbose bool) error {
 	// starts the command
 	if err := t.Cmd.Start(); err != nil {
 		// keeps track of this failure type to block execution of following TestCmd
-		c.failed = true
+		c.setFailed(t.Task)
 
 		// record test case timeout and exits with error
-		return c.registerTestCase(t.Name, withFailure(err.Error()), withDuration(time.Since(start)))
+		return c.registerTestCase(t.Task, withFailure(err.Error()), withDuration(time.Since(start)))
 	}
 
 	// starts a go routine responsible for waiting the command completes
@@ -139,52 +144,105 @@ func (c *taskCmdRunner) Run(t *taskCmd, artifacts string, verbose bool) error {
 	// - the command completes
 	// - the command is canceled
 	// - the timeout is reached
+	var options []testCaseOption
 	select {
 	case err := <-result:
-		// if the command completed without an error or if we are ignoring errors, record the test case success and exit
+		// if the command completed without an error or if we are ignoring errors, the test case is recorded as success
 		if err == nil || t.IgnoreError {
-			// record test case timeout as success
-			return c.registerTestCase(t.Name,
-				withDuration(time.Since(start)),
-			)
+			break
 		}
 		// keeps track of this failure type to block execution of following TestCmd
-		c.failed = true
+		c.setFailed(t.Task)
 
 		// cleanup command process and its child, if any
 		cleanup(t.Cmd)
 
-		// otherwise record test case failure and exits with error
-		return c.registerTestCase(t.Name,
-			withFailure(err.Error()),
-			withDuration(time.Since(start)),
-		)
+		// otherwise record test case failure
+		options = append(options, withFailure(err.Error()))
 
 	case <-cancel:
 		// keeps track of this failure type to block execution of following TestCmd
 		c.canceled = true
 
-		// cleanup command process and its child, if any
-		cleanup(t.Cmd)
+		// gracefully terminates command process and its child, if any
+		terminate(t.Cmd, result, gracePeriod(t.Task))
 
-		// record test case cancellation and exits with error
-		return c.registerTestCase(t.Name,
-			withFailure("task was canceled by the user"),
-			withDuration(time.Since(start)),
-		)
+		// record test case cancellation
+		options = append(options, withFailure("task was canceled by the user"))
 
 	case <-time.After(t.Timeout.Duration):
 		// keeps track of this failure type to block execution of following TestCmd
-		c.timedOut = true
+		c.setTimedOut(t.Task)
 
-		// cleanup command process and its child, if any
-		cleanup(t.Cmd)
+		// gracefully terminates command process and its child, if any
+		terminate(t.Cmd, result, gracePeriod(t.Task))
 
-		// record test case timeout and exits with error
-		return c.registerTestCase(t.Name,
-			withFailure(fmt.Sprintf("timeout. The task did not complete in less than %s as expected", t.Timeout.Duration)),
-			withDuration(time.Since(start)),
-		)
+		// record test case timeout
+		options = append(options, withFailure(fmt.Sprintf("timeout. The task did not complete in less than %s as expected", t.Timeout.Duration)))
+	}
+	options = append(options, withDuration(time.Since(start)))
+
+	// collects the task artifacts, if any, no matter of the task result;
+	// errors collecting artifacts are recorded in the task log, but they do not fail the task
+	if len(t.Artifacts) > 0 {
+		collected, err := collectArtifacts(t.Task, filepath.Join(artifacts, fmt.Sprintf("%s-artifacts", t.Name)))
+		if err != nil {
+			writer.WriteString(fmt.Sprintf("\nerror collecting artifacts: %v\n", err))
+		}
+		options = append(options, withArtifacts(collected))
+	}
+
+	// record test case result and exits with error, if any
+	return c.registerTestCase(t.Task, options...)
+}
+
+// skipReason returns the reason for skipping a task according to the execution policy, if any
+func (c *taskCmdRunner) skipReason(t *Task) string {
+	// nb. a workflow canceled by the user is stopped no matter of the execution policy
+	if c.canceled {
+		return "skipping because task workflow was canceled by the user"
+	}
+
+	switch c.policy {
+	case ContinueOnError:
+		return ""
+	case StopChain:
+		// nb. a task is skipped also if any of the chains importing its chain is stopped
+		for _, chain := range append(importingChains(t), junitClassName(t)) {
+			if reason, ok := c.stoppedChains[chain]; ok {
+				return reason
+			}
+		}
+		return ""
+	}
+
+	if c.failed {
+		return "skipping because a predecessor task failed"
+	}
+	if c.timedOut {
+		return "skipping because a predecessor task timed-out"
+	}
+	return ""
+}
+
+// setFailed keeps track of a task failure, stopping the chain of the task
+func (c *taskCmdRunner) setFailed(t *Task) {
+	c.failed = true
+	c.stopChain(t, "skipping because a predecessor task in the same chain failed")
+}
+
+// setTimedOut keeps track of a task timeout, stopping the chain of the task
+func (c *taskCmdRunner) setTimedOut(t *Task) {
+	c.timedOut = true
+	c.stopChain(t, "skipping because a predecessor task in the same chain timed-out")
+}
+
+// stopChain records the reason for skipping the remaining tasks in the chain of a task;
+// the first failure in a chain is preserved
+func (c *taskCmdRunner) stopChain(t *Task, reason string) {
+	chain := junitClassName(t)
+	if _, ok := c.stoppedChains[chain]; !ok {
+		c.stoppedChains[chain] = reason
 	}
 }
 
@@ -254,11 +312,20 @@ func withSkipped(message string) testCaseOption {
 	}
 }
 
-// registerTestCase register task output as a test case result
-func (c *taskCmdRunner) registerTestCase(name string, options ...testCaseOption) error {
+func withArtifacts(paths []string) testCaseOption {
+	return func(t *junitTestCase) {
+		if len(paths) > 0 {
+			t.SystemOut = fmt.Sprintf("collected artifacts:\n%s", strings.Join(paths, "\n"))
+		}
+	}
+}
+
+// registerTestCase register task output as a test case result;
+// tasks from imported workflows are grouped using the name of the imported workflow as a classname
+func (c *taskCmdRunner) registerTestCase(t *Task, options ...testCaseOption) error {
 	tc := &junitTestCase{
-		ClassName: "kinder.test.workflow",
-		Name:      name,
+		ClassName: junitClassName(t),
+		Name:      t.Name,
 	}
 
 	for _, option := range options {
@@ -279,6 +346,44 @@ func (c *taskCmdRunner) registerTestCase(name string, options ...testCaseOption)
 	return nil
 }
 
+// terminate sends SIGTERM to all the processes in the process group of a cmdtask, so the processes can
+// cleanup, e.g. kubeadm can avoid leaving the node in an inconsistent state; then, if the command does not
+// complete within the grace period, the cmdtask is closed using cleanup.
+// If the grace period is zero, the cmdtask is closed using cleanup immediately.
+func terminate(cmd *exec.Cmd, result <-chan error, gracePeriod time.Duration) {
+	if gracePeriod > 0 {
+		if err := signalProcessGroup(cmd, syscall.SIGTERM); err != nil {
+			fmt.Printf("error: failed to terminate pid: %v, %v\n", cmd.Process.Pid, err)
+		} else {
+			select {
+			case <-result:
+			case <-time.After(gracePeriod):
+				fmt.Printf("pid %v did not terminate within the %s grace period, killing it\n", cmd.Process.Pid, gracePeriod)
+			}
+		}
+	}
+
+	// cleanup command process and its child, if any
+	cleanup(cmd)
+}
+
+// gracePeriod returns the grace period of a task, or the default grace period if not defined
+func gracePeriod(t *Task) time.Duration {
+	if t.GracePeriod == nil {
+		return defaultGracePeriod
+	}
+	return t.GracePeriod.Duration
+}
+
+// signalProcessGroup sends a signal to all the processes in the process group of a cmdtask
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	pgid, err := syscall.Getpgid(cmd.Process.Pid)
+	if err != nil {
+		return errors.Wrapf(err, "failed obtaining the pgid for pid: %v", cmd.Process.Pid)
+	}
+	return syscall.Kill(-pgid, sig)
+}
+
 // cleanup tries to ensure a cmdtask is properly closed
 func cleanup(cmd *exec.Cmd) {
 	defer func() {
@@ -313,3 +418,29 @@ kill_process:
 		fmt.Printf("error: failed killing process with pid: %v, %v\n", cmd.Process.Pid, err)
 	}
 }
+
+// importingChains returns the chains importing the chain of a task, directly or indirectly, from the outermost,
+// i.e. the chain of the top level workflow file; chains are identified by the junit classname of the tasks.
+func importingChains(t *Task) []string {
+	if t.ImportedFrom == "" {
+		return nil
+	}
+	chains := []string{junitClassName(&Task{})}
+	if len(t.ImportChain) > 1 {
+		for _, importPath := range t.ImportChain[:len(t.ImportChain)-1] {
+			chains = append(chains, junitClassName(&Task{ImportedFrom: importPath}))
+		}
+	}
+	return chains
+}
+
+// junitClassName returns the junit classname for a task, e.g. kinder.test.workflow.discovery-tasks
+// for a task imported from the discovery-tasks.yaml workflow file
+func junitClassName(t *Task) string {
+	if t.ImportedFrom == "" {
+		return junitClassNamePrefix
+	}
+	name := filepath.Base(t.ImportedFrom)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return fmt.Sprintf("%s.%s", junitClassNamePrefix, strings.ReplaceAll(name, ".", "-"))
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExecutionPolicy(t *testing.T) {
+	// tasks of the top level workflow file and of an imported workflow file, that is two chains
+	newTasks := func() Tasks {
+		return Tasks{
+			{Name: "setup", Cmd: "true"},
+			{Name: "child-fail", Cmd: "false", ImportedFrom: "child.yaml"},
+			{Name: "child-ignored", Cmd: "false", ImportedFrom: "child.yaml", IgnoreError: true},
+			{Name: "child-next", Cmd: "true", ImportedFrom: "child.yaml"},
+			{Name: "child-cleanup", Cmd: "true", ImportedFrom: "child.yaml", Force: true},
+			{Name: "other", Cmd: "true"},
+			{Name: "cleanup", Cmd: "true", Force: true},
+		}
+	}
+
+	testCases := []struct {
+		policy   ExecutionPolicy
+		expected []string
+	}{
+		{
+			policy:   FailFast,
+			expected: []string{"passed", "failed", "skipped", "skipped", "passed", "skipped", "passed"},
+		},
+		{
+			policy:   ContinueOnError,
+			expected: []string{"passed", "failed", "passed", "passed", "passed", "passed", "passed"},
+		},
+		{
+			policy:   StopChain,
+			expected: []string{"passed", "failed", "skipped", "skipped", "passed", "passed", "passed"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			artifacts := t.TempDir()
+			builder := &taskCmdBuilder{env: map[string]string{}, vars: map[string]string{}}
+			runner := newTaskCmdRunner(tc.policy)
+			for _, task := range newTasks() {
+				task.Timeout.Duration = time.Minute
+				tcmd, err := builder.build(task, false)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				_ = runner.Run(tcmd, artifacts, false)
+			}
+
+			var results []string
+			for _, c := range runner.suite.Cases {
+				switch {
+				case c.Failure != "":
+					results = append(results, "failed")
+				case c.Skipped != "":
+					results = append(results, "skipped")
+				default:
+					results = append(results, "passed")
+				}
+			}
+			if !reflect.DeepEqual(results, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, results)
+			}
+		})
+	}
+}
+
+func TestStopChainImportedChains(t *testing.T) {
+	// tasks of the top level workflow file, of a child workflow file and of a grandchild workflow
+	// file imported by the child, that is three chains
+	childImport := []string{"child.yaml"}
+	grandchildImport := []string{"child.yaml", "grandchild.yaml"}
+
+	testCases := []struct {
+		name     string
+		tasks    Tasks
+		expected []string
+	}{
+		{
+			name: "a failure in the importing chain stops the imported chains",
+			tasks: Tasks{
+				{Name: "setup", Cmd: "false"},
+				{Name: "child", Cmd: "true", ImportedFrom: "child.yaml", ImportChain: childImport},
+				{Name: "child-cleanup", Cmd: "true", ImportedFrom: "child.yaml", ImportChain: childImport, Force: true},
+				{Name: "grandchild", Cmd: "true", ImportedFrom: "grandchild.yaml", ImportChain: grandchildImport},
+			},
+			expected: []string{"failed", "skipped", "passed", "skipped"},
+		},
+		{
+			name: "a failure in an imported chain does not stop the importing chain",
+			tasks: Tasks{
+				{Name: "child", Cmd: "false", ImportedFrom: "child.yaml", ImportChain: childImport},
+				{Name: "grandchild", Cmd: "true", ImportedFrom: "grandchild.yaml", ImportChain: grandchildImport},
+				{Name: "other", Cmd: "true"},
+			},
+			expected: []string{"failed", "skipped", "passed"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			artifacts := t.TempDir()
+			builder := &taskCmdBuilder{env: map[string]string{}, vars: map[string]string{}}
+			runner := newTaskCmdRunner(StopChain)
+			for _, task := range tc.tasks {
+				task.Timeout.Duration = time.Minute
+				tcmd, err := builder.build(task, false)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				_ = runner.Run(tcmd, artifacts, false)
+			}
+
+			var results []string
+			for _, c := range runner.suite.Cases {
+				switch {
+				case c.Failure != "":
+					results = append(results, "failed")
+				case c.Skipped != "":
+					results = append(results, "skipped")
+				default:
+					results = append(results, "passed")
+				}
+			}
+			if !reflect.DeepEqual(results, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, results)
+			}
+		})
+	}
+}
+
+func TestValidateExecutionPolicy(t *testing.T) {
+	for _, p := range KnownExecutionPolicies() {
+		if err := ValidateExecutionPolicy(ExecutionPolicy(p)); err != nil {
+			t.Errorf("unexpected error for %s: %v", p, err)
+		}
+	}
+	if err := ValidateExecutionPolicy("StopAll"); err == nil {
+		t.Error("expected an error for an unknown execution policy")
+	}
+}
+
+func TestGracePeriod(t *testing.T) {
+	testCases := []struct {
+		name        string
+		script      string
+		gracePeriod time.Duration
+		minDuration time.Duration
+		maxDuration time.Duration
+	}{
+		{
+			name:        "task handling SIGTERM exits before the grace period",
+			script:      `trap "exit 0" TERM; sleep 30 & wait`,
+			gracePeriod: 10 * time.Second,
+			maxDuration: 5 * time.Second,
+		},
+		{
+			name:        "task ignoring SIGTERM is killed after the grace period",
+			script:      `trap "" TERM; sleep 30 & wait`,
+			gracePeriod: time.Second,
+			minDuration: time.Second,
+			maxDuration: 10 * time.Second,
+		},
+		{
+			name:        "task ignoring SIGTERM is killed immediately without grace period",
+			script:      `trap "" TERM; sleep 30 & wait`,
+			gracePeriod: 0,
+			maxDuration: 5 * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			task := &Task{Name: "task", Cmd: "sh", Args: []string{"-c", tc.script}}
+			task.Timeout.Duration = 200 * time.Millisecond
+			task.GracePeriod = &Duration{Duration: tc.gracePeriod}
+
+			builder := &taskCmdBuilder{env: map[string]string{}, vars: map[string]string{}}
+			tcmd, err := builder.build(task, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			runner := newTaskCmdRunner(FailFast)
+			start := time.Now()
+			if err := runner.Run(tcmd, t.TempDir(), false); err == nil {
+				t.Fatal("expected a timeout error")
+			}
+			duration := time.Since(start)
+
+			if duration < tc.minDuration || duration > tc.maxDuration {
+				t.Errorf("expected the task to complete in [%s, %s], got %s", tc.minDuration, tc.maxDuration, duration)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Validate checks a workflow without executing it, and reports all the errors found.
+// Parsing and import expansion are already checked by NewWorkflow; Validate additionally checks that
+// the templates used in vars, env and in the cmd, args, dir and artifacts of each task can be parsed, and that
+// they reference only vars defined in the workflow and env variables defined in the workflow or in the OS.
+// NB. templates are not executed, so e.g. the resolve function is not invoked.
+func (w *Workflow) Validate() error {
+	vars := map[string]bool{}
+	for k := range w.Vars {
+		vars[k] = true
+	}
+	env := map[string]bool{
+		"ARTIFACTS": true,
+	}
+	for _, e := range os.Environ() {
+		env[strings.SplitN(e, "=", 2)[0]] = true
+	}
+	for k := range w.Env {
+		env[k] = true
+	}
+
+	var errs []error
+	validate := func(text, location string) {
+		if err := validateTemplate(text, vars, env); err != nil {
+			errs = append(errs, errors.Wrap(err, location))
+		}
+	}
+
+	for _, k := range sortedKeys(w.Vars) {
+		validate(w.Vars[k], fmt.Sprintf("invalid %q var", k))
+	}
+	for _, k := range sortedKeys(w.Env) {
+		validate(w.Env[k], fmt.Sprintf("invalid %q env var", k))
+	}
+	for _, t := range w.Tasks {
+		validate(t.Cmd, fmt.Sprintf("invalid cmd for task %q", t.Name))
+		for i, a := range t.Args {
+			validate(a, fmt.Sprintf("invalid args[%d] for task %q", i, t.Name))
+		}
+		validate(t.Dir, fmt.Sprintf("invalid dir for task %q", t.Name))
+		for i, a := range t.Artifacts {
+			validate(a, fmt.Sprintf("invalid artifacts[%d] for task %q", i, t.Name))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// validateTemplate checks that a golang template can be parsed, and that all the fields it references
+// are known vars or env variables, that is {{ .vars.KEY }} or {{ .env.KEY }}
+func validateTemplate(text string, vars, env map[string]bool) error {
+	templ, err := template.New("").Funcs(funcMap).Parse(text)
+	if err != nil {
+		return errors.Wrapf(err, "%q is not a valid expression", text)
+	}
+	if templ.Tree == nil {
+		return nil
+	}
+
+	for _, ident := range templateFields(templ.Tree.Root) {
+		switch {
+		case ident[0] == "vars" && len(ident) > 1 && !vars[ident[1]]:
+			return errors.Errorf("expression %q references the undefined var %q", text, ident[1])
+		case ident[0] == "env" && len(ident) > 1 && !env[ident[1]]:
+			return errors.Errorf("expression %q references the undefined env var %q", text, ident[1])
+		case ident[0] != "vars" && ident[0] != "env":
+			return errors.Errorf("expression %q references the unknown field %q, only .vars and .env can be used", text, "."+ident[0])
+		}
+	}
+	return nil
+}
+
+// templateFields returns the identifiers of all the fields referenced in a template parse tree, e.g. [vars KEY]
+func templateFields(node parse.Node) [][]string {
+	var fields [][]string
+	var walk func(parse.Node)
+	walkBranch := func(n *parse.BranchNode) {
+		walk(n.Pipe)
+		walk(n.List)
+		walk(n.ElseList)
+	}
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Cmds {
+				walk(c)
+			}
+		case *parse.CommandNode:
+			for _, a := range n.Args {
+				walk(a)
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.FieldNode:
+			fields = append(fields, n.Ident)
+		case *parse.IfNode:
+			walkBranch(&n.BranchNode)
+		case *parse.RangeNode:
+			walkBranch(&n.BranchNode)
+		case *parse.WithNode:
+			walkBranch(&n.BranchNode)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		}
+	}
+	walk(node)
+	return fields
+}
+
+// sortedKeys returns the keys of a map in alphabetical order, so errors are reported in a stable order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
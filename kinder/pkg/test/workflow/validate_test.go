@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"strings"
+	"testing"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+func TestValidate(t *testing.T) {
+	t.Setenv("KINDER_VALIDATE_TEST", "foo")
+
+	testCases := []struct {
+		name           string
+		workflow       *Workflow
+		expectedErrors []string
+	}{
+		{
+			name: "valid workflow",
+			workflow: &Workflow{
+				Vars: map[string]string{"version": `{{ resolve "ci/latest" }}`},
+				Env:  map[string]string{"VERSION": "{{ .vars.version }}"},
+				Tasks: Tasks{
+					{
+						Name: "task-00",
+						Cmd:  "kinder",
+						Args: []string{"--version={{ .env.VERSION }}", "{{ if .env.KINDER_VALIDATE_TEST }}--verbose{{ end }}"},
+						Dir:  "{{ .env.ARTIFACTS }}",
+					},
+				},
+			},
+		},
+		{
+			name: "all the errors are reported",
+			workflow: &Workflow{
+				Vars: map[string]string{"version": "{{ .vars.missing }}"},
+				Env:  map[string]string{"VERSION": "{{ .env.MISSING_KINDER_VALIDATE_TEST }}"},
+				Tasks: Tasks{
+					{
+						Name: "task-00",
+						Cmd:  "{{ .foo }}",
+						Args: []string{"{{ .vars.version", "{{ unknown }}"},
+					},
+				},
+			},
+			expectedErrors: []string{
+				`invalid "version" var`,
+				`invalid "VERSION" env var`,
+				`invalid cmd for task "task-00"`,
+				`invalid args[0] for task "task-00"`,
+				`invalid args[1] for task "task-00"`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.workflow.Validate()
+			if len(tc.expectedErrors) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			agg, ok := err.(utilerrors.Aggregate)
+			if !ok {
+				t.Fatalf("expected an aggregate error, got %v", err)
+			}
+			if len(agg.Errors()) != len(tc.expectedErrors) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tc.expectedErrors), len(agg.Errors()), err)
+			}
+			for i, e := range agg.Errors() {
+				if !strings.HasPrefix(e.Error(), tc.expectedErrors[i]) {
+					t.Errorf("expected error %d to start with %q, got %q", i, tc.expectedErrors[i], e.Error())
+				}
+			}
+		})
+	}
+}
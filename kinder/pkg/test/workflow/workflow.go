@@ -22,7 +22,7 @@ can be any command, thus including also any kinder commands invoked via CLI.
 
 Tasks will be executed in order; in case of errors the workflow will stop and the remaining tasks
 will be skipped with the only exception of tasks specifically marked to be executed in any case
-(e.g. cleanup tasks).
+(e.g. cleanup tasks). How failures propagate to the remaining tasks can be changed by using an ExecutionPolicy.
 */
 package workflow
 
@@ -37,6 +37,7 @@ import (
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/yaml"
 )
 
@@ -65,13 +66,18 @@ type Workflow struct {
 	// Env variables can be used for golang template expansion using {{ .env.KEY }}
 	Env map[string]string
 
+	// PreFlight defines a set of checks on the environment, e.g. required commands, images or disk space,
+	// to be executed before running the workflow tasks
+	PreFlight *PreFlight
+
 	// Tasks defines the list of tasks to be executed during test workflow
 	Tasks Tasks
 }
 
 // Tasks represents a list of tasks to be executed during test workflow.
 // Task are executed in order; if a task fails, timeouts or it is canceled by the user,
-// following task are skipped (unless execution is explicitly forced on a specific task)
+// following task are skipped (unless execution is explicitly forced on a specific task);
+// see ExecutionPolicy for changing how task failures propagate.
 type Tasks []*Task
 
 // Task represents a task to be executed as part of a test workflow
@@ -82,9 +88,12 @@ type Task struct {
 	// Description of the task
 	Description string
 
-	// Dir allows to set the working directory for this tasks
+	// Dir allows to set the working directory for this tasks; it can be a literal or a template
 	Dir string
 
+	// CreateDir sets the working directory to be created if it does not exist
+	CreateDir bool `yaml:"createDir"`
+
 	// Cmd to execute; it can be a literal or a template
 	Cmd string
 
@@ -101,8 +110,72 @@ type Task struct {
 	// Timeout for the current task, 5m by default
 	Timeout Duration
 
+	// GracePeriod defines how long a task is given for cleaning up after SIGTERM when it times out or it is canceled,
+	// before being killed with SIGKILL, 10s by default; a grace period of 0s kills the task immediately
+	GracePeriod *Duration `yaml:"gracePeriod"`
+
 	// IgnoreError sets a task to be recorded as successful even if it is actually failed
 	IgnoreError bool `yaml:"ignoreError"`
+
+	// Artifacts defines a list of glob paths, relative to Dir, of files to be collected into the workflow
+	// artifacts folder after the task completes, no matter of the task result; e.g. logs or configs
+	// generated by the task. Each path can be a literal or a template
+	Artifacts []string
+
+	// ImportedFrom is the import path of the workflow file that defines this task, if the task was imported;
+	// in case of nested imports, the innermost import path is recorded.
+	// NB. this field is set by kinder while expanding imports, and it can't be set in workflow files
+	ImportedFrom string `json:"-"`
+
+	// ImportChain is the list of import paths that lead to the workflow file that defines this task, if the task
+	// was imported, from the outermost to the innermost; the last import path is the same as ImportedFrom.
+	// NB. this field is set by kinder while expanding imports, and it can't be set in workflow files
+	ImportChain []string `json:"-"`
+}
+
+// defaultGracePeriod defines the default grace period for tasks that time out or are canceled
+const defaultGracePeriod = 10 * time.Second
+
+// ExecutionPolicy defines how a task failure propagates to the remaining tasks of a workflow.
+// A task failure is a task failing or timing out; tasks with IgnoreError are never considered failed,
+// and tasks with Force are always executed, no matter of the execution policy; if a workflow is canceled
+// by the user, all the remaining tasks without Force are skipped, no matter of the execution policy.
+type ExecutionPolicy string
+
+const (
+	// FailFast skips all the remaining tasks after a task failure; this is the default execution policy
+	FailFast = ExecutionPolicy("FailFast")
+
+	// ContinueOnError executes all the remaining tasks after a task failure, and reports the failure at the end of the workflow
+	ContinueOnError = ExecutionPolicy("ContinueOnError")
+
+	// StopChain skips the remaining tasks of the same chain after a task failure, while the tasks of other chains
+	// are still executed. Each workflow file defines a chain, so tasks imported from a workflow file form a chain
+	// distinct from the tasks of the importing workflow file; tasks of a chain are grouped in the junit_runner.xml
+	// file using the same classname. Imported chains depend on the importing chain, so after a task failure also
+	// the remaining tasks of the chains imported, directly or indirectly, by the chain of the failed task are skipped.
+	StopChain = ExecutionPolicy("StopChain")
+)
+
+// KnownExecutionPolicies returns the list of known ExecutionPolicy
+func KnownExecutionPolicies() []string {
+	return []string{
+		string(FailFast),
+		string(ContinueOnError),
+		string(StopChain),
+	}
+}
+
+// ValidateExecutionPolicy validates an ExecutionPolicy
+func ValidateExecutionPolicy(p ExecutionPolicy) error {
+	switch p {
+	case FailFast:
+	case ContinueOnError:
+	case StopChain:
+	default:
+		return errors.Errorf("invalid execution policy %q. Use one of %s", p, KnownExecutionPolicies())
+	}
+	return nil
 }
 
 // Duration is a wrapper around time.Duration to satisfy the encoding/json Marshaller
@@ -142,6 +215,12 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 
 // NewWorkflow creates a new workflow as defined in a workflow file
 func NewWorkflow(file string) (*Workflow, error) {
+	return newWorkflow(file, nil)
+}
+
+// newWorkflow creates a new workflow as defined in a workflow file; importChain is the list of
+// workflow files importing the current file, and it is used for detecting circular imports
+func newWorkflow(file string, importChain []string) (*Workflow, error) {
 	// Checks if the workflow file exists
 	if _, err := os.Stat(file); err != nil {
 		return nil, errors.Errorf("invalid workflow file: %s does not exist", file)
@@ -161,18 +240,26 @@ func NewWorkflow(file string) (*Workflow, error) {
 	// checks minimum requirements
 	// - version is set and well know
 	// - at least one task exists
+	// NB. all the errors found are reported, so workflow authors can fix them at once
 
+	var errs []error
 	if w.Version != 1 {
-		return nil, errors.Errorf("invalid taskfile %s: version does not contain a supported value", file)
+		errs = append(errs, errors.Errorf("invalid taskfile %s: version does not contain a supported value", file))
 	}
 
 	if len(w.Tasks) == 0 {
-		return nil, errors.Errorf("invalid taskfile %s: at least one task should be defined", file)
+		errs = append(errs, errors.Errorf("invalid taskfile %s: at least one task should be defined", file))
+	}
+
+	if w.PreFlight != nil {
+		if err := w.PreFlight.validate(); err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid taskfile %s", file))
+		}
 	}
 
 	// Detect and resolve imports by expanding imported workflows into the top level workflow
-	if err := w.expandImports(file); err != nil {
-		return nil, err
+	if err := w.expandImports(file, importChain); err != nil {
+		errs = append(errs, err)
 	}
 
 	// For each task
@@ -191,17 +278,37 @@ func NewWorkflow(file string) (*Workflow, error) {
 			t.Timeout.Duration = time.Duration(5 * time.Minute)
 		}
 
+		// if a grace period is not defined, assign a default one; an explicit 0s disables the grace period.
+		// nb. the grace period is short, but it allows e.g. kubeadm to cleanup before being killed
+		if t.GracePeriod == nil {
+			t.GracePeriod = &Duration{Duration: defaultGracePeriod}
+		}
+		if t.GracePeriod.Duration < 0 {
+			errs = append(errs, errors.Errorf("invalid taskfile %s: task %q defines a negative gracePeriod", file, t.Name))
+		}
+
 		// check if the task defines a cmd
 		if t.Cmd == "" {
-			return nil, errors.Errorf("invalid taskfile %s: task %q does not define a cmd", file, t.Name)
+			errs = append(errs, errors.Errorf("invalid taskfile %s: task %q does not define a cmd", file, t.Name))
 		}
 	}
 
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return nil, err
+	}
+
 	return &w, nil
 }
 
 // expandImports imports a secondary workflow into the top level Workflow
-func (w *Workflow) expandImports(file string) error {
+func (w *Workflow) expandImports(file string, importChain []string) error {
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return errors.Wrapf(err, "error getting the absolute path of workflow file %s", file)
+	}
+	importChain = append(importChain[:len(importChain):len(importChain)], absFile)
+
+	var errs []error
 	tasks := w.Tasks
 	w.Tasks = Tasks{}
 	for i, t := range tasks {
@@ -213,41 +320,67 @@ func (w *Workflow) expandImports(file string) error {
 
 		// otherwise it is an import task
 		// ensure the import task does not have other settings
+		settingErrs := len(errs)
 		if t.Dir != "" {
-			return errors.Errorf("invalid workflow file %s: task #%d - dir setting can't be combined with import directive", file, i+1)
+			errs = append(errs, errors.Errorf("invalid workflow file %s: task #%d - dir setting can't be combined with import directive", file, i+1))
+		}
+		if t.CreateDir {
+			errs = append(errs, errors.Errorf("invalid workflow file %s: task #%d - createDir setting can't be combined with import directive", file, i+1))
 		}
 		if t.Cmd != "" {
-			return errors.Errorf("invalid workflow file %s: task #%d - cmd setting can't be combined with import directive", file, i+1)
+			errs = append(errs, errors.Errorf("invalid workflow file %s: task #%d - cmd setting can't be combined with import directive", file, i+1))
 		}
 		if len(t.Args) != 0 {
-			return errors.Errorf("invalid workflow file %s: task #%d - args setting can't be combined with import directive", file, i+1)
+			errs = append(errs, errors.Errorf("invalid workflow file %s: task #%d - args setting can't be combined with import directive", file, i+1))
 		}
 		if t.Force {
-			return errors.Errorf("invalid workflow file %s: task #%d - force setting can't be combined with import directive", file, i+1)
+			errs = append(errs, errors.Errorf("invalid workflow file %s: task #%d - force setting can't be combined with import directive", file, i+1))
 		}
 		if t.Timeout.Duration != 0 {
-			return errors.Errorf("invalid workflow file %s: task #%d - timeout setting can't be combined with import directive", file, i+1)
+			errs = append(errs, errors.Errorf("invalid workflow file %s: task #%d - timeout setting can't be combined with import directive", file, i+1))
+		}
+		if t.GracePeriod != nil {
+			errs = append(errs, errors.Errorf("invalid workflow file %s: task #%d - gracePeriod setting can't be combined with import directive", file, i+1))
 		}
 		if t.IgnoreError {
-			return errors.Errorf("invalid workflow file %s: task #%d - ignoreError setting can't be combined with import directive", file, i+1)
+			errs = append(errs, errors.Errorf("invalid workflow file %s: task #%d - ignoreError setting can't be combined with import directive", file, i+1))
+		}
+		if len(t.Artifacts) != 0 {
+			errs = append(errs, errors.Errorf("invalid workflow file %s: task #%d - artifacts setting can't be combined with import directive", file, i+1))
+		}
+
+		if len(errs) > settingErrs {
+			continue
 		}
 
 		// reads the Import file
 		// if path are relative, consider as a base path the folder where the importing file is located.
-		// TODO: implement a check for avoiding circular imports
 		path := t.Import
 		if !filepath.IsAbs(path) {
 			base := filepath.Dir(file)
 			path = filepath.Join(base, path)
 		}
-		wx, err := NewWorkflow(path)
+		absPath, err := filepath.Abs(path)
 		if err != nil {
-			return errors.Wrapf(err, "error importing workflow file %s", path)
+			errs = append(errs, errors.Wrapf(err, "error getting the absolute path of workflow file %s", path))
+			continue
+		}
+		if isImported(importChain, absPath) {
+			errs = append(errs, errors.Errorf("invalid workflow file %s: task #%d - circular import of workflow file %s", file, i+1, path))
+			continue
+		}
+		wx, err := newWorkflow(path, importChain)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "error importing workflow file %s", path))
+			continue
 		}
 
 		// merge the vars from the import file into the parent file
 		// in case of conflicts, vars in the parent file will shadow vars in the import file
 		for k, v := range wx.Vars {
+			if w.Vars == nil {
+				w.Vars = map[string]string{}
+			}
 			if _, ok := w.Vars[k]; !ok {
 				w.Vars[k] = v
 				continue
@@ -258,6 +391,9 @@ func (w *Workflow) expandImports(file string) error {
 		// merge the env vars from the import file into the parent file
 		// in case of conflicts, env vars in the parent file will shadow env vars in the import file
 		for k, v := range wx.Env {
+			if w.Env == nil {
+				w.Env = map[string]string{}
+			}
 			if _, ok := w.Env[k]; !ok {
 				w.Env[k] = v
 				continue
@@ -265,19 +401,52 @@ func (w *Workflow) expandImports(file string) error {
 			log.Debugf("env var %s in workflow file %s is shadowed by env var %[1]s in parent workflow file %[3]s", k, path, file)
 		}
 
+		// merge the pre-flight checks from the import file into the parent file
+		if wx.PreFlight != nil {
+			if w.PreFlight == nil {
+				w.PreFlight = &PreFlight{}
+			}
+			w.PreFlight.merge(wx.PreFlight)
+		}
+
 		// import all tasks from the import file into the parent file, removing task name prefix
 		re := regexp.MustCompile(`^task\-\d{2}\-?`)
 		for _, tx := range wx.Tasks {
 			tx.Name = re.ReplaceAllString(tx.Name, "")
+			if tx.ImportedFrom == "" {
+				tx.ImportedFrom = t.Import
+			}
+			tx.ImportChain = append([]string{t.Import}, tx.ImportChain...)
 			w.Tasks = append(w.Tasks, tx)
 		}
 	}
 
-	return nil
+	return utilerrors.NewAggregate(errs)
+}
+
+// isImported returns true if the workflow file is already part of the import chain
+func isImported(importChain []string, absFile string) bool {
+	for _, f := range importChain {
+		if f == absFile {
+			return true
+		}
+	}
+	return false
 }
 
-// Run executes a workflow
-func (w *Workflow) Run(out io.Writer, dryRun, verbose, exitOnError bool, artifacts string) (err error) {
+// Run executes a workflow using the given execution policy, FailFast if empty.
+// If exitOnError is set, Run returns after the first task failure without executing any other task,
+// including tasks with Force; exitOnError can be used only with the FailFast execution policy.
+func (w *Workflow) Run(out io.Writer, dryRun, verbose, exitOnError bool, policy ExecutionPolicy, artifacts string) (err error) {
+	if policy == "" {
+		policy = FailFast
+	}
+	if err := ValidateExecutionPolicy(policy); err != nil {
+		return err
+	}
+	if exitOnError && policy != FailFast {
+		return errors.Errorf("exit on error can't be combined with the %s execution policy", policy)
+	}
 
 	// get a new taskCmdBuilder, responsible for creating taskCmd commands
 	taskCmdBuilder, err := newTaskCmdBuilder(w)
@@ -323,7 +492,7 @@ func (w *Workflow) Run(out io.Writer, dryRun, verbose, exitOnError bool, artifac
 	// Gets a taskCmdRunner, responsible for executing taskCmd,
 	// handling failure, cancellation, timeouts and for generating or collecting
 	// all the workflow artifacts (junit_runner.xml, task logs, etc)
-	taskCmdRunner := newTaskCmdRunner()
+	taskCmdRunner := newTaskCmdRunner(policy)
 
 	// Process all tasks, exploding golang templates for cmd and args
 	// and create the corresponding taskCmd
@@ -340,6 +509,15 @@ func (w *Workflow) Run(out io.Writer, dryRun, verbose, exitOnError bool, artifac
 		tcmds = append(tcmds, tcmd)
 	}
 
+	// Executes pre-flight checks (if any), aborting the workflow before executing
+	// any task if the environment does not satisfy the workflow requirements
+	if w.PreFlight != nil && !dryRun {
+		fmt.Fprintf(out, "# preflight\n")
+		if err := w.PreFlight.run(out, taskCmdBuilder, artifacts); err != nil {
+			return errors.Wrap(err, "the environment does not satisfy the workflow pre-flight checks")
+		}
+	}
+
 	foundError := false
 	// Executes taskCmds
 	for _, tcmd := range tcmds {
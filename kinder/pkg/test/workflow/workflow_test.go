@@ -19,6 +19,10 @@ package workflow
 import (
 	"bytes"
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -102,3 +106,173 @@ func TestDurationJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestImportedTasksClassName(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"parent.yaml": `version: 1
+tasks:
+- name: setup
+  cmd: "true"
+- import: child-tasks.yaml
+`,
+		"child-tasks.yaml": `version: 1
+tasks:
+- name: child
+  cmd: "true"
+- import: nested/grandchild.tasks.yaml
+`,
+		"nested/grandchild.tasks.yaml": `version: 1
+tasks:
+- name: grandchild
+  cmd: "true"
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := NewWorkflow(filepath.Join(dir, "parent.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"kinder.test.workflow",
+		"kinder.test.workflow.child-tasks",
+		"kinder.test.workflow.grandchild-tasks",
+	}
+	if len(w.Tasks) != len(expected) {
+		t.Fatalf("expected %d tasks, got %d", len(expected), len(w.Tasks))
+	}
+	for i, task := range w.Tasks {
+		if className := junitClassName(task); className != expected[i] {
+			t.Errorf("task %s: expected classname %q, got %q", task.Name, expected[i], className)
+		}
+	}
+
+	expectedImportChain := []string{"child-tasks.yaml", "nested/grandchild.tasks.yaml"}
+	if !reflect.DeepEqual(w.Tasks[2].ImportChain, expectedImportChain) {
+		t.Errorf("expected import chain %v, got %v", expectedImportChain, w.Tasks[2].ImportChain)
+	}
+}
+
+func TestCircularImports(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"parent.yaml": `version: 1
+tasks:
+- import: child.yaml
+`,
+		"child.yaml": `version: 1
+tasks:
+- name: child
+  cmd: "true"
+- import: parent.yaml
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := NewWorkflow(filepath.Join(dir, "parent.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "circular import") {
+		t.Errorf("expected a circular import error, got %v", err)
+	}
+}
+
+func TestNewWorkflowReportsAllErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"parent.yaml": `version: 2
+tasks:
+- name: no-cmd
+- import: child.yaml
+  force: true
+- import: missing.yaml
+`,
+		"child.yaml": `version: 1
+tasks:
+- name: child
+  cmd: "true"
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := NewWorkflow(filepath.Join(dir, "parent.yaml"))
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	for _, expected := range []string{
+		"version does not contain a supported value",
+		"force setting can't be combined with import directive",
+		"error importing workflow file",
+		"does not define a cmd",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestGracePeriodDefault(t *testing.T) {
+	testCases := []struct {
+		name                string
+		task                string
+		expectedGracePeriod time.Duration
+		expectedError       bool
+	}{
+		{
+			name:                "grace period not defined",
+			task:                "- cmd: \"true\"\n",
+			expectedGracePeriod: defaultGracePeriod,
+		},
+		{
+			name:                "grace period defined",
+			task:                "- cmd: \"true\"\n  gracePeriod: 30s\n",
+			expectedGracePeriod: 30 * time.Second,
+		},
+		{
+			name:                "grace period disabled",
+			task:                "- cmd: \"true\"\n  gracePeriod: 0s\n",
+			expectedGracePeriod: 0,
+		},
+		{
+			name:          "negative grace period",
+			task:          "- cmd: \"true\"\n  gracePeriod: -1s\n",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "workflow.yaml")
+			if err := os.WriteFile(file, []byte("version: 1\ntasks:\n"+tc.task), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			w, err := NewWorkflow(file)
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("expected error: %v, got: %v", tc.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if d := w.Tasks[0].GracePeriod.Duration; d != tc.expectedGracePeriod {
+				t.Errorf("expected grace period %s, got %s", tc.expectedGracePeriod, d)
+			}
+		})
+	}
+}
@@ -105,7 +105,7 @@ var (
 	archList = []string{"amd64", "arm", "arm64", "ppc64le", "s390x"}
 	// status of images is cached here, so that the same image is not
 	// tested by multiple tests.
-	verifiedImageCache = make(map[string]error)
+	verifiedImageCache = make(map[string]ImageReport)
 	// define a map where the keys are the first unseported version and the values are slices of architectures to be removed
 	architecturesToRemove = map[string][]string{
 		"1.27.0-beta.0": []string{"arm"},
@@ -147,6 +147,76 @@ type manifestList struct {
 	Manifests     []manifest `json:"manifests"`
 }
 
+// results of the verification.
+
+// ArchResult is the result of the verification of an image for a single architecture.
+type ArchResult struct {
+	Arch string `json:"arch"`
+	// Error is empty if the image for the architecture passed the verification.
+	Error string `json:"error,omitempty"`
+}
+
+// ImageReport is the result of the verification of an image:tag.
+type ImageReport struct {
+	Image string `json:"image"`
+	Tag   string `json:"tag"`
+	// Arches contains the result for each architecture found in the manifest list
+	// or required but missing from it.
+	Arches []ArchResult `json:"arches,omitempty"`
+	// Error is set if the image failed the verification for reasons not related to a specific
+	// architecture, e.g. the manifest list could not be downloaded or parsed.
+	Error string `json:"error,omitempty"`
+}
+
+// ImageTag returns the image:tag of the report.
+func (r ImageReport) ImageTag() string {
+	return fmt.Sprintf("%s:%s", r.Image, r.Tag)
+}
+
+// Passed returns true if the image passed the verification for all the architectures.
+func (r ImageReport) Passed() bool {
+	if r.Error != "" {
+		return false
+	}
+	for _, a := range r.Arches {
+		if a.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// failures returns a description of the errors of the image.
+func (r ImageReport) failures() []string {
+	failures := []string{}
+	if r.Error != "" {
+		failures = append(failures, r.Error)
+	}
+	for _, a := range r.Arches {
+		if a.Error != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", a.Arch, a.Error))
+		}
+	}
+	return failures
+}
+
+// VersionReport is the result of the verification of all the images for a k8s version.
+type VersionReport struct {
+	Version string        `json:"version"`
+	Images  []ImageReport `json:"images"`
+}
+
+// FailedImages returns the image:tag of the images that failed the verification.
+func (r VersionReport) FailedImages() []string {
+	failed := []string{}
+	for _, i := range r.Images {
+		if !i.Passed() {
+			failed = append(failed, i.ImageTag())
+		}
+	}
+	return failed
+}
+
 // download progress tracking.
 
 type writeCounter struct {
@@ -199,8 +269,17 @@ func (s VersionList) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
 
+// Less sorts versions in descending order; versions that are equal according to the semver precedence rules,
+// e.g. versions differing only in build metadata, are sorted by their string representation in descending order,
+// so the output is the same across runs.
 func (s VersionList) Less(i, j int) bool {
-	return s[j].LessThan(s[i])
+	if s[j].LessThan(s[i]) {
+		return true
+	}
+	if s[i].LessThan(s[j]) {
+		return false
+	}
+	return s[i].String() > s[j].String()
 }
 
 // throw an error.
@@ -452,17 +531,18 @@ func verifyArchImage(arch, imageName, archImage string) error {
 }
 
 // verify a manifest list and match the required architectures.
-func verifyManifestList(manifest, imageName, tag string, ver *version.Version) error {
+// the result for each architecture is returned; the error is set only if the manifest list itself is not valid.
+func verifyManifestList(manifest, imageName, tag string, ver *version.Version) ([]ArchResult, error) {
 	ml := manifestList{}
 	if err := json.Unmarshal([]byte(manifest), &ml); err != nil {
-		return err
+		return nil, err
 	}
 
 	if ml.SchemaVersion != 2 {
-		return errors.New("manifest is not schemaVersion 2")
+		return nil, errors.New("manifest is not schemaVersion 2")
 	}
 	if ml.MediaType != typeManifestList {
-		return fmt.Errorf("not a manifest list: %s", ml.MediaType)
+		return nil, fmt.Errorf("not a manifest list: %s", ml.MediaType)
 	}
 	aList := make([]string, len(archList))
 	// copy into a temp slice.
@@ -492,6 +572,7 @@ func verifyManifestList(manifest, imageName, tag string, ver *version.Version) e
 	}
 
 	// traverse the manifests in the list.
+	results := []ArchResult{}
 	for _, m := range ml.Manifests {
 		// skip unknown arches
 		known := false
@@ -507,14 +588,6 @@ func verifyManifestList(manifest, imageName, tag string, ver *version.Version) e
 			continue
 		}
 
-		// verify media type and digest.
-		if m.MediaType != typeManifest {
-			return fmt.Errorf("unknown media type: %s, manifest: %#v", m.MediaType, m)
-		}
-		if m.Digest == "" {
-			return fmt.Errorf("empty digest for manifest: %#v", m)
-		}
-
 		printLineSeparator('-')
 		fmt.Printf("* verifyManifestList(): verifying image: %s-%s:%s\n", imageName, m.Platform.Architecture, tag)
 
@@ -527,38 +600,55 @@ func verifyManifestList(manifest, imageName, tag string, ver *version.Version) e
 			aList = aList[1:]
 		}
 
-		// download the arch minifest and verify its size.
-		url := fmt.Sprintf("%s/%s/manifests/%s", gcrBucket, imageName, m.Digest)
-		archImageSrc, _, err := getFromURL(url)
-		if err != nil {
-			return fmt.Errorf("cannot download manifest for arch %q: %v", m.Platform.Architecture, err)
-		}
-		sz := len(archImageSrc)
-		if m.Size != sz {
-			return fmt.Errorf("manifest size differs for arch %q; wanted: %d, got: %d", m.Platform.Architecture, m.Size, sz)
+		// verify the arch manifest and image; in case of errors continue with the next arch.
+		result := ArchResult{Arch: m.Platform.Architecture}
+		if err := verifyArchManifest(m, imageName); err != nil {
+			fmt.Printf("* verifyManifestList(): ERROR: %s-%s:%s; error: %v\n", imageName, m.Platform.Architecture, tag, err)
+			result.Error = err.Error()
 		}
+		results = append(results, result)
+	}
 
-		// verify the arch image.
-		err = verifyArchImage(m.Platform.Architecture, imageName, archImageSrc)
-		if err != nil {
-			return err
-		}
+	// record the required arches that are missing from the manifest list.
+	for _, arch := range aList {
+		results = append(results, ArchResult{Arch: arch, Error: "did not find a match for this architecture"})
 	}
 
-	if len(aList) > 0 {
-		return fmt.Errorf("did not find a match for these architectures: %s", strings.Join(aList, ", "))
+	return results, nil
+}
+
+// verify the manifest for an architecture of a manifest list and the corresponding image.
+func verifyArchManifest(m manifest, imageName string) error {
+	// verify media type and digest.
+	if m.MediaType != typeManifest {
+		return fmt.Errorf("unknown media type: %s, manifest: %#v", m.MediaType, m)
+	}
+	if m.Digest == "" {
+		return fmt.Errorf("empty digest for manifest: %#v", m)
 	}
 
-	return nil
+	// download the arch minifest and verify its size.
+	url := fmt.Sprintf("%s/%s/manifests/%s", gcrBucket, imageName, m.Digest)
+	archImageSrc, _, err := getFromURL(url)
+	if err != nil {
+		return fmt.Errorf("cannot download manifest for arch %q: %v", m.Platform.Architecture, err)
+	}
+	sz := len(archImageSrc)
+	if m.Size != sz {
+		return fmt.Errorf("manifest size differs for arch %q; wanted: %d, got: %d", m.Platform.Architecture, m.Size, sz)
+	}
+
+	// verify the arch image.
+	return verifyArchImage(m.Platform.Architecture, imageName, archImageSrc)
 }
 
 // verify all images for a given k8s version.
-func verifyKubernetesVersion(ver *version.Version) ([]string, error) {
-	missingImages := []string{}
+func verifyKubernetesVersion(ver *version.Version) (VersionReport, error) {
+	report := VersionReport{Version: ver.String()}
 
 	images := make(map[string]string)
 	if err := getImageVersions(ver, images); err != nil {
-		return missingImages, err
+		return report, err
 	}
 
 	keys := make([]string, 0, len(images))
@@ -570,7 +660,8 @@ func verifyKubernetesVersion(ver *version.Version) ([]string, error) {
 	// download and process a manifest for each image:tag.
 	for _, k := range keys {
 		printLineSeparator('=')
-		imageTag := fmt.Sprintf("%s:%s", k, images[k])
+		imageReport := ImageReport{Image: k, Tag: images[k]}
+		imageTag := imageReport.ImageTag()
 		fmt.Printf("* verifyManifestList(): %s\n", imageTag)
 
 		url := fmt.Sprintf("%s/%s/manifests/%s", gcrBucket, k, images[k])
@@ -578,33 +669,37 @@ func verifyKubernetesVersion(ver *version.Version) ([]string, error) {
 
 		if err != nil {
 			fmt.Printf("* ERROR: %v\n", err)
-			missingImages = append(missingImages, imageTag)
+			imageReport.Error = err.Error()
+			report.Images = append(report.Images, imageReport)
 			continue
 		}
 
 		// attempt to fetch result from cache.
-		if err, ok := verifiedImageCache[imageTag]; ok {
-			if err != nil {
-				fmt.Printf("\n* ERROR(cached result): %s; error: %v\n", imageTag, err)
-				missingImages = append(missingImages, imageTag)
+		if cached, ok := verifiedImageCache[imageTag]; ok {
+			if !cached.Passed() {
+				fmt.Printf("\n* ERROR(cached result): %s\n", imageTag)
 			} else {
 				fmt.Printf("\n* PASSED(cached result): %s\n", imageTag)
 			}
+			report.Images = append(report.Images, cached)
 			continue
 		}
 
 		// uncached; run tests
-		if err = verifyManifestList(manifest, k, images[k], ver); err != nil {
-			fmt.Printf("\n* ERROR: %s; error: %v\n", imageTag, err)
-			missingImages = append(missingImages, imageTag)
-			verifiedImageCache[imageTag] = err
+		imageReport.Arches, err = verifyManifestList(manifest, k, images[k], ver)
+		if err != nil {
+			imageReport.Error = err.Error()
+		}
+		if !imageReport.Passed() {
+			fmt.Printf("\n* ERROR: %s; failed: %s\n", imageTag, strings.Join(imageReport.failures(), "; "))
 		} else {
 			fmt.Printf("\n* PASSED: %s\n", imageTag)
-			verifiedImageCache[imageTag] = nil
 		}
+		verifiedImageCache[imageTag] = imageReport
+		report.Images = append(report.Images, imageReport)
 	}
 
-	return missingImages, nil
+	return report, nil
 }
 
 // gets the k8s tags from github and parses them.
@@ -733,12 +828,12 @@ func main() {
 		printLineSeparator('#')
 		fmt.Println()
 
-		missingImages, err := verifyKubernetesVersion(v)
+		report, err := verifyKubernetesVersion(v)
 		if err != nil {
 			fmt.Printf("\n* ERROR: could not process version %q: %s\n", v.String(), err)
 			continue
 		}
-		if len(missingImages) > 0 {
+		if missingImages := report.FailedImages(); len(missingImages) > 0 {
 			fmt.Printf("\n* ERROR: the following images have manifest lists errors for version %q: %s\n", v.String(), strings.Join(missingImages, ", "))
 			versionsWithErrors = append(versionsWithErrors, v.String())
 		} else {
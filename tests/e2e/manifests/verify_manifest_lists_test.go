@@ -99,6 +99,33 @@ func TestSemVerListSort(t *testing.T) {
 	}
 }
 
+func TestSemVerListSortBuildMetadata(t *testing.T) {
+	inputs := []VersionList{
+		{
+			version.MustParseSemantic("1.12.2+b"),
+			version.MustParseSemantic("1.12.3"),
+			version.MustParseSemantic("1.12.2+a"),
+			version.MustParseSemantic("1.12.2+c"),
+		},
+		{
+			version.MustParseSemantic("1.12.2+c"),
+			version.MustParseSemantic("1.12.2+a"),
+			version.MustParseSemantic("1.12.3"),
+			version.MustParseSemantic("1.12.2+b"),
+		},
+	}
+	expected := []string{"1.12.3", "1.12.2+c", "1.12.2+b", "1.12.2+a"}
+
+	for i, input := range inputs {
+		input.sort()
+		for j, v := range expected {
+			if input[j].String() != v {
+				t.Fatalf("input #%d, element %d does not match: expected: %s, got: %s\n", i, j, v, input[j].String())
+			}
+		}
+	}
+}
+
 func TestFilterVersions(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -181,3 +208,60 @@ func TestFilterVersions(t *testing.T) {
 		})
 	}
 }
+
+func TestVersionReportFailedImages(t *testing.T) {
+	report := VersionReport{
+		Version: "1.31.0",
+		Images: []ImageReport{
+			{
+				Image:  "kube-apiserver",
+				Tag:    "v1.31.0",
+				Arches: []ArchResult{{Arch: "amd64"}, {Arch: "arm64"}},
+			},
+			{
+				Image:  "kube-proxy",
+				Tag:    "v1.31.0",
+				Arches: []ArchResult{{Arch: "amd64"}, {Arch: "s390x", Error: "layer size differs"}},
+			},
+			{
+				Image: "etcd",
+				Tag:   "3.5.15-0",
+				Error: "responded with status: 404",
+			},
+		},
+	}
+
+	expected := []string{"kube-proxy:v1.31.0", "etcd:3.5.15-0"}
+	failed := report.FailedImages()
+	if len(failed) != len(expected) {
+		t.Fatalf("expected failed images %v, got %v", expected, failed)
+	}
+	for i := range expected {
+		if failed[i] != expected[i] {
+			t.Errorf("expected failed images %v, got %v", expected, failed)
+		}
+	}
+}
+
+func TestVerifyManifestListMissingArches(t *testing.T) {
+	manifest := `{"schemaVersion": 2, "mediaType": "` + typeManifestList + `", "manifests": []}`
+	results, err := verifyManifestList(manifest, "pause", "3.10", version.MustParseSemantic("1.31.0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// arm is not required since v1.27.0-beta.0
+	expected := []string{"amd64", "arm64", "ppc64le", "s390x"}
+	if len(results) != len(expected) {
+		t.Fatalf("expected results for %v, got %#v", expected, results)
+	}
+	for i, r := range results {
+		if r.Arch != expected[i] || r.Error == "" {
+			t.Errorf("expected a failure for arch %s, got %#v", expected[i], r)
+		}
+	}
+
+	if _, err := verifyManifestList(`{"schemaVersion": 1}`, "pause", "3.10", version.MustParseSemantic("1.31.0")); err == nil {
+		t.Error("expected an error for an invalid manifest list")
+	}
+}